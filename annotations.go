@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/kobi2187/go2json/astjson"
+)
+
+// sidecarAnnotations holds the annotations loaded with -annotations, or nil.
+var sidecarAnnotations *annotationSet
+
+// annotationSet maps node IDs to user-supplied annotation objects and records
+// which of them were merged into an output tree.
+type annotationSet struct {
+	byID   map[string]jsoniter.RawMessage
+	mu     sync.Mutex // guards merged, for -jobs
+	merged map[string]bool
+}
+
+// loadAnnotations reads a sidecar file holding a JSON object that maps node IDs
+// (as assigned by -ids) to arbitrary JSON values.
+func loadAnnotations(path string) (*annotationSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading annotations %s: %w", path, err)
+	}
+	set := &annotationSet{merged: make(map[string]bool)}
+	var json = jsoniter.ConfigCompatibleWithStandardLibrary
+	if err := json.Unmarshal(data, &set.byID); err != nil {
+		return nil, fmt.Errorf("error decoding annotations %s: %w", path, err)
+	}
+	return set, nil
+}
+
+// merge attaches the annotations of astNode and its descendants, identified by ID.
+func (s *annotationSet) merge(astNode *astjson.ASTNode) {
+	if astNode == nil {
+		return
+	}
+	if annotation, ok := s.byID[astNode.ID]; ok {
+		astNode.Annotations = annotation
+		s.mu.Lock()
+		s.merged[astNode.ID] = true
+		s.mu.Unlock()
+	}
+	for _, child := range astNode.Children {
+		s.merge(child)
+	}
+}
+
+// logUnmatched warns about annotations whose node was not part of any output,
+// usually because the source changed since the IDs were taken.
+func (s *annotationSet) logUnmatched() {
+	if s == nil {
+		return
+	}
+	for id := range s.byID {
+		if !s.merged[id] {
+			slog.Warn("annotation does not match any node", "id", id)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kobi2187/go2json/astjson"
+)
+
+var apiSurface = &apiCollector{packages: make(map[vocabularyKey]*PackageAPI)}
+
+// apiCollector gathers the exported API of every package during a run.
+type apiCollector struct {
+	packages map[vocabularyKey]*PackageAPI
+}
+
+// APIReport is the result of -api. Symbols are sorted by name and hold no
+// positions, so reports of two releases can be diffed directly.
+type APIReport struct {
+	Packages []*PackageAPI `json:"packages"`
+}
+
+// PackageAPI lists the exported symbols of one package.
+type PackageAPI struct {
+	Dir     string       `json:"dir"`
+	Name    string       `json:"name"`
+	Symbols []*APISymbol `json:"symbols"`
+}
+
+// APISymbol is an exported constant, variable, type, function or method of an
+// exported type. Synopsis is the first sentence of its doc comment, Deprecated
+// the text of a "Deprecated:" paragraph, and Stability the stability marked
+// with a //stable, //experimental or //deprecated line in the doc comment, or
+// "deprecated" if the doc has a Deprecated paragraph. Start and End are the
+// byte offsets of the declaration, or of its spec in a grouped declaration.
+type APISymbol struct {
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Signature  string `json:"signature"`
+	Synopsis   string `json:"synopsis,omitempty"`
+	Deprecated string `json:"deprecated,omitempty"`
+	Stability  string `json:"stability,omitempty"`
+	Start      int    `json:"start,omitempty"`
+	End        int    `json:"end,omitempty"`
+}
+
+// stabilityMarks are the comment lines that set the stability of a symbol.
+var stabilityMarks = map[string]string{
+	"//stable":       "stable",
+	"//experimental": "experimental",
+	"//deprecated":   "deprecated",
+}
+
+// record adds the exported symbols declared in the source src of a file. The
+// source is parsed again with comments, which the converted tree may lack.
+// Test files are not part of the API.
+func (c *apiCollector) record(sourceFilePath string, src []byte) error {
+	if strings.HasSuffix(sourceFilePath, "_test.go") {
+		return nil
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, sourceFilePath, src, parser.ParseComments)
+	if err != nil {
+		return fmt.Errorf("error parsing Go source file %s: %w", sourceFilePath, err)
+	}
+	key := vocabularyKey{dir: filepath.Dir(sourceFilePath), name: file.Name.Name}
+	pkg := c.packages[key]
+	if pkg == nil {
+		pkg = &PackageAPI{Dir: key.dir, Name: key.name, Symbols: []*APISymbol{}}
+		c.packages[key] = pkg
+	}
+
+	add := func(node ast.Node, kind, name, signature string, docs ...*ast.CommentGroup) {
+		symbol := &APISymbol{Kind: kind, Name: name, Signature: signature}
+		symbol.Start, symbol.End = sourceRange(fset, node)
+		for _, group := range docs {
+			if group != nil {
+				apiDoc(symbol, group)
+				break
+			}
+		}
+		pkg.Symbols = append(pkg.Symbols, symbol)
+	}
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			kind, name := "func", d.Name.Name
+			if recv := receiverTypeName(d.Recv); recv != "" {
+				if !ast.IsExported(recv) {
+					continue
+				}
+				kind, name = "method", recv+"."+name
+			}
+			if d.Name.IsExported() {
+				add(d, kind, name, astjson.FuncSignature(d), d.Doc)
+			}
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					if s.Name.IsExported() {
+						add(s, "type", s.Name.Name, "type "+s.Name.Name+typeParamsString(s.TypeParams)+" "+types.ExprString(s.Type), s.Doc, d.Doc)
+					}
+				case *ast.ValueSpec:
+					for i, name := range s.Names {
+						if !name.IsExported() {
+							continue
+						}
+						signature := d.Tok.String() + " " + name.Name
+						if s.Type != nil {
+							signature += " " + types.ExprString(s.Type)
+						}
+						if i < len(s.Values) {
+							signature += " = " + types.ExprString(s.Values[i])
+						}
+						add(s, d.Tok.String(), name.Name, signature, s.Doc, d.Doc)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// typeParamsString returns the type parameter list of a generic type
+// declaration, or "" for other types.
+func typeParamsString(params *ast.FieldList) string {
+	if params == nil {
+		return ""
+	}
+	var list []string
+	for _, field := range params.List {
+		for _, name := range field.Names {
+			list = append(list, name.Name+" "+types.ExprString(field.Type))
+		}
+	}
+	return "[" + strings.Join(list, ", ") + "]"
+}
+
+// apiDoc fills in the synopsis, deprecation and stability of a symbol from its
+// doc comment.
+func apiDoc(symbol *APISymbol, group *ast.CommentGroup) {
+	text := group.Text()
+	symbol.Synopsis = new(doc.Package).Synopsis(text)
+	for _, paragraph := range strings.Split(text, "\n\n") {
+		if rest, ok := strings.CutPrefix(paragraph, "Deprecated: "); ok {
+			symbol.Deprecated = strings.Join(strings.Fields(rest), " ")
+			symbol.Stability = "deprecated"
+		}
+	}
+	// Marks are directive-like lines, which Text leaves out.
+	for _, comment := range group.List {
+		if stability, ok := stabilityMarks[strings.TrimSpace(comment.Text)]; ok {
+			symbol.Stability = stability
+		}
+	}
+}
+
+// report sorts the packages and their symbols.
+func (c *apiCollector) report() *APIReport {
+	report := &APIReport{Packages: []*PackageAPI{}}
+	for _, pkg := range c.packages {
+		sort.SliceStable(pkg.Symbols, func(i, j int) bool { return pkg.Symbols[i].Name < pkg.Symbols[j].Name })
+		report.Packages = append(report.Packages, pkg)
+	}
+	sort.Slice(report.Packages, func(i, j int) bool {
+		a, b := report.Packages[i], report.Packages[j]
+		if a.Dir != b.Dir {
+			return a.Dir < b.Dir
+		}
+		return a.Name < b.Name
+	})
+	return report
+}
+
+// write saves the report to path.
+func (c *apiCollector) write(path string) error {
+	outputFile, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("error creating API report %s: %w", path, err)
+	}
+	defer outputFile.Close()
+	return encodeAST(outputFile, c.report(), "  ")
+}
@@ -0,0 +1,125 @@
+// Package astbuild builds ASTNode trees in code, for tools that synthesize Go
+// code through the JSON format. A built tree can be written as a document,
+// given as the ast of a patch edit, or turned into a go/ast tree with
+// astjson.BuildNode and printed with go/format.
+//
+// Builder has a method for every go/ast node type, returning the builder of a
+// new node of that type. Node builders have a method for every field of the
+// type that holds nodes, named after the field, which only accepts the
+// builders of the node types the field can hold. A tree that compiles thus
+// has every node where go/ast allows it:
+//
+//	var b astbuild.Builder
+//	hello := b.FuncDecl().
+//		Name(b.Ident("hello")).
+//		Type(b.FuncType().Params(b.FieldList())).
+//		Body(b.BlockStmt().List(
+//			b.ExprStmt().X(b.CallExpr().Fun(b.Ident("println")).Args(b.BasicLit(`"hello"`))),
+//		))
+//
+// Attributes that nodes carry besides their children, such as the name of an
+// identifier or the operator of a binary expression, are arguments of the
+// Builder methods, or set by methods such as GenDeclBuilder.Grouped if they
+// are optional. Comments cannot be built.
+//
+// The node builders are generated from the go/ast node types by gen.go into
+// builders.go.
+package astbuild
+
+//go:generate go run gen.go
+
+import (
+	"go/ast"
+	"reflect"
+
+	"github.com/kobi2187/go2json/astjson"
+)
+
+// Builder creates node builders. Its zero value is ready to use.
+type Builder struct{}
+
+// Node is the builder of a node.
+type Node interface {
+	// Build returns the ASTNode tree of the node, with the children of every
+	// node in the order of the fields holding them. The builder can be
+	// changed and built again afterwards.
+	Build() *astjson.ASTNode
+}
+
+// Expr is the builder of a node implementing ast.Expr.
+type Expr interface {
+	Node
+	exprNode()
+}
+
+// Stmt is the builder of a node implementing ast.Stmt.
+type Stmt interface {
+	Node
+	stmtNode()
+}
+
+// Decl is the builder of a node implementing ast.Decl.
+type Decl interface {
+	Node
+	declNode()
+}
+
+// Spec is the builder of a node implementing ast.Spec.
+type Spec interface {
+	Node
+	specNode()
+}
+
+// node is the state the node builders share: the attributes of the node and
+// its children by field, with the roles they have in the node.
+type node struct {
+	astNode  astjson.ASTNode
+	roles    []string
+	children [][]Node
+}
+
+// newNode returns the state of a node of the given kind whose fields holding
+// nodes have the given roles, in field order.
+func newNode(kind string, roles ...string) node {
+	return node{
+		astNode:  astjson.ASTNode{Type: kind},
+		roles:    roles,
+		children: make([][]Node, len(roles)),
+	}
+}
+
+// set replaces the children held by the field with the given index. Nil
+// builders are left out.
+func (n *node) set(field int, children ...Node) {
+	n.children[field] = n.children[field][:0]
+	for _, child := range children {
+		if child != nil && !reflect.ValueOf(child).IsNil() {
+			n.children[field] = append(n.children[field], child)
+		}
+	}
+}
+
+// Build implements Node.
+func (n *node) Build() *astjson.ASTNode {
+	astNode := n.astNode
+	astNode.Children = nil
+	for field, children := range n.children {
+		for _, child := range children {
+			childNode := child.Build()
+			childNode.Role = n.roles[field]
+			astNode.Children = append(astNode.Children, childNode)
+		}
+	}
+	return &astNode
+}
+
+// chanDir returns the dir attribute of a channel type.
+func chanDir(dir ast.ChanDir) string {
+	switch dir {
+	case ast.SEND:
+		return "send"
+	case ast.RECV:
+		return "recv"
+	}
+	return "both"
+}
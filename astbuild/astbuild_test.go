@@ -0,0 +1,99 @@
+package astbuild
+
+import (
+	"bytes"
+	"go/format"
+	"go/token"
+	"testing"
+
+	"github.com/kobi2187/go2json/astjson"
+)
+
+// source returns the formatted source of the tree built by n.
+func source(t *testing.T, n Node) string {
+	t.Helper()
+	node, err := astjson.BuildNode(n.Build())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := format.Node(&buf, token.NewFileSet(), node); err != nil {
+		t.Fatal(err)
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		t.Fatalf("%v\n%s", err, buf.Bytes())
+	}
+	return string(src)
+}
+
+func TestBuildFile(t *testing.T) {
+	var b Builder
+	file := b.File().
+		Name(b.Ident("main")).
+		Decls(
+			b.GenDecl(token.IMPORT).Grouped().Specs(b.ImportSpec().Path(b.BasicLit(`"fmt"`))),
+			b.FuncDecl().
+				Name(b.Ident("main")).
+				Type(b.FuncType().Params(b.FieldList())).
+				Body(b.BlockStmt().List(
+					b.AssignStmt(token.DEFINE).
+						Lhs(b.Ident("xs")).
+						Rhs(b.CompositeLit().Type(b.ArrayType().Elt(b.Ident("any"))).Elts(b.BasicLit("1"), b.BasicLit(`"a"`))),
+					b.RangeStmt().Key(b.Ident("_")).Value(b.Ident("x")).Tok(token.DEFINE).X(b.Ident("xs")).Body(b.BlockStmt().List(
+						b.IfStmt().
+							Cond(b.BinaryExpr(token.NEQ).X(b.Ident("x")).Y(b.Ident("nil"))).
+							Body(b.BlockStmt().List(b.ExprStmt().X(b.CallExpr().Fun(b.SelectorExpr().X(b.Ident("fmt")).Sel(b.Ident("Println"))).Args(b.Ident("x"))))),
+					)),
+					b.ExprStmt().X(b.CallExpr().Fun(b.SelectorExpr().X(b.Ident("fmt")).Sel(b.Ident("Println"))).Args(b.Ident("xs")).Variadic()),
+				)),
+		)
+
+	want := `package main
+
+import (
+	"fmt"
+)
+
+func main() {
+	xs := []any{1, "a"}
+	for _, x := range xs {
+		if x != nil {
+			fmt.Println(x)
+		}
+	}
+	fmt.Println(xs...)
+}
+`
+	if got := source(t, file); got != want {
+		t.Errorf("built file:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestBuildOrdersChildrenByField(t *testing.T) {
+	var b Builder
+	spec := b.TypeSpec().Type(b.Ident("int")).Name(b.Ident("T"))
+	astNode := spec.Build()
+	if len(astNode.Children) != 2 || astNode.Children[0].Role != "name" || astNode.Children[1].Role != "type" {
+		t.Fatalf("children of the type spec are not in field order: %+v", astNode.Children)
+	}
+
+	// Setting a field again replaces its nodes, and builders can be built
+	// again after changes.
+	spec.Type(b.StarExpr().X(b.Ident("int")))
+	if got := source(t, b.GenDecl(token.TYPE).Specs(spec)); got != "type T *int" {
+		t.Errorf("rebuilt type declaration is %q", got)
+	}
+}
+
+func TestBuildLeavesOutNil(t *testing.T) {
+	var b Builder
+	var label *IdentBuilder
+	astNode := b.BranchStmt(token.BREAK).Label(label).Build()
+	if len(astNode.Children) != 0 {
+		t.Errorf("nil label was built: %+v", astNode.Children)
+	}
+	if _, err := astjson.BuildNode(astNode); err != nil {
+		t.Error(err)
+	}
+}
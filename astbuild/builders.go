@@ -0,0 +1,1234 @@
+// Code generated by gen.go; DO NOT EDIT.
+
+package astbuild
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+// ArrayTypeBuilder builds *ast.ArrayType nodes.
+type ArrayTypeBuilder struct{ node }
+
+// ArrayType returns the builder of a new *ast.ArrayType node.
+func (Builder) ArrayType() *ArrayTypeBuilder {
+	b := &ArrayTypeBuilder{newNode("*ast.ArrayType", "len", "elt")}
+	return b
+}
+
+// Len sets the node of the len field.
+func (b *ArrayTypeBuilder) Len(lenNode Expr) *ArrayTypeBuilder {
+	b.set(0, lenNode)
+	return b
+}
+
+// Elt sets the node of the elt field.
+func (b *ArrayTypeBuilder) Elt(elt Expr) *ArrayTypeBuilder {
+	b.set(1, elt)
+	return b
+}
+
+func (*ArrayTypeBuilder) exprNode() {}
+
+// AssignStmtBuilder builds *ast.AssignStmt nodes.
+type AssignStmtBuilder struct{ node }
+
+// AssignStmt returns the builder of a new *ast.AssignStmt node.
+func (Builder) AssignStmt(tok token.Token) *AssignStmtBuilder {
+	b := &AssignStmtBuilder{newNode("*ast.AssignStmt", "lhs", "rhs")}
+	b.astNode.Tok = tok.String()
+	return b
+}
+
+// Lhs sets the nodes of the lhs field.
+func (b *AssignStmtBuilder) Lhs(lhs ...Expr) *AssignStmtBuilder {
+	children := make([]Node, len(lhs))
+	for i, child := range lhs {
+		children[i] = child
+	}
+	b.set(0, children...)
+	return b
+}
+
+// Rhs sets the nodes of the rhs field.
+func (b *AssignStmtBuilder) Rhs(rhs ...Expr) *AssignStmtBuilder {
+	children := make([]Node, len(rhs))
+	for i, child := range rhs {
+		children[i] = child
+	}
+	b.set(1, children...)
+	return b
+}
+
+func (*AssignStmtBuilder) stmtNode() {}
+
+// BadDeclBuilder builds *ast.BadDecl nodes.
+type BadDeclBuilder struct{ node }
+
+// BadDecl returns the builder of a new *ast.BadDecl node.
+func (Builder) BadDecl() *BadDeclBuilder {
+	b := &BadDeclBuilder{newNode("*ast.BadDecl")}
+	return b
+}
+
+func (*BadDeclBuilder) declNode() {}
+
+// BadExprBuilder builds *ast.BadExpr nodes.
+type BadExprBuilder struct{ node }
+
+// BadExpr returns the builder of a new *ast.BadExpr node.
+func (Builder) BadExpr() *BadExprBuilder {
+	b := &BadExprBuilder{newNode("*ast.BadExpr")}
+	return b
+}
+
+func (*BadExprBuilder) exprNode() {}
+
+// BadStmtBuilder builds *ast.BadStmt nodes.
+type BadStmtBuilder struct{ node }
+
+// BadStmt returns the builder of a new *ast.BadStmt node.
+func (Builder) BadStmt() *BadStmtBuilder {
+	b := &BadStmtBuilder{newNode("*ast.BadStmt")}
+	return b
+}
+
+func (*BadStmtBuilder) stmtNode() {}
+
+// BasicLitBuilder builds *ast.BasicLit nodes.
+type BasicLitBuilder struct{ node }
+
+// BasicLit returns the builder of a new *ast.BasicLit node.
+func (Builder) BasicLit(value string) *BasicLitBuilder {
+	b := &BasicLitBuilder{newNode("*ast.BasicLit")}
+	b.astNode.Value = value
+	return b
+}
+
+func (*BasicLitBuilder) exprNode() {}
+
+// BinaryExprBuilder builds *ast.BinaryExpr nodes.
+type BinaryExprBuilder struct{ node }
+
+// BinaryExpr returns the builder of a new *ast.BinaryExpr node.
+func (Builder) BinaryExpr(op token.Token) *BinaryExprBuilder {
+	b := &BinaryExprBuilder{newNode("*ast.BinaryExpr", "x", "y")}
+	b.astNode.Op = op.String()
+	return b
+}
+
+// X sets the node of the x field.
+func (b *BinaryExprBuilder) X(x Expr) *BinaryExprBuilder {
+	b.set(0, x)
+	return b
+}
+
+// Y sets the node of the y field.
+func (b *BinaryExprBuilder) Y(y Expr) *BinaryExprBuilder {
+	b.set(1, y)
+	return b
+}
+
+func (*BinaryExprBuilder) exprNode() {}
+
+// BlockStmtBuilder builds *ast.BlockStmt nodes.
+type BlockStmtBuilder struct{ node }
+
+// BlockStmt returns the builder of a new *ast.BlockStmt node.
+func (Builder) BlockStmt() *BlockStmtBuilder {
+	b := &BlockStmtBuilder{newNode("*ast.BlockStmt", "list")}
+	return b
+}
+
+// List sets the nodes of the list field.
+func (b *BlockStmtBuilder) List(list ...Stmt) *BlockStmtBuilder {
+	children := make([]Node, len(list))
+	for i, child := range list {
+		children[i] = child
+	}
+	b.set(0, children...)
+	return b
+}
+
+func (*BlockStmtBuilder) stmtNode() {}
+
+// BranchStmtBuilder builds *ast.BranchStmt nodes.
+type BranchStmtBuilder struct{ node }
+
+// BranchStmt returns the builder of a new *ast.BranchStmt node.
+func (Builder) BranchStmt(tok token.Token) *BranchStmtBuilder {
+	b := &BranchStmtBuilder{newNode("*ast.BranchStmt", "label")}
+	b.astNode.Tok = tok.String()
+	return b
+}
+
+// Label sets the node of the label field.
+func (b *BranchStmtBuilder) Label(label *IdentBuilder) *BranchStmtBuilder {
+	b.set(0, label)
+	return b
+}
+
+func (*BranchStmtBuilder) stmtNode() {}
+
+// CallExprBuilder builds *ast.CallExpr nodes.
+type CallExprBuilder struct{ node }
+
+// CallExpr returns the builder of a new *ast.CallExpr node.
+func (Builder) CallExpr() *CallExprBuilder {
+	b := &CallExprBuilder{newNode("*ast.CallExpr", "fun", "args")}
+	return b
+}
+
+// Fun sets the node of the fun field.
+func (b *CallExprBuilder) Fun(fun Expr) *CallExprBuilder {
+	b.set(0, fun)
+	return b
+}
+
+// Args sets the nodes of the args field.
+func (b *CallExprBuilder) Args(args ...Expr) *CallExprBuilder {
+	children := make([]Node, len(args))
+	for i, child := range args {
+		children[i] = child
+	}
+	b.set(1, children...)
+	return b
+}
+
+// Variadic passes the last argument as the variadic parameter, as in f(xs...).
+func (b *CallExprBuilder) Variadic() *CallExprBuilder {
+	b.astNode.Variadic = true
+	return b
+}
+
+func (*CallExprBuilder) exprNode() {}
+
+// CaseClauseBuilder builds *ast.CaseClause nodes.
+type CaseClauseBuilder struct{ node }
+
+// CaseClause returns the builder of a new *ast.CaseClause node.
+func (Builder) CaseClause() *CaseClauseBuilder {
+	b := &CaseClauseBuilder{newNode("*ast.CaseClause", "list", "body")}
+	return b
+}
+
+// List sets the nodes of the list field.
+func (b *CaseClauseBuilder) List(list ...Expr) *CaseClauseBuilder {
+	children := make([]Node, len(list))
+	for i, child := range list {
+		children[i] = child
+	}
+	b.set(0, children...)
+	return b
+}
+
+// Body sets the nodes of the body field.
+func (b *CaseClauseBuilder) Body(body ...Stmt) *CaseClauseBuilder {
+	children := make([]Node, len(body))
+	for i, child := range body {
+		children[i] = child
+	}
+	b.set(1, children...)
+	return b
+}
+
+func (*CaseClauseBuilder) stmtNode() {}
+
+// ChanTypeBuilder builds *ast.ChanType nodes.
+type ChanTypeBuilder struct{ node }
+
+// ChanType returns the builder of a new *ast.ChanType node.
+func (Builder) ChanType(dir ast.ChanDir) *ChanTypeBuilder {
+	b := &ChanTypeBuilder{newNode("*ast.ChanType", "value")}
+	b.astNode.Dir = chanDir(dir)
+	return b
+}
+
+// Value sets the node of the value field.
+func (b *ChanTypeBuilder) Value(value Expr) *ChanTypeBuilder {
+	b.set(0, value)
+	return b
+}
+
+func (*ChanTypeBuilder) exprNode() {}
+
+// CommClauseBuilder builds *ast.CommClause nodes.
+type CommClauseBuilder struct{ node }
+
+// CommClause returns the builder of a new *ast.CommClause node.
+func (Builder) CommClause() *CommClauseBuilder {
+	b := &CommClauseBuilder{newNode("*ast.CommClause", "comm", "body")}
+	return b
+}
+
+// Comm sets the node of the comm field.
+func (b *CommClauseBuilder) Comm(comm Stmt) *CommClauseBuilder {
+	b.set(0, comm)
+	return b
+}
+
+// Body sets the nodes of the body field.
+func (b *CommClauseBuilder) Body(body ...Stmt) *CommClauseBuilder {
+	children := make([]Node, len(body))
+	for i, child := range body {
+		children[i] = child
+	}
+	b.set(1, children...)
+	return b
+}
+
+func (*CommClauseBuilder) stmtNode() {}
+
+// CompositeLitBuilder builds *ast.CompositeLit nodes.
+type CompositeLitBuilder struct{ node }
+
+// CompositeLit returns the builder of a new *ast.CompositeLit node.
+func (Builder) CompositeLit() *CompositeLitBuilder {
+	b := &CompositeLitBuilder{newNode("*ast.CompositeLit", "type", "elts")}
+	return b
+}
+
+// Type sets the node of the type field.
+func (b *CompositeLitBuilder) Type(typeNode Expr) *CompositeLitBuilder {
+	b.set(0, typeNode)
+	return b
+}
+
+// Elts sets the nodes of the elts field.
+func (b *CompositeLitBuilder) Elts(elts ...Expr) *CompositeLitBuilder {
+	children := make([]Node, len(elts))
+	for i, child := range elts {
+		children[i] = child
+	}
+	b.set(1, children...)
+	return b
+}
+
+func (*CompositeLitBuilder) exprNode() {}
+
+// DeclStmtBuilder builds *ast.DeclStmt nodes.
+type DeclStmtBuilder struct{ node }
+
+// DeclStmt returns the builder of a new *ast.DeclStmt node.
+func (Builder) DeclStmt() *DeclStmtBuilder {
+	b := &DeclStmtBuilder{newNode("*ast.DeclStmt", "decl")}
+	return b
+}
+
+// Decl sets the node of the decl field.
+func (b *DeclStmtBuilder) Decl(decl Decl) *DeclStmtBuilder {
+	b.set(0, decl)
+	return b
+}
+
+func (*DeclStmtBuilder) stmtNode() {}
+
+// DeferStmtBuilder builds *ast.DeferStmt nodes.
+type DeferStmtBuilder struct{ node }
+
+// DeferStmt returns the builder of a new *ast.DeferStmt node.
+func (Builder) DeferStmt() *DeferStmtBuilder {
+	b := &DeferStmtBuilder{newNode("*ast.DeferStmt", "call")}
+	return b
+}
+
+// Call sets the node of the call field.
+func (b *DeferStmtBuilder) Call(call *CallExprBuilder) *DeferStmtBuilder {
+	b.set(0, call)
+	return b
+}
+
+func (*DeferStmtBuilder) stmtNode() {}
+
+// EllipsisBuilder builds *ast.Ellipsis nodes.
+type EllipsisBuilder struct{ node }
+
+// Ellipsis returns the builder of a new *ast.Ellipsis node.
+func (Builder) Ellipsis() *EllipsisBuilder {
+	b := &EllipsisBuilder{newNode("*ast.Ellipsis", "elt")}
+	return b
+}
+
+// Elt sets the node of the elt field.
+func (b *EllipsisBuilder) Elt(elt Expr) *EllipsisBuilder {
+	b.set(0, elt)
+	return b
+}
+
+func (*EllipsisBuilder) exprNode() {}
+
+// EmptyStmtBuilder builds *ast.EmptyStmt nodes.
+type EmptyStmtBuilder struct{ node }
+
+// EmptyStmt returns the builder of a new *ast.EmptyStmt node.
+func (Builder) EmptyStmt() *EmptyStmtBuilder {
+	b := &EmptyStmtBuilder{newNode("*ast.EmptyStmt")}
+	return b
+}
+
+func (*EmptyStmtBuilder) stmtNode() {}
+
+// ExprStmtBuilder builds *ast.ExprStmt nodes.
+type ExprStmtBuilder struct{ node }
+
+// ExprStmt returns the builder of a new *ast.ExprStmt node.
+func (Builder) ExprStmt() *ExprStmtBuilder {
+	b := &ExprStmtBuilder{newNode("*ast.ExprStmt", "x")}
+	return b
+}
+
+// X sets the node of the x field.
+func (b *ExprStmtBuilder) X(x Expr) *ExprStmtBuilder {
+	b.set(0, x)
+	return b
+}
+
+func (*ExprStmtBuilder) stmtNode() {}
+
+// FieldBuilder builds *ast.Field nodes.
+type FieldBuilder struct{ node }
+
+// Field returns the builder of a new *ast.Field node.
+func (Builder) Field() *FieldBuilder {
+	b := &FieldBuilder{newNode("*ast.Field", "names", "type", "tag")}
+	return b
+}
+
+// Names sets the nodes of the names field.
+func (b *FieldBuilder) Names(names ...*IdentBuilder) *FieldBuilder {
+	children := make([]Node, len(names))
+	for i, child := range names {
+		children[i] = child
+	}
+	b.set(0, children...)
+	return b
+}
+
+// Type sets the node of the type field.
+func (b *FieldBuilder) Type(typeNode Expr) *FieldBuilder {
+	b.set(1, typeNode)
+	return b
+}
+
+// Tag sets the node of the tag field.
+func (b *FieldBuilder) Tag(tag *BasicLitBuilder) *FieldBuilder {
+	b.set(2, tag)
+	return b
+}
+
+// FieldListBuilder builds *ast.FieldList nodes.
+type FieldListBuilder struct{ node }
+
+// FieldList returns the builder of a new *ast.FieldList node.
+func (Builder) FieldList() *FieldListBuilder {
+	b := &FieldListBuilder{newNode("*ast.FieldList", "list")}
+	return b
+}
+
+// List sets the nodes of the list field.
+func (b *FieldListBuilder) List(list ...*FieldBuilder) *FieldListBuilder {
+	children := make([]Node, len(list))
+	for i, child := range list {
+		children[i] = child
+	}
+	b.set(0, children...)
+	return b
+}
+
+// FileBuilder builds *ast.File nodes.
+type FileBuilder struct{ node }
+
+// File returns the builder of a new *ast.File node.
+func (Builder) File() *FileBuilder {
+	b := &FileBuilder{newNode("*ast.File", "name", "decls")}
+	return b
+}
+
+// Name sets the node of the name field.
+func (b *FileBuilder) Name(name *IdentBuilder) *FileBuilder {
+	b.set(0, name)
+	return b
+}
+
+// Decls sets the nodes of the decls field.
+func (b *FileBuilder) Decls(decls ...Decl) *FileBuilder {
+	children := make([]Node, len(decls))
+	for i, child := range decls {
+		children[i] = child
+	}
+	b.set(1, children...)
+	return b
+}
+
+// ForStmtBuilder builds *ast.ForStmt nodes.
+type ForStmtBuilder struct{ node }
+
+// ForStmt returns the builder of a new *ast.ForStmt node.
+func (Builder) ForStmt() *ForStmtBuilder {
+	b := &ForStmtBuilder{newNode("*ast.ForStmt", "init", "cond", "post", "body")}
+	return b
+}
+
+// Init sets the node of the init field.
+func (b *ForStmtBuilder) Init(init Stmt) *ForStmtBuilder {
+	b.set(0, init)
+	return b
+}
+
+// Cond sets the node of the cond field.
+func (b *ForStmtBuilder) Cond(cond Expr) *ForStmtBuilder {
+	b.set(1, cond)
+	return b
+}
+
+// Post sets the node of the post field.
+func (b *ForStmtBuilder) Post(post Stmt) *ForStmtBuilder {
+	b.set(2, post)
+	return b
+}
+
+// Body sets the node of the body field.
+func (b *ForStmtBuilder) Body(body *BlockStmtBuilder) *ForStmtBuilder {
+	b.set(3, body)
+	return b
+}
+
+func (*ForStmtBuilder) stmtNode() {}
+
+// FuncDeclBuilder builds *ast.FuncDecl nodes.
+type FuncDeclBuilder struct{ node }
+
+// FuncDecl returns the builder of a new *ast.FuncDecl node.
+func (Builder) FuncDecl() *FuncDeclBuilder {
+	b := &FuncDeclBuilder{newNode("*ast.FuncDecl", "recv", "name", "type", "body")}
+	return b
+}
+
+// Recv sets the node of the recv field.
+func (b *FuncDeclBuilder) Recv(recv *FieldListBuilder) *FuncDeclBuilder {
+	b.set(0, recv)
+	return b
+}
+
+// Name sets the node of the name field.
+func (b *FuncDeclBuilder) Name(name *IdentBuilder) *FuncDeclBuilder {
+	b.set(1, name)
+	return b
+}
+
+// Type sets the node of the type field.
+func (b *FuncDeclBuilder) Type(typeNode *FuncTypeBuilder) *FuncDeclBuilder {
+	b.set(2, typeNode)
+	return b
+}
+
+// Body sets the node of the body field.
+func (b *FuncDeclBuilder) Body(body *BlockStmtBuilder) *FuncDeclBuilder {
+	b.set(3, body)
+	return b
+}
+
+func (*FuncDeclBuilder) declNode() {}
+
+// FuncLitBuilder builds *ast.FuncLit nodes.
+type FuncLitBuilder struct{ node }
+
+// FuncLit returns the builder of a new *ast.FuncLit node.
+func (Builder) FuncLit() *FuncLitBuilder {
+	b := &FuncLitBuilder{newNode("*ast.FuncLit", "type", "body")}
+	return b
+}
+
+// Type sets the node of the type field.
+func (b *FuncLitBuilder) Type(typeNode *FuncTypeBuilder) *FuncLitBuilder {
+	b.set(0, typeNode)
+	return b
+}
+
+// Body sets the node of the body field.
+func (b *FuncLitBuilder) Body(body *BlockStmtBuilder) *FuncLitBuilder {
+	b.set(1, body)
+	return b
+}
+
+func (*FuncLitBuilder) exprNode() {}
+
+// FuncTypeBuilder builds *ast.FuncType nodes.
+type FuncTypeBuilder struct{ node }
+
+// FuncType returns the builder of a new *ast.FuncType node.
+func (Builder) FuncType() *FuncTypeBuilder {
+	b := &FuncTypeBuilder{newNode("*ast.FuncType", "type_params", "params", "results")}
+	return b
+}
+
+// TypeParams sets the node of the type_params field.
+func (b *FuncTypeBuilder) TypeParams(typeParams *FieldListBuilder) *FuncTypeBuilder {
+	b.set(0, typeParams)
+	return b
+}
+
+// Params sets the node of the params field.
+func (b *FuncTypeBuilder) Params(params *FieldListBuilder) *FuncTypeBuilder {
+	b.set(1, params)
+	return b
+}
+
+// Results sets the node of the results field.
+func (b *FuncTypeBuilder) Results(results *FieldListBuilder) *FuncTypeBuilder {
+	b.set(2, results)
+	return b
+}
+
+func (*FuncTypeBuilder) exprNode() {}
+
+// GenDeclBuilder builds *ast.GenDecl nodes.
+type GenDeclBuilder struct{ node }
+
+// GenDecl returns the builder of a new *ast.GenDecl node.
+func (Builder) GenDecl(tok token.Token) *GenDeclBuilder {
+	b := &GenDeclBuilder{newNode("*ast.GenDecl", "specs")}
+	b.astNode.Tok = tok.String()
+	return b
+}
+
+// Specs sets the nodes of the specs field.
+func (b *GenDeclBuilder) Specs(specs ...Spec) *GenDeclBuilder {
+	children := make([]Node, len(specs))
+	for i, child := range specs {
+		children[i] = child
+	}
+	b.set(0, children...)
+	return b
+}
+
+// Grouped parenthesizes the specs of the declaration, even if there is only one.
+func (b *GenDeclBuilder) Grouped() *GenDeclBuilder {
+	b.astNode.Grouped = true
+	return b
+}
+
+func (*GenDeclBuilder) declNode() {}
+
+// GoStmtBuilder builds *ast.GoStmt nodes.
+type GoStmtBuilder struct{ node }
+
+// GoStmt returns the builder of a new *ast.GoStmt node.
+func (Builder) GoStmt() *GoStmtBuilder {
+	b := &GoStmtBuilder{newNode("*ast.GoStmt", "call")}
+	return b
+}
+
+// Call sets the node of the call field.
+func (b *GoStmtBuilder) Call(call *CallExprBuilder) *GoStmtBuilder {
+	b.set(0, call)
+	return b
+}
+
+func (*GoStmtBuilder) stmtNode() {}
+
+// IdentBuilder builds *ast.Ident nodes.
+type IdentBuilder struct{ node }
+
+// Ident returns the builder of a new *ast.Ident node.
+func (Builder) Ident(name string) *IdentBuilder {
+	b := &IdentBuilder{newNode("*ast.Ident")}
+	b.astNode.Value = name
+	return b
+}
+
+func (*IdentBuilder) exprNode() {}
+
+// IfStmtBuilder builds *ast.IfStmt nodes.
+type IfStmtBuilder struct{ node }
+
+// IfStmt returns the builder of a new *ast.IfStmt node.
+func (Builder) IfStmt() *IfStmtBuilder {
+	b := &IfStmtBuilder{newNode("*ast.IfStmt", "init", "cond", "body", "else")}
+	return b
+}
+
+// Init sets the node of the init field.
+func (b *IfStmtBuilder) Init(init Stmt) *IfStmtBuilder {
+	b.set(0, init)
+	return b
+}
+
+// Cond sets the node of the cond field.
+func (b *IfStmtBuilder) Cond(cond Expr) *IfStmtBuilder {
+	b.set(1, cond)
+	return b
+}
+
+// Body sets the node of the body field.
+func (b *IfStmtBuilder) Body(body *BlockStmtBuilder) *IfStmtBuilder {
+	b.set(2, body)
+	return b
+}
+
+// Else sets the node of the else field.
+func (b *IfStmtBuilder) Else(elseNode Stmt) *IfStmtBuilder {
+	b.set(3, elseNode)
+	return b
+}
+
+func (*IfStmtBuilder) stmtNode() {}
+
+// ImportSpecBuilder builds *ast.ImportSpec nodes.
+type ImportSpecBuilder struct{ node }
+
+// ImportSpec returns the builder of a new *ast.ImportSpec node.
+func (Builder) ImportSpec() *ImportSpecBuilder {
+	b := &ImportSpecBuilder{newNode("*ast.ImportSpec", "name", "path")}
+	return b
+}
+
+// Name sets the node of the name field.
+func (b *ImportSpecBuilder) Name(name *IdentBuilder) *ImportSpecBuilder {
+	b.set(0, name)
+	return b
+}
+
+// Path sets the node of the path field.
+func (b *ImportSpecBuilder) Path(path *BasicLitBuilder) *ImportSpecBuilder {
+	b.set(1, path)
+	return b
+}
+
+func (*ImportSpecBuilder) specNode() {}
+
+// IncDecStmtBuilder builds *ast.IncDecStmt nodes.
+type IncDecStmtBuilder struct{ node }
+
+// IncDecStmt returns the builder of a new *ast.IncDecStmt node.
+func (Builder) IncDecStmt(tok token.Token) *IncDecStmtBuilder {
+	b := &IncDecStmtBuilder{newNode("*ast.IncDecStmt", "x")}
+	b.astNode.Tok = tok.String()
+	return b
+}
+
+// X sets the node of the x field.
+func (b *IncDecStmtBuilder) X(x Expr) *IncDecStmtBuilder {
+	b.set(0, x)
+	return b
+}
+
+func (*IncDecStmtBuilder) stmtNode() {}
+
+// IndexExprBuilder builds *ast.IndexExpr nodes.
+type IndexExprBuilder struct{ node }
+
+// IndexExpr returns the builder of a new *ast.IndexExpr node.
+func (Builder) IndexExpr() *IndexExprBuilder {
+	b := &IndexExprBuilder{newNode("*ast.IndexExpr", "x", "index")}
+	return b
+}
+
+// X sets the node of the x field.
+func (b *IndexExprBuilder) X(x Expr) *IndexExprBuilder {
+	b.set(0, x)
+	return b
+}
+
+// Index sets the node of the index field.
+func (b *IndexExprBuilder) Index(index Expr) *IndexExprBuilder {
+	b.set(1, index)
+	return b
+}
+
+func (*IndexExprBuilder) exprNode() {}
+
+// IndexListExprBuilder builds *ast.IndexListExpr nodes.
+type IndexListExprBuilder struct{ node }
+
+// IndexListExpr returns the builder of a new *ast.IndexListExpr node.
+func (Builder) IndexListExpr() *IndexListExprBuilder {
+	b := &IndexListExprBuilder{newNode("*ast.IndexListExpr", "x", "indices")}
+	return b
+}
+
+// X sets the node of the x field.
+func (b *IndexListExprBuilder) X(x Expr) *IndexListExprBuilder {
+	b.set(0, x)
+	return b
+}
+
+// Indices sets the nodes of the indices field.
+func (b *IndexListExprBuilder) Indices(indices ...Expr) *IndexListExprBuilder {
+	children := make([]Node, len(indices))
+	for i, child := range indices {
+		children[i] = child
+	}
+	b.set(1, children...)
+	return b
+}
+
+func (*IndexListExprBuilder) exprNode() {}
+
+// InterfaceTypeBuilder builds *ast.InterfaceType nodes.
+type InterfaceTypeBuilder struct{ node }
+
+// InterfaceType returns the builder of a new *ast.InterfaceType node.
+func (Builder) InterfaceType() *InterfaceTypeBuilder {
+	b := &InterfaceTypeBuilder{newNode("*ast.InterfaceType", "methods")}
+	return b
+}
+
+// Methods sets the node of the methods field.
+func (b *InterfaceTypeBuilder) Methods(methods *FieldListBuilder) *InterfaceTypeBuilder {
+	b.set(0, methods)
+	return b
+}
+
+func (*InterfaceTypeBuilder) exprNode() {}
+
+// KeyValueExprBuilder builds *ast.KeyValueExpr nodes.
+type KeyValueExprBuilder struct{ node }
+
+// KeyValueExpr returns the builder of a new *ast.KeyValueExpr node.
+func (Builder) KeyValueExpr() *KeyValueExprBuilder {
+	b := &KeyValueExprBuilder{newNode("*ast.KeyValueExpr", "key", "value")}
+	return b
+}
+
+// Key sets the node of the key field.
+func (b *KeyValueExprBuilder) Key(key Expr) *KeyValueExprBuilder {
+	b.set(0, key)
+	return b
+}
+
+// Value sets the node of the value field.
+func (b *KeyValueExprBuilder) Value(value Expr) *KeyValueExprBuilder {
+	b.set(1, value)
+	return b
+}
+
+func (*KeyValueExprBuilder) exprNode() {}
+
+// LabeledStmtBuilder builds *ast.LabeledStmt nodes.
+type LabeledStmtBuilder struct{ node }
+
+// LabeledStmt returns the builder of a new *ast.LabeledStmt node.
+func (Builder) LabeledStmt() *LabeledStmtBuilder {
+	b := &LabeledStmtBuilder{newNode("*ast.LabeledStmt", "label", "stmt")}
+	return b
+}
+
+// Label sets the node of the label field.
+func (b *LabeledStmtBuilder) Label(label *IdentBuilder) *LabeledStmtBuilder {
+	b.set(0, label)
+	return b
+}
+
+// Stmt sets the node of the stmt field.
+func (b *LabeledStmtBuilder) Stmt(stmt Stmt) *LabeledStmtBuilder {
+	b.set(1, stmt)
+	return b
+}
+
+func (*LabeledStmtBuilder) stmtNode() {}
+
+// MapTypeBuilder builds *ast.MapType nodes.
+type MapTypeBuilder struct{ node }
+
+// MapType returns the builder of a new *ast.MapType node.
+func (Builder) MapType() *MapTypeBuilder {
+	b := &MapTypeBuilder{newNode("*ast.MapType", "key", "value")}
+	return b
+}
+
+// Key sets the node of the key field.
+func (b *MapTypeBuilder) Key(key Expr) *MapTypeBuilder {
+	b.set(0, key)
+	return b
+}
+
+// Value sets the node of the value field.
+func (b *MapTypeBuilder) Value(value Expr) *MapTypeBuilder {
+	b.set(1, value)
+	return b
+}
+
+func (*MapTypeBuilder) exprNode() {}
+
+// ParenExprBuilder builds *ast.ParenExpr nodes.
+type ParenExprBuilder struct{ node }
+
+// ParenExpr returns the builder of a new *ast.ParenExpr node.
+func (Builder) ParenExpr() *ParenExprBuilder {
+	b := &ParenExprBuilder{newNode("*ast.ParenExpr", "x")}
+	return b
+}
+
+// X sets the node of the x field.
+func (b *ParenExprBuilder) X(x Expr) *ParenExprBuilder {
+	b.set(0, x)
+	return b
+}
+
+func (*ParenExprBuilder) exprNode() {}
+
+// RangeStmtBuilder builds *ast.RangeStmt nodes.
+type RangeStmtBuilder struct{ node }
+
+// RangeStmt returns the builder of a new *ast.RangeStmt node.
+func (Builder) RangeStmt() *RangeStmtBuilder {
+	b := &RangeStmtBuilder{newNode("*ast.RangeStmt", "key", "value", "x", "body")}
+	return b
+}
+
+// Key sets the node of the key field.
+func (b *RangeStmtBuilder) Key(key Expr) *RangeStmtBuilder {
+	b.set(0, key)
+	return b
+}
+
+// Value sets the node of the value field.
+func (b *RangeStmtBuilder) Value(value Expr) *RangeStmtBuilder {
+	b.set(1, value)
+	return b
+}
+
+// X sets the node of the x field.
+func (b *RangeStmtBuilder) X(x Expr) *RangeStmtBuilder {
+	b.set(2, x)
+	return b
+}
+
+// Body sets the node of the body field.
+func (b *RangeStmtBuilder) Body(body *BlockStmtBuilder) *RangeStmtBuilder {
+	b.set(3, body)
+	return b
+}
+
+// Tok sets the token assigning the key and value, token.DEFINE or token.ASSIGN.
+func (b *RangeStmtBuilder) Tok(tok token.Token) *RangeStmtBuilder {
+	b.astNode.Tok = tok.String()
+	return b
+}
+
+func (*RangeStmtBuilder) stmtNode() {}
+
+// ReturnStmtBuilder builds *ast.ReturnStmt nodes.
+type ReturnStmtBuilder struct{ node }
+
+// ReturnStmt returns the builder of a new *ast.ReturnStmt node.
+func (Builder) ReturnStmt() *ReturnStmtBuilder {
+	b := &ReturnStmtBuilder{newNode("*ast.ReturnStmt", "results")}
+	return b
+}
+
+// Results sets the nodes of the results field.
+func (b *ReturnStmtBuilder) Results(results ...Expr) *ReturnStmtBuilder {
+	children := make([]Node, len(results))
+	for i, child := range results {
+		children[i] = child
+	}
+	b.set(0, children...)
+	return b
+}
+
+func (*ReturnStmtBuilder) stmtNode() {}
+
+// SelectStmtBuilder builds *ast.SelectStmt nodes.
+type SelectStmtBuilder struct{ node }
+
+// SelectStmt returns the builder of a new *ast.SelectStmt node.
+func (Builder) SelectStmt() *SelectStmtBuilder {
+	b := &SelectStmtBuilder{newNode("*ast.SelectStmt", "body")}
+	return b
+}
+
+// Body sets the node of the body field.
+func (b *SelectStmtBuilder) Body(body *BlockStmtBuilder) *SelectStmtBuilder {
+	b.set(0, body)
+	return b
+}
+
+func (*SelectStmtBuilder) stmtNode() {}
+
+// SelectorExprBuilder builds *ast.SelectorExpr nodes.
+type SelectorExprBuilder struct{ node }
+
+// SelectorExpr returns the builder of a new *ast.SelectorExpr node.
+func (Builder) SelectorExpr() *SelectorExprBuilder {
+	b := &SelectorExprBuilder{newNode("*ast.SelectorExpr", "x", "sel")}
+	return b
+}
+
+// X sets the node of the x field.
+func (b *SelectorExprBuilder) X(x Expr) *SelectorExprBuilder {
+	b.set(0, x)
+	return b
+}
+
+// Sel sets the node of the sel field.
+func (b *SelectorExprBuilder) Sel(sel *IdentBuilder) *SelectorExprBuilder {
+	b.set(1, sel)
+	return b
+}
+
+func (*SelectorExprBuilder) exprNode() {}
+
+// SendStmtBuilder builds *ast.SendStmt nodes.
+type SendStmtBuilder struct{ node }
+
+// SendStmt returns the builder of a new *ast.SendStmt node.
+func (Builder) SendStmt() *SendStmtBuilder {
+	b := &SendStmtBuilder{newNode("*ast.SendStmt", "chan", "value")}
+	return b
+}
+
+// Chan sets the node of the chan field.
+func (b *SendStmtBuilder) Chan(chanNode Expr) *SendStmtBuilder {
+	b.set(0, chanNode)
+	return b
+}
+
+// Value sets the node of the value field.
+func (b *SendStmtBuilder) Value(value Expr) *SendStmtBuilder {
+	b.set(1, value)
+	return b
+}
+
+func (*SendStmtBuilder) stmtNode() {}
+
+// SliceExprBuilder builds *ast.SliceExpr nodes.
+type SliceExprBuilder struct{ node }
+
+// SliceExpr returns the builder of a new *ast.SliceExpr node.
+func (Builder) SliceExpr() *SliceExprBuilder {
+	b := &SliceExprBuilder{newNode("*ast.SliceExpr", "x", "low", "high", "max")}
+	return b
+}
+
+// X sets the node of the x field.
+func (b *SliceExprBuilder) X(x Expr) *SliceExprBuilder {
+	b.set(0, x)
+	return b
+}
+
+// Low sets the node of the low field.
+func (b *SliceExprBuilder) Low(low Expr) *SliceExprBuilder {
+	b.set(1, low)
+	return b
+}
+
+// High sets the node of the high field.
+func (b *SliceExprBuilder) High(high Expr) *SliceExprBuilder {
+	b.set(2, high)
+	return b
+}
+
+// Max sets the node of the max field.
+func (b *SliceExprBuilder) Max(maxNode Expr) *SliceExprBuilder {
+	b.set(3, maxNode)
+	return b
+}
+
+func (*SliceExprBuilder) exprNode() {}
+
+// StarExprBuilder builds *ast.StarExpr nodes.
+type StarExprBuilder struct{ node }
+
+// StarExpr returns the builder of a new *ast.StarExpr node.
+func (Builder) StarExpr() *StarExprBuilder {
+	b := &StarExprBuilder{newNode("*ast.StarExpr", "x")}
+	return b
+}
+
+// X sets the node of the x field.
+func (b *StarExprBuilder) X(x Expr) *StarExprBuilder {
+	b.set(0, x)
+	return b
+}
+
+func (*StarExprBuilder) exprNode() {}
+
+// StructTypeBuilder builds *ast.StructType nodes.
+type StructTypeBuilder struct{ node }
+
+// StructType returns the builder of a new *ast.StructType node.
+func (Builder) StructType() *StructTypeBuilder {
+	b := &StructTypeBuilder{newNode("*ast.StructType", "fields")}
+	return b
+}
+
+// Fields sets the node of the fields field.
+func (b *StructTypeBuilder) Fields(fields *FieldListBuilder) *StructTypeBuilder {
+	b.set(0, fields)
+	return b
+}
+
+func (*StructTypeBuilder) exprNode() {}
+
+// SwitchStmtBuilder builds *ast.SwitchStmt nodes.
+type SwitchStmtBuilder struct{ node }
+
+// SwitchStmt returns the builder of a new *ast.SwitchStmt node.
+func (Builder) SwitchStmt() *SwitchStmtBuilder {
+	b := &SwitchStmtBuilder{newNode("*ast.SwitchStmt", "init", "tag", "body")}
+	return b
+}
+
+// Init sets the node of the init field.
+func (b *SwitchStmtBuilder) Init(init Stmt) *SwitchStmtBuilder {
+	b.set(0, init)
+	return b
+}
+
+// Tag sets the node of the tag field.
+func (b *SwitchStmtBuilder) Tag(tag Expr) *SwitchStmtBuilder {
+	b.set(1, tag)
+	return b
+}
+
+// Body sets the node of the body field.
+func (b *SwitchStmtBuilder) Body(body *BlockStmtBuilder) *SwitchStmtBuilder {
+	b.set(2, body)
+	return b
+}
+
+func (*SwitchStmtBuilder) stmtNode() {}
+
+// TypeAssertExprBuilder builds *ast.TypeAssertExpr nodes.
+type TypeAssertExprBuilder struct{ node }
+
+// TypeAssertExpr returns the builder of a new *ast.TypeAssertExpr node.
+func (Builder) TypeAssertExpr() *TypeAssertExprBuilder {
+	b := &TypeAssertExprBuilder{newNode("*ast.TypeAssertExpr", "x", "type")}
+	return b
+}
+
+// X sets the node of the x field.
+func (b *TypeAssertExprBuilder) X(x Expr) *TypeAssertExprBuilder {
+	b.set(0, x)
+	return b
+}
+
+// Type sets the node of the type field.
+func (b *TypeAssertExprBuilder) Type(typeNode Expr) *TypeAssertExprBuilder {
+	b.set(1, typeNode)
+	return b
+}
+
+func (*TypeAssertExprBuilder) exprNode() {}
+
+// TypeSpecBuilder builds *ast.TypeSpec nodes.
+type TypeSpecBuilder struct{ node }
+
+// TypeSpec returns the builder of a new *ast.TypeSpec node.
+func (Builder) TypeSpec() *TypeSpecBuilder {
+	b := &TypeSpecBuilder{newNode("*ast.TypeSpec", "name", "type_params", "type")}
+	return b
+}
+
+// Name sets the node of the name field.
+func (b *TypeSpecBuilder) Name(name *IdentBuilder) *TypeSpecBuilder {
+	b.set(0, name)
+	return b
+}
+
+// TypeParams sets the node of the type_params field.
+func (b *TypeSpecBuilder) TypeParams(typeParams *FieldListBuilder) *TypeSpecBuilder {
+	b.set(1, typeParams)
+	return b
+}
+
+// Type sets the node of the type field.
+func (b *TypeSpecBuilder) Type(typeNode Expr) *TypeSpecBuilder {
+	b.set(2, typeNode)
+	return b
+}
+
+// Alias declares the type as an alias of its type, as in type A = B.
+func (b *TypeSpecBuilder) Alias() *TypeSpecBuilder {
+	b.astNode.Alias = true
+	return b
+}
+
+func (*TypeSpecBuilder) specNode() {}
+
+// TypeSwitchStmtBuilder builds *ast.TypeSwitchStmt nodes.
+type TypeSwitchStmtBuilder struct{ node }
+
+// TypeSwitchStmt returns the builder of a new *ast.TypeSwitchStmt node.
+func (Builder) TypeSwitchStmt() *TypeSwitchStmtBuilder {
+	b := &TypeSwitchStmtBuilder{newNode("*ast.TypeSwitchStmt", "init", "assign", "body")}
+	return b
+}
+
+// Init sets the node of the init field.
+func (b *TypeSwitchStmtBuilder) Init(init Stmt) *TypeSwitchStmtBuilder {
+	b.set(0, init)
+	return b
+}
+
+// Assign sets the node of the assign field.
+func (b *TypeSwitchStmtBuilder) Assign(assign Stmt) *TypeSwitchStmtBuilder {
+	b.set(1, assign)
+	return b
+}
+
+// Body sets the node of the body field.
+func (b *TypeSwitchStmtBuilder) Body(body *BlockStmtBuilder) *TypeSwitchStmtBuilder {
+	b.set(2, body)
+	return b
+}
+
+func (*TypeSwitchStmtBuilder) stmtNode() {}
+
+// UnaryExprBuilder builds *ast.UnaryExpr nodes.
+type UnaryExprBuilder struct{ node }
+
+// UnaryExpr returns the builder of a new *ast.UnaryExpr node.
+func (Builder) UnaryExpr(op token.Token) *UnaryExprBuilder {
+	b := &UnaryExprBuilder{newNode("*ast.UnaryExpr", "x")}
+	b.astNode.Op = op.String()
+	return b
+}
+
+// X sets the node of the x field.
+func (b *UnaryExprBuilder) X(x Expr) *UnaryExprBuilder {
+	b.set(0, x)
+	return b
+}
+
+func (*UnaryExprBuilder) exprNode() {}
+
+// ValueSpecBuilder builds *ast.ValueSpec nodes.
+type ValueSpecBuilder struct{ node }
+
+// ValueSpec returns the builder of a new *ast.ValueSpec node.
+func (Builder) ValueSpec() *ValueSpecBuilder {
+	b := &ValueSpecBuilder{newNode("*ast.ValueSpec", "names", "type", "values")}
+	return b
+}
+
+// Names sets the nodes of the names field.
+func (b *ValueSpecBuilder) Names(names ...*IdentBuilder) *ValueSpecBuilder {
+	children := make([]Node, len(names))
+	for i, child := range names {
+		children[i] = child
+	}
+	b.set(0, children...)
+	return b
+}
+
+// Type sets the node of the type field.
+func (b *ValueSpecBuilder) Type(typeNode Expr) *ValueSpecBuilder {
+	b.set(1, typeNode)
+	return b
+}
+
+// Values sets the nodes of the values field.
+func (b *ValueSpecBuilder) Values(values ...Expr) *ValueSpecBuilder {
+	children := make([]Node, len(values))
+	for i, child := range values {
+		children[i] = child
+	}
+	b.set(2, children...)
+	return b
+}
+
+func (*ValueSpecBuilder) specNode() {}
@@ -0,0 +1,226 @@
+//go:build ignore
+
+// gen.go writes builders.go, the builders of the go/ast node types.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"go/types"
+	"log"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// nodeTypes are the go/ast node types there are builders for: all of them
+// except comments.
+var nodeTypes = []ast.Node{
+	&ast.ArrayType{}, &ast.AssignStmt{}, &ast.BadDecl{}, &ast.BadExpr{}, &ast.BadStmt{},
+	&ast.BasicLit{}, &ast.BinaryExpr{}, &ast.BlockStmt{}, &ast.BranchStmt{}, &ast.CallExpr{},
+	&ast.CaseClause{}, &ast.ChanType{}, &ast.CommClause{},
+	&ast.CompositeLit{}, &ast.DeclStmt{}, &ast.DeferStmt{}, &ast.Ellipsis{}, &ast.EmptyStmt{},
+	&ast.ExprStmt{}, &ast.Field{}, &ast.FieldList{}, &ast.File{}, &ast.ForStmt{},
+	&ast.FuncDecl{}, &ast.FuncLit{}, &ast.FuncType{}, &ast.GenDecl{}, &ast.GoStmt{},
+	&ast.Ident{}, &ast.IfStmt{}, &ast.ImportSpec{}, &ast.IncDecStmt{}, &ast.IndexExpr{},
+	&ast.IndexListExpr{}, &ast.InterfaceType{}, &ast.KeyValueExpr{}, &ast.LabeledStmt{}, &ast.MapType{},
+	&ast.ParenExpr{}, &ast.RangeStmt{}, &ast.ReturnStmt{}, &ast.SelectStmt{}, &ast.SelectorExpr{},
+	&ast.SendStmt{}, &ast.SliceExpr{}, &ast.StarExpr{}, &ast.StructType{}, &ast.SwitchStmt{},
+	&ast.TypeAssertExpr{}, &ast.TypeSpec{}, &ast.TypeSwitchStmt{}, &ast.UnaryExpr{}, &ast.ValueSpec{},
+}
+
+// skippedFields are the fields holding nodes that have no setter: comments,
+// and the fields of a file listing nodes found elsewhere in the tree.
+var skippedFields = map[string]bool{
+	"File.Imports":    true,
+	"File.Unresolved": true,
+	"File.Comments":   true,
+}
+
+// attribute is an attribute of a node, given as parameters of its constructor
+// or set by a method of its builder.
+type attribute struct {
+	name   string // the method setting the attribute; "" for constructors
+	doc    string
+	params string
+	body   string
+}
+
+// attributes lists the attributes of node types, by type name.
+var attributes = map[string][]attribute{
+	"Ident":      {{params: "name string", body: "b.astNode.Value = name"}},
+	"BasicLit":   {{params: "value string", body: "b.astNode.Value = value"}},
+	"GenDecl":    {tokAttribute, {name: "Grouped", doc: "parenthesizes the specs of the declaration, even if there is only one.", body: "b.astNode.Grouped = true"}},
+	"AssignStmt": {tokAttribute},
+	"IncDecStmt": {tokAttribute},
+	"BranchStmt": {tokAttribute},
+	"RangeStmt":  {{name: "Tok", doc: "sets the token assigning the key and value, token.DEFINE or token.ASSIGN.", params: "tok token.Token", body: "b.astNode.Tok = tok.String()"}},
+	"UnaryExpr":  {opAttribute},
+	"BinaryExpr": {opAttribute},
+	"ChanType":   {{params: "dir ast.ChanDir", body: "b.astNode.Dir = chanDir(dir)"}},
+	"TypeSpec":   {{name: "Alias", doc: "declares the type as an alias of its type, as in type A = B.", body: "b.astNode.Alias = true"}},
+	"CallExpr":   {{name: "Variadic", doc: "passes the last argument as the variadic parameter, as in f(xs...).", body: "b.astNode.Variadic = true"}},
+}
+
+var (
+	tokAttribute = attribute{params: "tok token.Token", body: "b.astNode.Tok = tok.String()"}
+	opAttribute  = attribute{params: "op token.Token", body: "b.astNode.Op = op.String()"}
+)
+
+// interfaces are the builder interfaces of the node interfaces of go/ast.
+var interfaces = []struct {
+	typ    reflect.Type
+	name   string
+	marker string
+}{
+	{reflect.TypeOf((*ast.Expr)(nil)).Elem(), "Expr", "exprNode"},
+	{reflect.TypeOf((*ast.Stmt)(nil)).Elem(), "Stmt", "stmtNode"},
+	{reflect.TypeOf((*ast.Decl)(nil)).Elem(), "Decl", "declNode"},
+	{reflect.TypeOf((*ast.Spec)(nil)).Elem(), "Spec", "specNode"},
+}
+
+var nodeType = reflect.TypeOf((*ast.Node)(nil)).Elem()
+
+func main() {
+	sort.Slice(nodeTypes, func(i, j int) bool {
+		return reflect.TypeOf(nodeTypes[i]).Elem().Name() < reflect.TypeOf(nodeTypes[j]).Elem().Name()
+	})
+
+	var body bytes.Buffer
+	for _, node := range nodeTypes {
+		writeBuilder(&body, reflect.TypeOf(node))
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by gen.go; DO NOT EDIT.\n\npackage astbuild\n\nimport (\n")
+	for _, pkg := range []string{"ast", "token"} {
+		if bytes.Contains(body.Bytes(), []byte(" "+pkg+".")) {
+			fmt.Fprintf(&buf, "\t\"go/%s\"\n", pkg)
+		}
+	}
+	buf.WriteString(")\n")
+	buf.Write(body.Bytes())
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatalf("error formatting builders: %v\n%s", err, buf.Bytes())
+	}
+	if err := os.WriteFile("builders.go", src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// writeBuilder writes the builder of the node type t.
+func writeBuilder(buf *bytes.Buffer, t reflect.Type) {
+	name := t.Elem().Name()
+	builder := name + "Builder"
+
+	var fields []reflect.StructField
+	var params []string
+	var roles []string
+	for i := 0; i < t.Elem().NumField(); i++ {
+		field := t.Elem().Field(i)
+		param := paramType(field.Type)
+		if param == "" || skippedFields[name+"."+field.Name] {
+			continue
+		}
+		fields = append(fields, field)
+		params = append(params, param)
+		roles = append(roles, fmt.Sprintf("%q", snakeCase(field.Name)))
+	}
+
+	fmt.Fprintf(buf, "\n// %s builds *ast.%s nodes.\ntype %s struct{ node }\n", builder, name, builder)
+
+	var constructorParams, constructorBody string
+	var options []attribute
+	for _, attr := range attributes[name] {
+		if attr.name == "" {
+			constructorParams, constructorBody = attr.params, attr.body
+		} else {
+			options = append(options, attr)
+		}
+	}
+	fmt.Fprintf(buf, "\n// %s returns the builder of a new *ast.%s node.\n", name, name)
+	fmt.Fprintf(buf, "func (Builder) %s(%s) *%s {\n", name, constructorParams, builder)
+	fmt.Fprintf(buf, "\tb := &%s{newNode(%s)}\n", builder, strings.Join(append([]string{fmt.Sprintf("%q", "*ast."+name)}, roles...), ", "))
+	if constructorBody != "" {
+		fmt.Fprintf(buf, "\t%s\n", constructorBody)
+	}
+	buf.WriteString("\treturn b\n}\n")
+
+	for i, field := range fields {
+		param := paramName(field.Name)
+		if strings.HasPrefix(params[i], "...") {
+			fmt.Fprintf(buf, "\n// %s sets the nodes of the %s field.\n", field.Name, snakeCase(field.Name))
+			fmt.Fprintf(buf, "func (b *%s) %s(%s %s) *%s {\n", builder, field.Name, param, params[i], builder)
+			fmt.Fprintf(buf, "\tchildren := make([]Node, len(%s))\n\tfor i, child := range %s {\n\t\tchildren[i] = child\n\t}\n", param, param)
+			fmt.Fprintf(buf, "\tb.set(%d, children...)\n\treturn b\n}\n", i)
+			continue
+		}
+		fmt.Fprintf(buf, "\n// %s sets the node of the %s field.\n", field.Name, snakeCase(field.Name))
+		fmt.Fprintf(buf, "func (b *%s) %s(%s %s) *%s {\n", builder, field.Name, param, params[i], builder)
+		fmt.Fprintf(buf, "\tb.set(%d, %s)\n\treturn b\n}\n", i, param)
+	}
+
+	for _, attr := range options {
+		fmt.Fprintf(buf, "\n// %s %s\n", attr.name, attr.doc)
+		fmt.Fprintf(buf, "func (b *%s) %s(%s) *%s {\n\t%s\n\treturn b\n}\n", builder, attr.name, attr.params, builder, attr.body)
+	}
+
+	for _, iface := range interfaces {
+		if t.Implements(iface.typ) {
+			fmt.Fprintf(buf, "\nfunc (*%s) %s() {}\n", builder, iface.marker)
+		}
+	}
+}
+
+// paramType returns the type of the setter parameter for a field of type t,
+// or "" if the field does not hold nodes.
+func paramType(t reflect.Type) string {
+	if t.Kind() == reflect.Slice {
+		if elem := paramType(t.Elem()); elem != "" {
+			return "..." + elem
+		}
+		return ""
+	}
+	for _, iface := range interfaces {
+		if t == iface.typ {
+			return iface.name
+		}
+	}
+	if t.Kind() == reflect.Pointer && t.Implements(nodeType) && t.Elem() != reflect.TypeOf(ast.CommentGroup{}) {
+		return "*" + t.Elem().Name() + "Builder"
+	}
+	return ""
+}
+
+// paramName returns the name of the setter parameter for a field, which must
+// not be a keyword such as type nor hide a predeclared name such as len.
+func paramName(field string) string {
+	name := strings.ToLower(field[:1]) + field[1:]
+	if token.IsKeyword(name) || types.Universe.Lookup(name) != nil {
+		name += "Node"
+	}
+	return name
+}
+
+// snakeCase converts a field name such as TypeParams into the role
+// type_params, like astjson does.
+func snakeCase(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
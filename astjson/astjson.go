@@ -0,0 +1,771 @@
+// Package astjson converts Go syntax trees into ASTNode trees, a representation
+// of the tree that serializes to JSON.
+package astjson
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// ASTNode represents a node in the abstract syntax tree.
+type ASTNode struct {
+	FormatVersion int                 `json:"format_version,omitempty"`
+	Source        *SourceHeader       `json:"source,omitempty"`
+	KindTable     []string            `json:"kind_table,omitempty"`
+	Name          string              `json:"name,omitempty"`
+	Type          string              `json:"type,omitempty"`
+	Role          string              `json:"role,omitempty"`
+	Kind          *int                `json:"kind,omitempty"`
+	ID            string              `json:"id,omitempty"`
+	Meta          *FileMeta           `json:"meta,omitempty"`
+	Children      []*ASTNode          `json:"children,omitempty"`
+	Value         interface{}         `json:"value,omitempty"`
+	Comments      []string            `json:"comments,omitempty"`
+	CommentInfo   []*Comment          `json:"comment_info,omitempty"`
+	Doc           string              `json:"doc,omitempty"`
+	Params        []*Param            `json:"params,omitempty"`
+	Results       []*Param            `json:"results,omitempty"`
+	TypeParams    []*Param            `json:"type_params,omitempty"`
+	TypeArgs      []string            `json:"type_args,omitempty"`
+	Receiver      *Receiver           `json:"receiver,omitempty"`
+	Signature     string              `json:"signature,omitempty"`
+	Owner         *Ownership          `json:"owner,omitempty"`
+	Calls         []string            `json:"calls,omitempty"`
+	Annotations   jsoniter.RawMessage `json:"annotations,omitempty"`
+	Count         *int                `json:"count,omitempty"`
+	LitKind       string              `json:"lit_kind,omitempty"`
+	AssignKind    string              `json:"assign_kind,omitempty"`
+	Op            string              `json:"op,omitempty"`
+	Tok           string              `json:"tok,omitempty"`
+	Dir           string              `json:"dir,omitempty"`
+	Tag           *StructTag          `json:"tag,omitempty"`
+	Keyed         *bool               `json:"keyed,omitempty"`
+	Grouped       bool                `json:"grouped,omitempty"`
+	Variadic      bool                `json:"variadic,omitempty"`
+	Alias         bool                `json:"alias,omitempty"`
+	Lparen        *Position           `json:"lparen,omitempty"`
+	Rparen        *Position           `json:"rparen,omitempty"`
+	Parens        int                 `json:"parens,omitempty"`
+	Folded        *string             `json:"folded,omitempty"`
+	Pos           *Position           `json:"pos,omitempty"`
+	End           *Position           `json:"end,omitempty"`
+	Span          *[2]int             `json:"span,omitempty"`
+	Code          string              `json:"code,omitempty"`
+	Blank         bool                `json:"blank,omitempty"`
+	Truncated     bool                `json:"truncated,omitempty"`
+	Unused        bool                `json:"unused,omitempty"`
+	GoType        string              `json:"go_type,omitempty"`
+	ObjKind       string              `json:"obj_kind,omitempty"`
+	Reports       *FileReports        `json:"reports,omitempty"`
+	Chunks        []*Chunk            `json:"chunks,omitempty"`
+	Tables        *PackageTables      `json:"tables,omitempty"`
+}
+
+// Position is a source position: the file name, the 1-based line and column
+// (in bytes), and the 0-based byte offset in the file. The end position of a
+// node is the position just after it, so the span [pos.offset, end.offset) of
+// a node slices its exact source text out of the file.
+type Position struct {
+	Filename string `json:"filename,omitempty"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Offset   int    `json:"offset"`
+}
+
+// Chunk is a top-level declaration of a file converted in chunks, written as a
+// document of its own. The root node of the file lists its chunks in order;
+// appending the chunk documents to the children of the root node restores the
+// tree of the whole file. Decls names the declarations of the chunk, such as
+// "func F" or "type T". Start and End are the byte offsets of the declaration
+// in the original file.
+type Chunk struct {
+	Document string   `json:"document"`
+	Decls    []string `json:"decls,omitempty"`
+	Line     int      `json:"line,omitempty"`
+	Start    int      `json:"start,omitempty"`
+	End      int      `json:"end,omitempty"`
+}
+
+// Comment describes the comment of the same index in the comments of a node.
+// Kind is line for // comments and block for /* */ comments. Group is the index
+// of the comment group the comment belongs to among those of the node, and Doc
+// is set if that group is the doc comment of the node. Pos and End are left out
+// along with the other positions.
+type Comment struct {
+	Kind  string    `json:"kind"`
+	Group int       `json:"group"`
+	Doc   bool      `json:"doc,omitempty"`
+	Pos   *Position `json:"pos,omitempty"`
+	End   *Position `json:"end,omitempty"`
+}
+
+// Param is a single parameter or result of a function signature. Group is the
+// index of the declaring field, so names declared together (a, b int) share it.
+// Unused marks, with type information, a named parameter or result the body of
+// the function never refers to.
+type Param struct {
+	Name     string `json:"name,omitempty"`
+	Type     string `json:"type"`
+	Variadic bool   `json:"variadic,omitempty"`
+	Group    int    `json:"group"`
+	Unused   bool   `json:"unused,omitempty"`
+}
+
+// Receiver describes the receiver of a method: its variable name (if any), the
+// name of its base type, whether it is a pointer receiver and, for methods of
+// generic types, the receiver's type parameter names.
+type Receiver struct {
+	Name       string   `json:"name,omitempty"`
+	Type       string   `json:"type"`
+	Pointer    bool     `json:"pointer"`
+	TypeParams []string `json:"type_params,omitempty"`
+}
+
+// Ownership tags a declaration with the region it belongs to, as marked by a
+// "// region: NAME" comment before it, and the owners CODEOWNERS assigns its file.
+type Ownership struct {
+	Region string   `json:"region,omitempty"`
+	Owners []string `json:"owners,omitempty"`
+}
+
+// methodReceiver returns the receiver details of a method, or nil for plain functions.
+func methodReceiver(recv *ast.FieldList) *Receiver {
+	if recv == nil || len(recv.List) == 0 {
+		return nil
+	}
+	field := recv.List[0]
+	receiver := &Receiver{}
+	if len(field.Names) > 0 {
+		receiver.Name = field.Names[0].Name
+	}
+
+	expr := ast.Unparen(field.Type)
+	if star, ok := expr.(*ast.StarExpr); ok {
+		receiver.Pointer = true
+		expr = ast.Unparen(star.X)
+	}
+	switch index := expr.(type) {
+	case *ast.IndexExpr:
+		expr = index.X
+		receiver.TypeParams = append(receiver.TypeParams, types.ExprString(index.Index))
+	case *ast.IndexListExpr:
+		expr = index.X
+		for _, param := range index.Indices {
+			receiver.TypeParams = append(receiver.TypeParams, types.ExprString(param))
+		}
+	}
+	receiver.Type = types.ExprString(expr)
+	return receiver
+}
+
+// classifyCompositeLit returns whether lit is a struct, map, slice or array literal
+// and, for struct literals with elements, whether the elements are keyed. The kind is
+// taken from type information when available and guessed from the syntax otherwise;
+// it is empty if it cannot be determined.
+func (m *Marshaler) classifyCompositeLit(lit *ast.CompositeLit) (string, *bool) {
+	litType := lit.Type
+	if litType == nil {
+		litType = m.impliedTypes[lit]
+	}
+	if litType != nil {
+		m.recordImpliedTypes(litType, lit.Elts)
+	}
+
+	kind := ""
+	if m.Info != nil {
+		if tv, ok := m.Info.Types[lit]; ok && tv.Type != nil {
+			kind = typeLitKind(tv.Type)
+		}
+	}
+	if kind == "" && litType != nil {
+		kind = syntacticLitKind(litType, lit.Elts)
+	}
+
+	if kind != "struct" || len(lit.Elts) == 0 {
+		return kind, nil
+	}
+	_, keyed := lit.Elts[0].(*ast.KeyValueExpr)
+	return kind, &keyed
+}
+
+// recordImpliedTypes remembers the types of element literals of a literal of
+// type litType whose own type is elided.
+func (m *Marshaler) recordImpliedTypes(litType ast.Expr, elts []ast.Expr) {
+	imply := func(elt ast.Expr, eltType ast.Expr) {
+		if lit, ok := elt.(*ast.CompositeLit); ok && lit.Type == nil {
+			// Elided &T{} elements of []*T literals are written as {...}.
+			if star, ok := eltType.(*ast.StarExpr); ok {
+				eltType = star.X
+			}
+			m.impliedTypes[lit] = eltType
+		}
+	}
+	switch t := ast.Unparen(litType).(type) {
+	case *ast.ArrayType:
+		for _, elt := range elts {
+			if kv, ok := elt.(*ast.KeyValueExpr); ok {
+				elt = kv.Value
+			}
+			imply(elt, t.Elt)
+		}
+	case *ast.MapType:
+		for _, elt := range elts {
+			if kv, ok := elt.(*ast.KeyValueExpr); ok {
+				imply(kv.Key, t.Key)
+				imply(kv.Value, t.Value)
+			}
+		}
+	}
+}
+
+// chanDir returns the direction of a channel type: send, recv or both.
+func chanDir(dir ast.ChanDir) string {
+	switch dir {
+	case ast.SEND:
+		return "send"
+	case ast.RECV:
+		return "recv"
+	}
+	return "both"
+}
+
+// typeLitKind returns the literal kind for the type of a composite literal.
+func typeLitKind(t types.Type) string {
+	switch u := t.Underlying().(type) {
+	case *types.Struct:
+		return "struct"
+	case *types.Map:
+		return "map"
+	case *types.Slice:
+		return "slice"
+	case *types.Array:
+		return "array"
+	case *types.Pointer:
+		return typeLitKind(u.Elem())
+	}
+	return ""
+}
+
+// syntacticLitKind guesses the literal kind from the literal's type expression.
+// For named types, elements keyed by identifiers indicate a struct and other keys
+// indicate a map; positional elements leave the kind undetermined.
+func syntacticLitKind(litType ast.Expr, elts []ast.Expr) string {
+	switch t := ast.Unparen(litType).(type) {
+	case *ast.StructType:
+		return "struct"
+	case *ast.MapType:
+		return "map"
+	case *ast.ArrayType:
+		if t.Len == nil {
+			return "slice"
+		}
+		return "array"
+	}
+	if len(elts) == 0 {
+		return ""
+	}
+	kv, ok := elts[0].(*ast.KeyValueExpr)
+	if !ok {
+		return ""
+	}
+	if _, ok := kv.Key.(*ast.Ident); ok {
+		return "struct"
+	}
+	return "map"
+}
+
+// ParamList flattens a parameter or result list into one Param per name.
+// Unnamed entries produce a single Param without a name.
+func ParamList(fields *ast.FieldList) []*Param {
+	if fields == nil {
+		return nil
+	}
+	var params []*Param
+	for group, field := range fields.List {
+		fieldType, variadic := field.Type, false
+		if ellipsis, ok := fieldType.(*ast.Ellipsis); ok {
+			fieldType, variadic = ellipsis.Elt, true
+		}
+		typeString := types.ExprString(fieldType)
+		if len(field.Names) == 0 {
+			params = append(params, &Param{Type: typeString, Variadic: variadic, Group: group})
+			continue
+		}
+		for _, name := range field.Names {
+			params = append(params, &Param{Name: name.Name, Type: typeString, Variadic: variadic, Group: group})
+		}
+	}
+	return params
+}
+
+// assignKind classifies an assignment or declaration of lhs operands from the
+// values rhs: tuple if a single call returns all the values, comma_ok for the
+// two-value forms of type assertions, map indexing and channel receives, and
+// parallel if there is one value per operand. It returns "" for the assignment
+// of a single value and for declarations without values.
+func assignKind(lhs int, rhs []ast.Expr) string {
+	if lhs < 2 || len(rhs) == 0 {
+		return ""
+	}
+	if len(rhs) > 1 {
+		return "parallel"
+	}
+	switch rhs := ast.Unparen(rhs[0]).(type) {
+	case *ast.CallExpr:
+		return "tuple"
+	case *ast.TypeAssertExpr, *ast.IndexExpr:
+		return "comma_ok"
+	case *ast.UnaryExpr:
+		if rhs.Op == token.ARROW {
+			return "comma_ok"
+		}
+	}
+	return ""
+}
+
+// isInstantiation reports whether indexing x instantiates a generic function or
+// type. An index expression with a single index is only told apart from indexing
+// a value with type information; an index expression with several indices is
+// always an instantiation.
+func (m *Marshaler) isInstantiation(x ast.Expr) bool {
+	if m.Info == nil {
+		return false
+	}
+	var ident *ast.Ident
+	switch x := ast.Unparen(x).(type) {
+	case *ast.Ident:
+		ident = x
+	case *ast.SelectorExpr:
+		ident = x.Sel
+	default:
+		return false
+	}
+	_, ok := m.Info.Instances[ident]
+	return ok
+}
+
+// exprStrings returns the source form of the given expressions.
+func exprStrings(exprs []ast.Expr) []string {
+	strs := make([]string, len(exprs))
+	for i, expr := range exprs {
+		strs[i] = types.ExprString(expr)
+	}
+	return strs
+}
+
+// Marshaler converts ast.Nodes into ASTNodes. The zero value converts syntax
+// alone, without type information or extra annotations.
+type Marshaler struct {
+	// Info holds type information for the nodes to convert. It is optional;
+	// with it, composite literals and call sites are classified by their types,
+	// expressions carry their type in GoType and identifiers the kind of their
+	// object in ObjKind.
+	Info *types.Info
+
+	// Options control the conversion; Filename and Indent are not used.
+	Options
+
+	// Fset is the file set the nodes were parsed with. Positions are only
+	// recorded if it is set.
+	Fset *token.FileSet
+
+	// Annotate, if set, is called for every ASTNode created, with the node it
+	// represents, before the node's children are converted.
+	Annotate func(node ast.Node, astNode *ASTNode)
+
+	// skipped holds the nodes left out of the tree although a field of their
+	// parent holds them: the comment groups listed in the comments of the nodes
+	// they belong to, the bodies left out for SkipBodies, and the declarations
+	// already handed out by MarshalChunks.
+	skipped map[ast.Node]bool
+	depth   int
+
+	// parents holds the nodes being converted, from the outermost one to the
+	// innermost one, for the Role of their children.
+	parents []ast.Node
+
+	// impliedTypes holds the element types of composite literals whose type is
+	// elided inside an enclosing literal, such as the inner literals of []T{{...}}.
+	impliedTypes map[*ast.CompositeLit]ast.Expr
+
+	// comments associates the comment groups of the converted file with the
+	// nodes they belong to, for Comments.
+	comments ast.CommentMap
+
+	// unusedParams holds the parameter and result names of the functions
+	// converted so far that their bodies never refer to, with type information.
+	unusedParams map[*ast.Ident]bool
+
+	// foldedOperands holds the string concatenations nested in one whose folded
+	// value has been recorded, for FoldStrings.
+	foldedOperands map[*ast.BinaryExpr]bool
+
+	// parens counts the parentheses elided around the node converted next, for
+	// DropParens, and parenRole holds the role of the outermost of them.
+	parens    int
+	parenRole string
+
+	// stream writes the nodes as they are converted for MarshalStream, which
+	// leaves their children out of the tree.
+	stream *nodeStream
+}
+
+// MarshalNode converts node and its descendants into an ASTNode tree.
+func MarshalNode(node ast.Node) *ASTNode {
+	return (&Marshaler{}).Marshal(node)
+}
+
+// MarshalFile parses a Go source file and converts it into an ASTNode tree whose
+// root carries the file's source header and metadata and whose nodes carry
+// their source positions.
+func MarshalFile(path string) (*ASTNode, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading Go source file %s: %w", path, err)
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.AllErrors)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing Go source file %s: %w", path, err)
+	}
+	astNode := (&Marshaler{Options: Options{Positions: true}, Fset: fset}).Marshal(file)
+	astNode.Source = NewSourceHeader(path, src)
+	astNode.Meta, err = SourceMetadata(path, src, file)
+	if err != nil {
+		return nil, err
+	}
+	return astNode, nil
+}
+
+// Marshal converts node and its descendants into an ASTNode tree.
+func (m *Marshaler) Marshal(node ast.Node) *ASTNode {
+	m.reset(node)
+	astNode := m.marshalAST(node)
+	if _, ok := node.(*ast.File); ok && astNode != nil {
+		astNode.FormatVersion = FormatVersion
+	}
+	return astNode
+}
+
+// MarshalChunks converts file like Marshal, but hands the node of each
+// top-level declaration to emit as soon as it is converted instead of adding it
+// to the children of the file node, so that the tree of a large file is never
+// held in memory as a whole. The file node is returned without the
+// declarations; they follow its children in order. An error returned by emit
+// stops the conversion.
+func (m *Marshaler) MarshalChunks(file *ast.File, emit func(decl ast.Decl, astNode *ASTNode) error) (*ASTNode, error) {
+	m.reset(file)
+	for _, decl := range file.Decls {
+		// Declarations are converted at the depth they have under the file node.
+		m.depth, m.parents = 1, []ast.Node{file}
+		astNode := m.marshalAST(decl)
+		m.depth, m.parents = 0, nil
+		if astNode == nil {
+			continue
+		}
+		astNode.FormatVersion = FormatVersion
+		if err := emit(decl, astNode); err != nil {
+			return nil, err
+		}
+		m.skip(decl)
+	}
+	astNode := m.marshalAST(file)
+	astNode.FormatVersion = FormatVersion
+	return astNode, nil
+}
+
+// reset clears the state of a previous conversion before converting node.
+func (m *Marshaler) reset(node ast.Node) {
+	m.skipped = make(map[ast.Node]bool)
+	m.impliedTypes = make(map[*ast.CompositeLit]ast.Expr)
+	m.foldedOperands = make(map[*ast.BinaryExpr]bool)
+	m.unusedParams = make(map[*ast.Ident]bool)
+	m.depth = 0
+	m.parents = nil
+	m.parens = 0
+	m.comments = nil
+	if file, ok := node.(*ast.File); ok && m.Comments && m.Fset != nil {
+		m.comments = ast.NewCommentMap(m.Fset, file, file.Comments)
+		// Comment groups are listed in the comments of the node they belong
+		// to rather than converted where a Doc or Comment field holds them.
+		for _, group := range file.Comments {
+			m.skip(group)
+		}
+	}
+}
+
+// position converts pos into a Position, or returns nil if pos is not valid,
+// as for nodes synthesized rather than parsed.
+func (m *Marshaler) position(pos token.Pos) *Position {
+	if !pos.IsValid() {
+		return nil
+	}
+	p := m.Fset.Position(pos)
+	return &Position{Filename: p.Filename, Line: p.Line, Column: p.Column, Offset: p.Offset}
+}
+
+// setPositions records the source range of node on astNode, unless positions
+// are left out.
+func (m *Marshaler) setPositions(node ast.Node, astNode *ASTNode) {
+	if !m.Positions || m.Fset == nil {
+		return
+	}
+	astNode.Pos, astNode.End = m.position(node.Pos()), m.position(node.End())
+	if astNode.Pos != nil && astNode.End != nil && astNode.Pos.Filename == astNode.End.Filename {
+		astNode.Span = &[2]int{astNode.Pos.Offset, astNode.End.Offset}
+	}
+}
+
+// formatDecl returns the source of decl as gofmt formats it, or "" if it
+// cannot be printed, as for a BadDecl.
+func (m *Marshaler) formatDecl(decl ast.Decl) string {
+	var buf bytes.Buffer
+	if err := format.Node(&buf, m.Fset, decl); err != nil {
+		return ""
+	}
+	return buf.String()
+}
+
+// skip leaves node, and with it its descendants, out of the tree.
+func (m *Marshaler) skip(node ast.Node) {
+	m.skipped[node] = true
+}
+
+// marshalAST converts an ast.Node into an ASTNode.
+func (m *Marshaler) marshalAST(node ast.Node) *ASTNode {
+	if node == nil {
+		return nil
+	}
+
+	if m.skipped[node] {
+		return nil
+	}
+	var parent ast.Node
+	if len(m.parents) > 0 {
+		parent = m.parents[len(m.parents)-1]
+	}
+
+	// Past MaxDepth, a subtree is replaced by a marker node telling its kind
+	// and source range, so consumers can tell the tree is incomplete.
+	if m.MaxDepth > 0 && m.depth >= m.MaxDepth {
+		astNode := m.newNode(node, parent)
+		astNode.Truncated = true
+		m.specDoc(parent, astNode)
+		m.stream.begin(astNode)
+		m.stream.end()
+		return astNode
+	}
+	m.depth++
+	m.parents = append(m.parents, node)
+	defer func() {
+		m.depth--
+		m.parents = m.parents[:len(m.parents)-1]
+	}()
+
+	// Elide parentheses, recording on the operand how many wrapped it. The
+	// operand takes the role of the outermost parentheses.
+	if paren, ok := node.(*ast.ParenExpr); ok && m.DropParens {
+		if m.parens == 0 {
+			m.parenRole = fieldRole(parent, paren)
+		}
+		m.parens++
+		astNode := m.marshalAST(paren.X)
+		m.parens = 0
+		return astNode
+	}
+
+	astNode := m.newNode(node, parent)
+	for i, group := range m.comments[node] {
+		doc := group == docGroup(node)
+		for _, comment := range group.List {
+			astNode.Comments = append(astNode.Comments, comment.Text)
+			astNode.CommentInfo = append(astNode.CommentInfo, m.commentInfo(comment, i, doc))
+		}
+	}
+	if decl, ok := node.(ast.Decl); ok && m.DeclSource && m.Fset != nil {
+		astNode.Code = m.formatDecl(decl)
+	}
+	if m.Annotate != nil {
+		m.Annotate(node, astNode)
+	}
+	if m.Info != nil {
+		m.annotateTypes(node, astNode)
+	}
+
+	m.attributes(node, astNode)
+	m.specDoc(parent, astNode)
+
+	// Convert the nodes held by the fields of node, in field order. Streamed
+	// nodes are written as they are converted instead.
+	m.stream.begin(astNode)
+	for _, child := range childNodes(node) {
+		childNode := m.marshalAST(child)
+		if childNode != nil && m.stream == nil {
+			astNode.Children = append(astNode.Children, childNode)
+		}
+	}
+	m.stream.end()
+
+	return astNode
+}
+
+// newNode returns the ASTNode of node, a child of parent, with its kind, role
+// and positions. It takes over the parentheses elided around node.
+func (m *Marshaler) newNode(node, parent ast.Node) *ASTNode {
+	astNode := &ASTNode{Type: fmt.Sprintf("%T", node), Role: fieldRole(parent, node)}
+	if m.parens > 0 {
+		astNode.Parens, astNode.Role = m.parens, m.parenRole
+		m.parens = 0
+	}
+	m.setPositions(node, astNode)
+	return astNode
+}
+
+// specDoc gives the spec of an ungrouped declaration the doc comment of the
+// declaration, which documents it, unless it has one of its own.
+func (m *Marshaler) specDoc(parent ast.Node, astNode *ASTNode) {
+	if decl, ok := parent.(*ast.GenDecl); ok && !decl.Lparen.IsValid() && astNode.Role == "specs" && astNode.Doc == "" {
+		astNode.Doc = decl.Doc.Text()
+	}
+}
+
+// attributes records on astNode the attributes of node that are not nodes
+// themselves, such as names, operators and literal values, and anything else a
+// kind needs before its children are converted. Kinds without attributes, and
+// node types added to go/ast later, are converted by their children alone.
+func (m *Marshaler) attributes(node ast.Node, astNode *ASTNode) {
+	switch n := node.(type) {
+	case *ast.Ident:
+		astNode.Value = n.Name
+		astNode.Blank = n.Name == "_"
+		astNode.Unused = m.unusedParams[n]
+	case *ast.BasicLit:
+		astNode.Value = n.Value
+	case *ast.Comment:
+		astNode.Comments = append(astNode.Comments, n.Text)
+	case *ast.File:
+		astNode.Value = n.Name.Name
+	case *ast.GenDecl:
+		astNode.Doc = n.Doc.Text()
+		astNode.Tok = n.Tok.String()
+		// A parenthesized declaration is grouped even if it has a single
+		// spec or none; its parentheses delimit the group.
+		astNode.Grouped = n.Lparen.IsValid()
+		if astNode.Grouped && m.Positions && m.Fset != nil {
+			astNode.Lparen, astNode.Rparen = m.position(n.Lparen), m.position(n.Rparen)
+		}
+	case *ast.FuncDecl:
+		astNode.Name = n.Name.Name
+		astNode.Doc = n.Doc.Text()
+		astNode.Receiver = methodReceiver(n.Recv)
+		astNode.Signature = m.signature(n)
+		astNode.Calls = m.callSites(n.Body)
+		if n.Type != nil {
+			astNode.TypeParams = ParamList(n.Type.TypeParams)
+		}
+		if m.Info != nil {
+			m.recordUnusedParams(n.Type, n.Body)
+		}
+		if n.Body != nil && m.SkipBodies {
+			m.skip(n.Body)
+		}
+	case *ast.FuncLit:
+		if m.Info != nil {
+			m.recordUnusedParams(n.Type, n.Body)
+		}
+		if n.Body != nil && m.SkipBodies {
+			m.skip(n.Body)
+		}
+	case *ast.TypeSpec:
+		astNode.Name = n.Name.Name
+		astNode.Doc = n.Doc.Text()
+		astNode.TypeParams = ParamList(n.TypeParams)
+		astNode.Alias = n.Assign.IsValid()
+	case *ast.ValueSpec:
+		astNode.Doc = n.Doc.Text()
+		astNode.AssignKind = assignKind(len(n.Names), n.Values)
+	case *ast.Field:
+		astNode.Doc = n.Doc.Text()
+		if n.Tag != nil {
+			astNode.Tag = NewStructTag(n.Tag.Value)
+		}
+	case *ast.FuncType:
+		astNode.Params = ParamList(n.Params)
+		astNode.Results = ParamList(n.Results)
+		m.markUnusedParams(astNode.Params, n.Params)
+		m.markUnusedParams(astNode.Results, n.Results)
+	case *ast.ChanType:
+		astNode.Dir = chanDir(n.Dir)
+	case *ast.AssignStmt:
+		astNode.Tok = n.Tok.String()
+		astNode.AssignKind = assignKind(len(n.Lhs), n.Rhs)
+	case *ast.IncDecStmt:
+		astNode.Tok = n.Tok.String()
+	case *ast.RangeStmt:
+		if n.Key != nil {
+			astNode.Tok = n.Tok.String()
+		}
+	case *ast.CallExpr:
+		astNode.Variadic = n.Ellipsis.IsValid()
+	case *ast.BranchStmt:
+		astNode.Tok = n.Tok.String()
+	case *ast.UnaryExpr:
+		astNode.Op = n.Op.String()
+	case *ast.BinaryExpr:
+		astNode.Op = n.Op.String()
+		if m.FoldStrings {
+			astNode.Folded = m.foldedValue(n)
+		}
+	case *ast.CompositeLit:
+		astNode.LitKind, astNode.Keyed = m.classifyCompositeLit(n)
+	case *ast.IndexExpr:
+		if m.isInstantiation(n.X) {
+			astNode.TypeArgs = exprStrings([]ast.Expr{n.Index})
+		}
+	case *ast.IndexListExpr:
+		astNode.TypeArgs = exprStrings(n.Indices)
+	}
+}
+
+// commentInfo describes comment, a comment of group number group of a node.
+func (m *Marshaler) commentInfo(comment *ast.Comment, group int, doc bool) *Comment {
+	info := &Comment{Kind: "line", Group: group, Doc: doc}
+	if strings.HasPrefix(comment.Text, "/*") {
+		info.Kind = "block"
+	}
+	if m.Positions && m.Fset != nil {
+		info.Pos, info.End = m.position(comment.Pos()), m.position(comment.End())
+	}
+	return info
+}
+
+// docGroup returns the doc comment of node, or nil if it has none or its kind
+// cannot have one.
+func docGroup(node ast.Node) *ast.CommentGroup {
+	switch n := node.(type) {
+	case *ast.File:
+		return n.Doc
+	case *ast.GenDecl:
+		return n.Doc
+	case *ast.FuncDecl:
+		return n.Doc
+	case *ast.TypeSpec:
+		return n.Doc
+	case *ast.ValueSpec:
+		return n.Doc
+	case *ast.ImportSpec:
+		return n.Doc
+	case *ast.Field:
+		return n.Doc
+	}
+	return nil
+}
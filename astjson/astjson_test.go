@@ -0,0 +1,98 @@
+package astjson
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// corpusFiles returns the Go files the tests convert: the selftest corpus and
+// the sources of this package.
+func corpusFiles(t *testing.T) []string {
+	t.Helper()
+	var files []string
+	for _, pattern := range []string{"../testdata/selftest/*.go", "*.go"} {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, matches...)
+	}
+	if len(files) == 0 {
+		t.Fatal("no Go files to convert")
+	}
+	return files
+}
+
+// encodeNode returns the compact JSON document of astNode.
+func encodeNode(t *testing.T, astNode *ASTNode) []byte {
+	t.Helper()
+	var json = jsoniter.ConfigCompatibleWithStandardLibrary
+	data, err := json.Marshal(astNode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// decodeNode parses a JSON document into an ASTNode tree.
+func decodeNode(t *testing.T, data []byte) *ASTNode {
+	t.Helper()
+	var json = jsoniter.ConfigCompatibleWithStandardLibrary
+	astNode := &ASTNode{}
+	if err := json.Unmarshal(data, astNode); err != nil {
+		t.Fatal(err)
+	}
+	return astNode
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	for _, path := range corpusFiles(t) {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			astNode, err := MarshalFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if astNode.FormatVersion != FormatVersion {
+				t.Errorf("format version is %d, want %d", astNode.FormatVersion, FormatVersion)
+			}
+			data := encodeNode(t, astNode)
+			if again := encodeNode(t, decodeNode(t, data)); !bytes.Equal(again, data) {
+				t.Errorf("document changed in a JSON round trip:\n%s\n%s", data, again)
+			}
+		})
+	}
+}
+
+func TestMarshalStreamMatchesMarshal(t *testing.T) {
+	for _, path := range corpusFiles(t) {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			src, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fset := token.NewFileSet()
+			file, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+			if err != nil {
+				t.Fatal(err)
+			}
+			opts := Options{Comments: true, Positions: true}
+			want := encodeNode(t, (&Marshaler{Options: opts, Fset: fset}).Marshal(file))
+
+			var streamed bytes.Buffer
+			if err := (&Marshaler{Options: opts, Fset: fset}).MarshalStream(&streamed, file, nil); err != nil {
+				t.Fatal(err)
+			}
+			// Streamed documents list children last; decoding restores the
+			// field order of ASTNode.
+			if got := encodeNode(t, decodeNode(t, streamed.Bytes())); !bytes.Equal(got, want) {
+				t.Errorf("streamed document differs from the marshaled one:\n%s\n%s", want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package astjson
+
+import (
+	"fmt"
+	"go/ast"
+	"go/scanner"
+	"go/token"
+	"reflect"
+)
+
+// tokens maps the text of operators and keywords to their tokens.
+var tokens = make(map[string]token.Token)
+
+func init() {
+	for tok := token.ILLEGAL; tok <= token.TILDE; tok++ {
+		tokens[tok.String()] = tok
+	}
+}
+
+// BuildNode converts an ASTNode tree back into a go/ast tree that go/format
+// can print, for trees taken from documents or written by hand. Every child
+// is put into the field of its parent its role names. The tree has no
+// positions and no comments, and it cannot hold nodes truncated by MaxDepth
+// or kinds interned into a kind table.
+func BuildNode(astNode *ASTNode) (ast.Node, error) {
+	t, ok := astNodeTypes[astNode.Type]
+	if !ok {
+		if astNode.Type == "" {
+			return nil, fmt.Errorf("node has no type; kinds interned into a kind table are not supported")
+		}
+		return nil, fmt.Errorf("unknown node type %s", astNode.Type)
+	}
+	if astNode.Truncated {
+		return nil, fmt.Errorf("node %s was truncated and has no children", astNode.Type)
+	}
+	value := reflect.New(t.Elem())
+	node := value.Interface().(ast.Node)
+	if err := buildAttributes(node, astNode); err != nil {
+		return nil, err
+	}
+
+	fields := value.Elem()
+	for _, childNode := range astNode.Children {
+		child, err := BuildNode(childNode)
+		if err != nil {
+			return nil, err
+		}
+		field := fields.FieldByName(fieldName(t.Elem(), childNode.Role))
+		if !field.IsValid() {
+			return nil, fmt.Errorf("%s has no field for the role %q of its child %s", astNode.Type, childNode.Role, childNode.Type)
+		}
+		v := reflect.ValueOf(child)
+		if field.Kind() == reflect.Slice {
+			if !v.Type().AssignableTo(field.Type().Elem()) {
+				return nil, fmt.Errorf("%s cannot hold %s in %s", astNode.Type, childNode.Type, childNode.Role)
+			}
+			field.Set(reflect.Append(field, v))
+			continue
+		}
+		if !v.Type().AssignableTo(field.Type()) {
+			return nil, fmt.Errorf("%s cannot hold %s in %s", astNode.Type, childNode.Type, childNode.Role)
+		}
+		field.Set(v)
+	}
+
+	// Parentheses dropped with DropParens are restored around the node.
+	for i := 0; i < astNode.Parens; i++ {
+		expr, ok := node.(ast.Expr)
+		if !ok {
+			break
+		}
+		node = &ast.ParenExpr{X: expr}
+	}
+	return node, nil
+}
+
+// fieldName returns the name of the field of the struct type t whose role is
+// role, or "" if there is none.
+func fieldName(t reflect.Type, role string) string {
+	for i := 0; i < t.NumField(); i++ {
+		if field := t.Field(i); field.IsExported() && snakeCase(field.Name) == role {
+			return field.Name
+		}
+	}
+	return ""
+}
+
+// buildAttributes sets the fields of node that Marshaler.attributes records
+// on astNode rather than as children.
+func buildAttributes(node ast.Node, astNode *ASTNode) error {
+	value, _ := astNode.Value.(string)
+	var err error
+	switch n := node.(type) {
+	case *ast.Ident:
+		n.Name = value
+	case *ast.BasicLit:
+		n.Value = value
+		n.Kind, err = literalKind(value)
+	case *ast.Comment:
+		if len(astNode.Comments) > 0 {
+			n.Text = astNode.Comments[0]
+		}
+	case *ast.GenDecl:
+		n.Tok, err = lookupToken(astNode.Tok)
+		if astNode.Grouped {
+			// The printer only parenthesizes a single spec if the
+			// parentheses have valid positions.
+			n.Lparen, n.Rparen = 1, 1
+		}
+	case *ast.AssignStmt:
+		n.Tok, err = lookupToken(astNode.Tok)
+	case *ast.IncDecStmt:
+		n.Tok, err = lookupToken(astNode.Tok)
+	case *ast.BranchStmt:
+		n.Tok, err = lookupToken(astNode.Tok)
+	case *ast.RangeStmt:
+		// Documents written before range statements recorded their token
+		// declare the key and value, the common case.
+		n.Tok = token.DEFINE
+		if astNode.Tok != "" {
+			n.Tok, err = lookupToken(astNode.Tok)
+		}
+	case *ast.UnaryExpr:
+		n.Op, err = lookupToken(astNode.Op)
+	case *ast.BinaryExpr:
+		n.Op, err = lookupToken(astNode.Op)
+	case *ast.ChanType:
+		switch astNode.Dir {
+		case "send":
+			n.Dir = ast.SEND
+		case "recv":
+			n.Dir = ast.RECV
+		default:
+			n.Dir = ast.SEND | ast.RECV
+		}
+	case *ast.TypeSpec:
+		if astNode.Alias {
+			// Any valid position makes the printer write the =.
+			n.Assign = 1
+		}
+	case *ast.CallExpr:
+		if astNode.Variadic {
+			// Any valid position makes the printer write the ellipsis.
+			n.Ellipsis = 1
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("error building %s: %w", astNode.Type, err)
+	}
+	return nil
+}
+
+// lookupToken returns the token written as text.
+func lookupToken(text string) (token.Token, error) {
+	tok, ok := tokens[text]
+	if !ok {
+		return token.ILLEGAL, fmt.Errorf("unknown token %q", text)
+	}
+	return tok, nil
+}
+
+// literalKind returns the kind of the basic literal written as value.
+func literalKind(value string) (token.Token, error) {
+	var s scanner.Scanner
+	s.Init(token.NewFileSet().AddFile("", -1, len(value)), []byte(value), nil, 0)
+	_, tok, lit := s.Scan()
+	if !tok.IsLiteral() || tok == token.IDENT || lit != value {
+		return token.ILLEGAL, fmt.Errorf("invalid basic literal %q", value)
+	}
+	return tok, nil
+}
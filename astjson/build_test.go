@@ -0,0 +1,49 @@
+package astjson
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuildNodeRoundTrip(t *testing.T) {
+	for _, path := range corpusFiles(t) {
+		for _, opts := range []Options{{}, {DropParens: true}} {
+			t.Run(filepath.Base(path), func(t *testing.T) {
+				fset := token.NewFileSet()
+				file, err := parser.ParseFile(fset, path, nil, 0)
+				if err != nil {
+					t.Fatal(err)
+				}
+				want := encodeNode(t, (&Marshaler{Options: opts, Fset: fset}).Marshal(file))
+
+				built, err := BuildNode(decodeNode(t, want))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := encodeNode(t, (&Marshaler{Options: opts}).Marshal(built)); !bytes.Equal(got, want) {
+					t.Errorf("built tree converts to a different document:\n%s\n%s", want, got)
+				}
+			})
+		}
+	}
+}
+
+func TestBuildNodeErrors(t *testing.T) {
+	kind := 0
+	for name, astNode := range map[string]*ASTNode{
+		"unknown type":  {Type: "*ast.Nonexistent"},
+		"interned kind": {Kind: &kind},
+		"truncated":     {Type: "*ast.BlockStmt", Truncated: true},
+		"wrong role":    {Type: "*ast.ReturnStmt", Children: []*ASTNode{{Type: "*ast.Ident", Role: "body", Value: "x"}}},
+		"wrong type":    {Type: "*ast.ExprStmt", Children: []*ASTNode{{Type: "*ast.BlockStmt", Role: "x"}}},
+		"bad literal":   {Type: "*ast.BasicLit", Value: "1 + 2"},
+		"bad operator":  {Type: "*ast.BinaryExpr", Op: "<=>"},
+	} {
+		if _, err := BuildNode(astNode); err == nil {
+			t.Errorf("%s: building succeeded", name)
+		}
+	}
+}
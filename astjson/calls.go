@@ -0,0 +1,79 @@
+package astjson
+
+import (
+	"go/ast"
+	"go/types"
+)
+
+// callSites lists the functions and methods called in body, including calls made
+// inside function literals, once each in order of first appearance. With type
+// information, callees are reported by their full name (e.g. fmt.Println or
+// (*bytes.Buffer).Write) and conversions are left out; otherwise the callee
+// expression is reported as written.
+func (m *Marshaler) callSites(body *ast.BlockStmt) []string {
+	if body == nil {
+		return nil
+	}
+	var calls []string
+	seen := make(map[string]bool)
+	ast.Inspect(body, func(node ast.Node) bool {
+		call, ok := node.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		name, ok := m.calleeName(call.Fun)
+		if ok && !seen[name] {
+			seen[name] = true
+			calls = append(calls, name)
+		}
+		return true
+	})
+	return calls
+}
+
+// calleeName returns the name of the function called through fun. It returns
+// false if fun is a type, i.e. the call is a conversion, or a function literal
+// invoked in place, whose calls are listed with the enclosing function's.
+func (m *Marshaler) calleeName(fun ast.Expr) (string, bool) {
+	fun = ast.Unparen(fun)
+	if _, ok := fun.(*ast.FuncLit); ok {
+		return "", false
+	}
+	if m.Info != nil {
+		if tv, ok := m.Info.Types[fun]; ok && tv.IsType() {
+			return "", false
+		}
+	}
+
+	// Strip explicit instantiations such as f[int].
+	callee := fun
+	switch index := callee.(type) {
+	case *ast.IndexExpr:
+		callee = index.X
+	case *ast.IndexListExpr:
+		callee = index.X
+	}
+	if m.Info == nil {
+		return types.ExprString(callee), true
+	}
+
+	var obj types.Object
+	switch c := callee.(type) {
+	case *ast.Ident:
+		obj = m.Info.Uses[c]
+	case *ast.SelectorExpr:
+		if selection, ok := m.Info.Selections[c]; ok {
+			obj = selection.Obj()
+		} else {
+			obj = m.Info.Uses[c.Sel]
+		}
+	}
+	switch o := obj.(type) {
+	case *types.Func:
+		return o.FullName(), true
+	case *types.Builtin:
+		return o.Name(), true
+	}
+	// Calls of function values keep their syntactic form.
+	return types.ExprString(callee), true
+}
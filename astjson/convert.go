@@ -0,0 +1,103 @@
+package astjson
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// Convert reads a Go source file from src and writes its JSON document to dst.
+// Unlike MarshalFile it does not touch the filesystem, so the metadata of the
+// document leaves out module and import path.
+func Convert(src io.Reader, dst io.Writer, opts Options) error {
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return fmt.Errorf("error reading Go source %s: %w", opts.Filename, err)
+	}
+	astNode, file, err := convertSource(opts.Filename, data, opts)
+	if err != nil {
+		return err
+	}
+	astNode.Meta, err = sourceMetadata(opts.Filename, data, file)
+	if err != nil {
+		return err
+	}
+	return encode(dst, astNode, opts.Indent)
+}
+
+// SinkFactory opens the destination of the document converted from the source
+// file at path, such as an object in a bucket, an in-memory buffer or a network
+// stream. The document is written and the sink closed before the next file is
+// converted.
+type SinkFactory func(path string) (io.WriteCloser, error)
+
+// ConvertFiles converts the Go source files at paths and writes the document of
+// each to the sink opens for its path. opts.Filename is not used. A file that
+// fails to convert does not stop the others; the errors of all files are
+// returned together.
+func ConvertFiles(paths []string, sink SinkFactory, opts Options) error {
+	var errs []error
+	for _, path := range paths {
+		if err := convertFileTo(path, sink, opts); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// convertFileTo converts the source file at path into the sink opened for it.
+func convertFileTo(path string, sink SinkFactory, opts Options) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading Go source file %s: %w", path, err)
+	}
+	astNode, file, err := convertSource(path, data, opts)
+	if err != nil {
+		return err
+	}
+	astNode.Meta, err = SourceMetadata(path, data, file)
+	if err != nil {
+		return err
+	}
+
+	dst, err := sink(path)
+	if err != nil {
+		return fmt.Errorf("error opening the output of %s: %w", path, err)
+	}
+	if err := encode(dst, astNode, opts.Indent); err != nil {
+		dst.Close()
+		return fmt.Errorf("error writing the output of %s: %w", path, err)
+	}
+	if err := dst.Close(); err != nil {
+		return fmt.Errorf("error writing the output of %s: %w", path, err)
+	}
+	return nil
+}
+
+// convertSource parses the source data of the file name and converts it into
+// an ASTNode tree carrying its source header. The metadata is left to the
+// caller, which decides whether to look up the module.
+func convertSource(name string, data []byte, opts Options) (*ASTNode, *ast.File, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, name, data, opts.ParserMode())
+	if err != nil {
+		return nil, nil, fmt.Errorf("error parsing Go source %s: %w", name, err)
+	}
+	astNode := (&Marshaler{Options: opts, Fset: fset}).Marshal(file)
+	astNode.Source = NewSourceHeader(name, data)
+	return astNode, file, nil
+}
+
+// encode writes the JSON document of v to w.
+func encode(w io.Writer, v interface{}, indent string) error {
+	var json = jsoniter.ConfigCompatibleWithStandardLibrary
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", indent)
+	return encoder.Encode(v)
+}
@@ -0,0 +1,52 @@
+package astjson
+
+import (
+	"go/ast"
+	"go/token"
+	"strconv"
+)
+
+// foldConcat returns the value of a concatenation of string literals, such as
+// "a" + ("b" + `c`), or false if expr is not such a concatenation.
+func foldConcat(expr ast.Expr) (string, bool) {
+	switch e := ast.Unparen(expr).(type) {
+	case *ast.BasicLit:
+		if e.Kind != token.STRING {
+			return "", false
+		}
+		value, err := strconv.Unquote(e.Value)
+		return value, err == nil
+	case *ast.BinaryExpr:
+		if e.Op != token.ADD {
+			return "", false
+		}
+		x, ok := foldConcat(e.X)
+		if !ok {
+			return "", false
+		}
+		y, ok := foldConcat(e.Y)
+		return x + y, ok
+	}
+	return "", false
+}
+
+// foldedValue returns the folded value of a string concatenation for its
+// ASTNode, or nil if it cannot be folded or an enclosing concatenation already
+// carries the value. Nested concatenations are remembered so only the outermost
+// one of a chain is annotated.
+func (m *Marshaler) foldedValue(expr *ast.BinaryExpr) *string {
+	if m.foldedOperands[expr] {
+		return nil
+	}
+	value, ok := foldConcat(expr)
+	if !ok {
+		return nil
+	}
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if operand, ok := n.(*ast.BinaryExpr); ok && operand != expr {
+			m.foldedOperands[operand] = true
+		}
+		return true
+	})
+	return &value
+}
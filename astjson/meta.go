@@ -0,0 +1,308 @@
+package astjson
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"go/ast"
+	"go/build/constraint"
+	"go/scanner"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// FileMeta holds per-file metadata attached to the root node of a file. It is a
+// compact header that lets consumers filter files without reading their trees.
+// Build is the file's build constraint, from its //go:build line or else its
+// // +build lines; Cgo is set for files importing "C". Generator is set for
+// generated files.
+type FileMeta struct {
+	Module     string     `json:"module,omitempty"`
+	ImportPath string     `json:"import_path,omitempty"`
+	Package    string     `json:"package"`
+	Build      string     `json:"build,omitempty"`
+	Imports    int        `json:"imports"`
+	Main       bool       `json:"main,omitempty"`
+	Test       bool       `json:"test,omitempty"`
+	Cgo        bool       `json:"cgo,omitempty"`
+	Generator  *Generator `json:"generator,omitempty"`
+}
+
+// Generator describes the tool that generated a file, as named by its
+// "// Code generated by NAME. DO NOT EDIT." header. Name is empty if the header
+// does not say. Command is the command line for headers that quote one, such as
+// "stringer -type=Pill", and Version the version of the tool if the header
+// line or a "// versions:" block below it gives one.
+type Generator struct {
+	Name    string `json:"name,omitempty"`
+	Version string `json:"version,omitempty"`
+	Command string `json:"command,omitempty"`
+}
+
+// SourceHeader identifies the source a document was converted from, so
+// consumers can verify that a document matches a file: Path is the path of the
+// file, relative to the root of the conversion where there is one, Size its
+// length in bytes and SHA256 the hex-encoded SHA-256 of its content.
+type SourceHeader struct {
+	Path   string `json:"path"`
+	Size   int    `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// NewSourceHeader returns the header of the source src found at path.
+func NewSourceHeader(path string, src []byte) *SourceHeader {
+	sum := sha256.Sum256(src)
+	return &SourceHeader{Path: filepath.ToSlash(path), Size: len(src), SHA256: hex.EncodeToString(sum[:])}
+}
+
+// FileMetadata returns the metadata of a parsed source file. Module and import
+// path are only known for files inside a module.
+func FileMetadata(sourceFilePath string, file *ast.File) (*FileMeta, error) {
+	src, err := os.ReadFile(sourceFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading Go source file %s: %w", sourceFilePath, err)
+	}
+	return SourceMetadata(sourceFilePath, src, file)
+}
+
+// SourceMetadata is like FileMetadata for a file whose source src has already
+// been read. The module is looked up from the folder of sourceFilePath.
+func SourceMetadata(sourceFilePath string, src []byte, file *ast.File) (*FileMeta, error) {
+	meta, err := sourceMetadata(sourceFilePath, src, file)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := filepath.Abs(filepath.Dir(sourceFilePath))
+	if err != nil {
+		return meta, nil
+	}
+	if module := FindModule(dir); module != nil {
+		if rel, err := filepath.Rel(module.Dir, dir); err == nil {
+			meta.Module = module.Path
+			meta.ImportPath = path.Join(module.Path, filepath.ToSlash(rel))
+		}
+	}
+	return meta, nil
+}
+
+// sourceMetadata returns the metadata that can be derived from the name and
+// source of a file alone, leaving out module and import path.
+func sourceMetadata(name string, src []byte, file *ast.File) (*FileMeta, error) {
+	meta := &FileMeta{
+		Package: file.Name.Name,
+		Imports: len(file.Imports),
+		Main:    file.Name.Name == "main",
+		Test:    strings.HasSuffix(name, "_test.go"),
+	}
+	for _, spec := range file.Imports {
+		if spec.Path.Value == `"C"` {
+			meta.Cgo = true
+		}
+	}
+	build, err := buildConstraint(name, src)
+	if err != nil {
+		return nil, err
+	}
+	meta.Build = build
+	meta.Generator = generator(headerComments(name, src))
+	return meta, nil
+}
+
+// headerComments returns the comments before the package clause of the source
+// src, which the converted syntax tree does not keep, so the file header is
+// scanned directly.
+func headerComments(sourceFilePath string, src []byte) []string {
+	var s scanner.Scanner
+	s.Init(token.NewFileSet().AddFile(sourceFilePath, -1, len(src)), src, nil, scanner.ScanComments)
+	var comments []string
+	for {
+		_, tok, lit := s.Scan()
+		if tok != token.COMMENT {
+			return comments
+		}
+		comments = append(comments, lit)
+	}
+}
+
+// buildConstraint returns the build constraint of a source file in //go:build
+// syntax, or "" if it has none. Constraints are comment lines before the package
+// clause.
+func buildConstraint(sourceFilePath string, src []byte) (string, error) {
+	var plusBuild constraint.Expr
+	for _, lit := range headerComments(sourceFilePath, src) {
+		switch {
+		case constraint.IsGoBuild(lit):
+			expr, err := constraint.Parse(lit)
+			if err != nil {
+				return "", fmt.Errorf("error parsing build constraint of %s: %w", sourceFilePath, err)
+			}
+			return expr.String(), nil
+		case constraint.IsPlusBuild(lit):
+			expr, err := constraint.Parse(lit)
+			if err != nil {
+				return "", fmt.Errorf("error parsing build constraint of %s: %w", sourceFilePath, err)
+			}
+			if plusBuild == nil {
+				plusBuild = expr
+			} else {
+				plusBuild = &constraint.AndExpr{X: plusBuild, Y: expr}
+			}
+		}
+	}
+	if plusBuild == nil {
+		return "", nil
+	}
+	return plusBuild.String(), nil
+}
+
+// Module is a module found by FindModule.
+type Module struct {
+	Path string // module path declared in go.mod
+	Dir  string // folder containing go.mod
+}
+
+// moduleCache remembers the module of every folder looked up so far; nil entries
+// mark folders outside of any module. It is guarded by moduleCacheMu, since
+// files may be converted concurrently.
+var (
+	moduleCache   = make(map[string]*Module)
+	moduleCacheMu sync.Mutex
+)
+
+// FindModule returns the module containing the absolute folder dir by looking for
+// the closest go.mod in dir and its parents, or nil if there is none.
+func FindModule(dir string) *Module {
+	moduleCacheMu.Lock()
+	defer moduleCacheMu.Unlock()
+	return findModule(dir)
+}
+
+// findModule is FindModule with moduleCacheMu held.
+func findModule(dir string) *Module {
+	if module, ok := moduleCache[dir]; ok {
+		return module
+	}
+	var module *Module
+	if data, err := os.ReadFile(filepath.Join(dir, "go.mod")); err == nil {
+		if modPath := parseModulePath(data); modPath != "" {
+			module = &Module{Path: modPath, Dir: dir}
+		}
+	} else if parent := filepath.Dir(dir); parent != dir {
+		module = findModule(parent)
+	}
+	moduleCache[dir] = module
+	return module
+}
+
+// parseModulePath returns the path of the module directive of a go.mod file.
+func parseModulePath(gomod []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(gomod))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		rest, ok := strings.CutPrefix(line, "module")
+		if !ok || rest == "" || (rest[0] != ' ' && rest[0] != '\t' && rest[0] != '"') {
+			continue
+		}
+		rest = strings.TrimSpace(rest)
+		if unquoted, err := strconv.Unquote(rest); err == nil {
+			return unquoted
+		}
+		return rest
+	}
+	return ""
+}
+
+// generatedHeader matches the comment that marks a generated file, following
+// https://go.dev/s/generatedcode.
+var generatedHeader = regexp.MustCompile(`^// Code generated(.*)DO NOT EDIT\.$`)
+
+// versionPattern matches a version as tools write it, such as v1.28.1 or 0.4.1.
+var versionPattern = regexp.MustCompile(`^(v\d+(\.\d+)*|\d+(\.\d+)+)([-+.]\S*)?$`)
+
+// generator returns the generator of a file from the comments before its
+// package clause, or nil if the file is not generated.
+func generator(comments []string) *Generator {
+	for i, lit := range comments {
+		match := generatedHeader.FindStringSubmatch(lit)
+		if match == nil {
+			continue
+		}
+		gen := &Generator{}
+		by, ok := strings.CutPrefix(strings.TrimSpace(match[1]), "by ")
+		if !ok {
+			return gen
+		}
+		by = strings.TrimRight(by, ".,;: ")
+		if command, err := strconv.Unquote(by); err == nil {
+			gen.Command = command
+			gen.Name, gen.Version = commandName(command)
+		} else {
+			fields := strings.Fields(by)
+			if n := len(fields); n > 1 && versionPattern.MatchString(fields[n-1]) {
+				gen.Version, fields = fields[n-1], fields[:n-1]
+			}
+			gen.Name = strings.Join(fields, " ")
+			if len(fields) == 1 {
+				gen.Name = path.Base(fields[0])
+			}
+		}
+		if gen.Version == "" {
+			gen.Version = listedVersion(gen.Name, comments[i+1:])
+		}
+		return gen
+	}
+	return nil
+}
+
+// commandName returns the name of the tool a generator command line runs,
+// looking through go run and go tool, and the version of a tool run as
+// path@version.
+func commandName(command string) (name, version string) {
+	fields := strings.Fields(command)
+	if len(fields) > 2 && fields[0] == "go" && (fields[1] == "run" || fields[1] == "tool") {
+		fields = fields[2:]
+		for len(fields) > 1 && strings.HasPrefix(fields[0], "-") {
+			fields = fields[1:]
+		}
+	}
+	if len(fields) == 0 {
+		return "", ""
+	}
+	tool, version, _ := strings.Cut(fields[0], "@")
+	return path.Base(tool), version
+}
+
+// listedVersion returns the version of the tool name listed in comment lines
+// such as "//   protoc-gen-go v1.28.1" or "// - protoc-gen-go-grpc v1.2.0",
+// as generators write them in a "// versions:" block under the header.
+func listedVersion(name string, comments []string) string {
+	if name == "" {
+		return ""
+	}
+	for _, lit := range comments {
+		text, ok := strings.CutPrefix(lit, "//")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) > 0 && fields[0] == "-" {
+			fields = fields[1:]
+		}
+		if len(fields) >= 2 && path.Base(fields[0]) == name && versionPattern.MatchString(fields[1]) {
+			return fields[1]
+		}
+	}
+	return ""
+}
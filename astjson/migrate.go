@@ -0,0 +1,115 @@
+package astjson
+
+import (
+	"fmt"
+	"go/ast"
+	"reflect"
+	"sort"
+)
+
+// FormatVersion is the version of the document format produced by this package.
+// Version 1 documents were not marked; since version 2, the root node of every
+// file document records the version it was written in. Since version 3, the
+// children of a node follow the order of the fields of its go/ast type.
+const FormatVersion = 3
+
+// migrations upgrade a document from the version they are keyed by to the next one.
+var migrations = map[int]func(doc *ASTNode) error{
+	// Version 2 only adds the version marker: version 1 documents have the
+	// same nodes, fields and child order, so there is no shape to convert.
+	1: func(doc *ASTNode) error {
+		doc.FormatVersion = 2
+		return nil
+	},
+	// Version 3 orders children by field, which version 2 did for most but
+	// not all kinds: the name of a type spec or function declaration came
+	// after its type, for one. Nodes of kinds outside go/ast, such as the
+	// package of a merged document, keep the order of their children.
+	2: func(doc *ASTNode) error {
+		if err := orderChildren(doc, doc.KindTable); err != nil {
+			return err
+		}
+		doc.FormatVersion = 3
+		return nil
+	},
+}
+
+// astNodeTypes holds the go/ast node types by the names documents give them.
+var astNodeTypes = make(map[string]reflect.Type)
+
+func init() {
+	for _, node := range []ast.Node{
+		&ast.ArrayType{}, &ast.AssignStmt{}, &ast.BadDecl{}, &ast.BadExpr{}, &ast.BadStmt{},
+		&ast.BasicLit{}, &ast.BinaryExpr{}, &ast.BlockStmt{}, &ast.BranchStmt{}, &ast.CallExpr{},
+		&ast.CaseClause{}, &ast.ChanType{}, &ast.CommClause{}, &ast.Comment{}, &ast.CommentGroup{},
+		&ast.CompositeLit{}, &ast.DeclStmt{}, &ast.DeferStmt{}, &ast.Ellipsis{}, &ast.EmptyStmt{},
+		&ast.ExprStmt{}, &ast.Field{}, &ast.FieldList{}, &ast.File{}, &ast.ForStmt{},
+		&ast.FuncDecl{}, &ast.FuncLit{}, &ast.FuncType{}, &ast.GenDecl{}, &ast.GoStmt{},
+		&ast.Ident{}, &ast.IfStmt{}, &ast.ImportSpec{}, &ast.IncDecStmt{}, &ast.IndexExpr{},
+		&ast.IndexListExpr{}, &ast.InterfaceType{}, &ast.KeyValueExpr{}, &ast.LabeledStmt{}, &ast.MapType{},
+		&ast.ParenExpr{}, &ast.RangeStmt{}, &ast.ReturnStmt{}, &ast.SelectStmt{}, &ast.SelectorExpr{},
+		&ast.SendStmt{}, &ast.SliceExpr{}, &ast.StarExpr{}, &ast.StructType{}, &ast.SwitchStmt{},
+		&ast.TypeAssertExpr{}, &ast.TypeSpec{}, &ast.TypeSwitchStmt{}, &ast.UnaryExpr{}, &ast.ValueSpec{},
+	} {
+		astNodeTypes[fmt.Sprintf("%T", node)] = reflect.TypeOf(node)
+	}
+}
+
+// orderChildren sorts the children of astNode and its descendants by the
+// field of their parent holding them, as told by their role. The nodes of a
+// field keep their order, which follows the source. kinds is the kind table
+// of the document, for nodes whose kind is interned. A child whose role names
+// no field of its parent cannot be ordered, and is an error.
+func orderChildren(astNode *ASTNode, kinds []string) error {
+	for _, child := range astNode.Children {
+		if err := orderChildren(child, kinds); err != nil {
+			return err
+		}
+	}
+	kind := astNode.Type
+	if kind == "" && astNode.Kind != nil && *astNode.Kind < len(kinds) {
+		kind = kinds[*astNode.Kind]
+	}
+	t, ok := astNodeTypes[kind]
+	if !ok {
+		return nil
+	}
+	fields := make(map[string]int)
+	for i := 0; i < t.Elem().NumField(); i++ {
+		fields[snakeCase(t.Elem().Field(i).Name)] = i
+	}
+	for _, child := range astNode.Children {
+		if _, ok := fields[child.Role]; !ok {
+			return fmt.Errorf("%s has no field for the role %q of a child", kind, child.Role)
+		}
+	}
+	sort.SliceStable(astNode.Children, func(i, j int) bool {
+		return fields[astNode.Children[i].Role] < fields[astNode.Children[j].Role]
+	})
+	return nil
+}
+
+// DocumentVersion returns the format version of a file document.
+func DocumentVersion(doc *ASTNode) int {
+	if doc.FormatVersion == 0 {
+		return 1
+	}
+	return doc.FormatVersion
+}
+
+// Migrate upgrades a file document from format version from to version to.
+// Documents cannot be downgraded.
+func Migrate(doc *ASTNode, from, to int) error {
+	if from < 1 || to > FormatVersion {
+		return fmt.Errorf("unsupported format version range %d to %d, versions 1 to %d are known", from, to, FormatVersion)
+	}
+	if to < from {
+		return fmt.Errorf("cannot downgrade a document from format version %d to %d", from, to)
+	}
+	for version := from; version < to; version++ {
+		if err := migrations[version](doc); err != nil {
+			return fmt.Errorf("error migrating from format version %d to %d: %w", version, version+1, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package astjson
+
+import (
+	"bytes"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+// reorderChildren puts the children of astNode and its descendants in an
+// order other than that of FormatVersion 3, keeping the nodes of a role in
+// source order like version 2 did.
+func reorderChildren(astNode *ASTNode) {
+	for _, child := range astNode.Children {
+		reorderChildren(child)
+	}
+	sort.SliceStable(astNode.Children, func(i, j int) bool {
+		return astNode.Children[i].Role > astNode.Children[j].Role
+	})
+}
+
+func TestMigrateOrdersChildren(t *testing.T) {
+	for _, path := range corpusFiles(t) {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			astNode, err := MarshalFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := encodeNode(t, astNode)
+
+			doc := decodeNode(t, want)
+			reorderChildren(doc)
+			doc.FormatVersion = 0
+			if version := DocumentVersion(doc); version != 1 {
+				t.Fatalf("unmarked document has version %d, want 1", version)
+			}
+			if err := Migrate(doc, 1, FormatVersion); err != nil {
+				t.Fatal(err)
+			}
+			if got := encodeNode(t, doc); !bytes.Equal(got, want) {
+				t.Errorf("migrated document differs:\n%s\n%s", want, got)
+			}
+		})
+	}
+}
+
+func TestMigrateRejectsUnknownRoles(t *testing.T) {
+	for _, role := range []string{"", "body"} {
+		doc := &ASTNode{FormatVersion: 2, Type: "*ast.File", Children: []*ASTNode{
+			{Type: "*ast.Ident", Role: "name", Value: "p"},
+			{Type: "*ast.Ident", Role: role, Value: "x"},
+		}}
+		if err := Migrate(doc, 2, FormatVersion); err == nil {
+			t.Errorf("migrating a child with the role %q succeeded", role)
+		}
+		if doc.FormatVersion != 2 {
+			t.Errorf("document with the role %q was marked version %d", role, doc.FormatVersion)
+		}
+	}
+}
+
+func TestMigrateRejectsUnknownVersions(t *testing.T) {
+	doc := &ASTNode{Type: "*ast.File"}
+	for _, versions := range [][2]int{{0, FormatVersion}, {1, FormatVersion + 1}, {FormatVersion, 1}} {
+		if err := Migrate(doc, versions[0], versions[1]); err == nil {
+			t.Errorf("migrating from version %d to %d succeeded", versions[0], versions[1])
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package astjson
+
+import "go/parser"
+
+// Options controls how source is parsed, converted and encoded. The zero value
+// converts the whole tree without comments into compact JSON.
+type Options struct {
+	// Filename names the source in error messages and decides whether it is a
+	// test file. It is only used by Convert, which never opens the file.
+	Filename string
+
+	// Indent indents nested JSON elements; if empty, a document is written as
+	// a single compact line.
+	Indent string
+
+	// Comments keeps comments when parsing, which also fills the doc field of
+	// declarations, specs and fields with the text of their doc comment. When
+	// converting a file whose file set is known, every comment is listed in the
+	// comments field of the node ast.NewCommentMap associates it with, such as
+	// the declaration a doc comment documents or the statement a trailing
+	// comment follows; otherwise comment groups are converted where the tree
+	// refers to them.
+	Comments bool
+
+	// SkipBodies leaves out the bodies of functions and function literals,
+	// keeping their signatures and call sites.
+	SkipBodies bool
+
+	// MaxDepth, if positive, replaces the nodes nested more than MaxDepth
+	// levels below the converted node by marker nodes that keep their kind,
+	// role and positions, are marked truncated and have no children.
+	MaxDepth int
+
+	// DropParens replaces ParenExprs by their operand, counting them in Parens.
+	DropParens bool
+
+	// Positions records the source range of every node in its pos and end
+	// fields, and the byte offsets of the range in span. It needs the file set
+	// the source was parsed with, which Convert and MarshalFile provide and
+	// other callers set in Marshaler.Fset.
+	Positions bool
+
+	// DeclSource records the gofmt-formatted source of every declaration in
+	// its code field, without comments. Like Positions, it needs the file set
+	// the source was parsed with.
+	DeclSource bool
+
+	// FoldStrings records the value of concatenations of string literals, such
+	// as "a" + "b", in the folded field of the outermost BinaryExpr. The
+	// operands are converted as usual.
+	FoldStrings bool
+}
+
+// ParserMode returns the go/parser mode to parse sources with under o.
+func (o Options) ParserMode() parser.Mode {
+	mode := parser.AllErrors
+	if o.Comments {
+		mode |= parser.ParseComments
+	}
+	return mode
+}
@@ -0,0 +1,25 @@
+package astjson
+
+// PackageTables are the tables shared by the files of a merged package document:
+// the imports of all files and the package-level declarations.
+type PackageTables struct {
+	Imports []*PackageImport `json:"imports"`
+	Symbols []*PackageSymbol `json:"symbols"`
+}
+
+// PackageImport is an import path together with the files importing it.
+type PackageImport struct {
+	Path  string   `json:"path"`
+	Files []string `json:"files"`
+}
+
+// PackageSymbol is a package-level declaration: a func, method, type, var or
+// const. Methods are named Type.Method. Start and End are the byte offsets of
+// the declaration in its file, or of its spec for types, vars and consts.
+type PackageSymbol struct {
+	Name  string `json:"name"`
+	Kind  string `json:"kind"`
+	File  string `json:"file"`
+	Start int    `json:"start,omitempty"`
+	End   int    `json:"end,omitempty"`
+}
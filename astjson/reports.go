@@ -0,0 +1,270 @@
+package astjson
+
+// FileReports holds the optional per-file analyses attached to the root node of a file.
+type FileReports struct {
+	Unresolved []string         `json:"unresolved,omitempty"`
+	Init       *InitReport      `json:"init,omitempty"`
+	Instances  []*Instance      `json:"instances,omitempty"`
+	Errors     []*ErrorHandling `json:"errors,omitempty"`
+	Context    *ContextReport   `json:"context,omitempty"`
+	Structs    []*StructLayout  `json:"structs,omitempty"`
+	Enums      []*Enum          `json:"enums,omitempty"`
+	Interfaces []*Interface     `json:"interfaces,omitempty"`
+	Directives []*Directive     `json:"directives,omitempty"`
+	Shadows    []*Shadow        `json:"shadows,omitempty"`
+	Switches   []*Switch        `json:"switches,omitempty"`
+	Externals  []*ExternalTypes `json:"external_types,omitempty"`
+	MethodSets []*MethodSet     `json:"method_sets,omitempty"`
+	Numbers    []*FuncNumbers   `json:"numbers,omitempty"`
+}
+
+// InitReport lists the package initialization work declared in a file.
+type InitReport struct {
+	Funcs []*InitFunc `json:"funcs"`
+	Vars  []*InitVar  `json:"vars"`
+}
+
+// InitFunc is an init function declaration. Start and End are the byte offsets
+// of the declaration.
+type InitFunc struct {
+	Line  int `json:"line,omitempty"`
+	Start int `json:"start,omitempty"`
+	End   int `json:"end,omitempty"`
+}
+
+// InitVar is a package-level variable initializer. Order is the 1-based position of
+// the initializer in the package initialization order, known only with type information.
+// Start and End are the byte offsets of the initializer expression.
+type InitVar struct {
+	Names []string `json:"names"`
+	Value string   `json:"value"`
+	Line  int      `json:"line,omitempty"`
+	Start int      `json:"start,omitempty"`
+	End   int      `json:"end,omitempty"`
+	Order int      `json:"order,omitempty"`
+}
+
+// Instance is an instantiation of a generic function or type.
+type Instance struct {
+	Name     string   `json:"name"`
+	TypeArgs []string `json:"type_args"`
+	Type     string   `json:"type"`
+	Line     int      `json:"line,omitempty"`
+	Column   int      `json:"column,omitempty"`
+}
+
+// ErrorHandling summarizes the error handling of a function, including the
+// function literals it contains. Checks counts the if err != nil statements,
+// Wrapped those of them that wrap the error with fmt.Errorf and %w, and Ignored
+// the error results assigned to the blank identifier. Start and End are the
+// byte offsets of the function declaration.
+type ErrorHandling struct {
+	Func    string `json:"func"`
+	Line    int    `json:"line,omitempty"`
+	Start   int    `json:"start,omitempty"`
+	End     int    `json:"end,omitempty"`
+	Checks  int    `json:"checks"`
+	Wrapped int    `json:"wrapped"`
+	Ignored int    `json:"ignored"`
+}
+
+// ContextReport lists the functions of a file that accept a context.Context and
+// the places that create a root context with context.Background or context.TODO.
+type ContextReport struct {
+	Funcs []*ContextFunc `json:"funcs"`
+	Roots []*ContextRoot `json:"roots"`
+}
+
+// ContextFunc is a function taking a context.Context parameter. Passed counts the
+// calls in the function, including its function literals, that receive the
+// parameter as an argument; a function that never passes it on has 0. Start and
+// End are the byte offsets of the function declaration.
+type ContextFunc struct {
+	Func   string `json:"func"`
+	Param  string `json:"param"`
+	Line   int    `json:"line,omitempty"`
+	Start  int    `json:"start,omitempty"`
+	End    int    `json:"end,omitempty"`
+	Passed int    `json:"passed"`
+}
+
+// ContextRoot is a call of context.Background or context.TODO.
+type ContextRoot struct {
+	Func   string `json:"func,omitempty"`
+	Call   string `json:"call"`
+	Line   int    `json:"line,omitempty"`
+	Column int    `json:"column,omitempty"`
+}
+
+// StructLayout is the field inventory of a struct type declaration. Size and
+// Align, like the offsets of the fields, are only known with type information
+// for non-generic types, and computed for the gc compiler on the host architecture.
+// Start and End are the byte offsets of the type spec in its file.
+type StructLayout struct {
+	Name   string         `json:"name"`
+	Line   int            `json:"line,omitempty"`
+	Start  int            `json:"start,omitempty"`
+	End    int            `json:"end,omitempty"`
+	Size   *int64         `json:"size,omitempty"`
+	Align  *int64         `json:"align,omitempty"`
+	Fields []*StructField `json:"fields"`
+}
+
+// StructField is a field of a struct type, in declaration order. Names declared
+// together (A, B int) are listed separately; embedded fields are named after
+// their type.
+type StructField struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Tag      string `json:"tag,omitempty"`
+	Embedded bool   `json:"embedded,omitempty"`
+	Exported bool   `json:"exported"`
+	Offset   *int64 `json:"offset,omitempty"`
+	Size     *int64 `json:"size,omitempty"`
+	Align    *int64 `json:"align,omitempty"`
+}
+
+// Enum is a named type together with the constants of a const block that
+// declares values of the type with iota, the idiomatic way to define an
+// enumeration in Go. Start and End are the byte offsets of the const block.
+type Enum struct {
+	Type    string        `json:"type"`
+	Line    int           `json:"line,omitempty"`
+	Start   int           `json:"start,omitempty"`
+	End     int           `json:"end,omitempty"`
+	Members []*EnumMember `json:"members"`
+}
+
+// EnumMember is a constant of an Enum. Value is empty if it cannot be
+// computed without type information.
+type EnumMember struct {
+	Name  string `json:"name"`
+	Value string `json:"value,omitempty"`
+}
+
+// Interface is a named interface type with the method signatures a mock or stub
+// of it has to implement. Embedded lists the embedded elements as written.
+// With type information, Methods is the complete method set, including the
+// methods of embedded interfaces; otherwise it only holds the methods declared
+// explicitly. Start and End are the byte offsets of the type spec.
+type Interface struct {
+	Name       string             `json:"name"`
+	Line       int                `json:"line,omitempty"`
+	Start      int                `json:"start,omitempty"`
+	End        int                `json:"end,omitempty"`
+	TypeParams []string           `json:"type_params,omitempty"`
+	Embedded   []string           `json:"embedded,omitempty"`
+	Methods    []*InterfaceMethod `json:"methods"`
+}
+
+// InterfaceMethod is a method of an Interface. From names the embedded
+// interface the method comes from, if it is not declared explicitly.
+type InterfaceMethod struct {
+	Name    string   `json:"name"`
+	Params  []*Param `json:"params"`
+	Results []*Param `json:"results"`
+	From    string   `json:"from,omitempty"`
+}
+
+// Directive is a directive comment such as //go:embed *.txt. Name is the
+// directive up to the first space (go:embed) and Args the rest. Decl names the
+// declaration the directive is attached to, if any, the way -delta keys
+// declarations, such as "func F" or "var x". Start and End are the byte
+// offsets of the comment.
+type Directive struct {
+	Name  string `json:"name"`
+	Args  string `json:"args,omitempty"`
+	Line  int    `json:"line,omitempty"`
+	Start int    `json:"start,omitempty"`
+	End   int    `json:"end,omitempty"`
+	Decl  string `json:"decl,omitempty"`
+}
+
+// Shadow is a declaration that shadows the declaration of the same name in an
+// enclosing scope. ShadowedFile is set if the shadowed declaration is in
+// another file of the package.
+type Shadow struct {
+	Name           string `json:"name"`
+	Line           int    `json:"line,omitempty"`
+	Column         int    `json:"column,omitempty"`
+	ShadowedFile   string `json:"shadowed_file,omitempty"`
+	ShadowedLine   int    `json:"shadowed_line,omitempty"`
+	ShadowedColumn int    `json:"shadowed_column,omitempty"`
+}
+
+// Switch is a switch, type switch or select statement, as Kind tells, with the
+// cases it handles as written and whether it has a default case. Tag is the
+// switch expression or the operand of the type switch. With type information,
+// Type is the type of Tag and, for switches over a named type or type switches
+// over a named interface, Possible lists the constants of the type or the types
+// implementing the interface in its package, and Missing those without a case.
+// Start and End are the byte offsets of the statement.
+type Switch struct {
+	Kind     string   `json:"kind"`
+	Func     string   `json:"func"`
+	Line     int      `json:"line,omitempty"`
+	Start    int      `json:"start,omitempty"`
+	End      int      `json:"end,omitempty"`
+	Tag      string   `json:"tag,omitempty"`
+	Type     string   `json:"type,omitempty"`
+	Cases    []string `json:"cases"`
+	Default  bool     `json:"default,omitempty"`
+	Possible []string `json:"possible,omitempty"`
+	Missing  []string `json:"missing,omitempty"`
+}
+
+// ExternalTypes lists the types of an imported package a file refers to, by
+// the package's import path and the name it is imported as.
+type ExternalTypes struct {
+	Path  string   `json:"path"`
+	Name  string   `json:"name"`
+	Types []string `json:"types"`
+}
+
+// MethodSet is the method set of a named type other than an interface: the
+// methods callable on a pointer to the type, including those promoted from
+// embedded fields. Start and End are the byte offsets of the type spec.
+type MethodSet struct {
+	Type    string       `json:"type"`
+	Line    int          `json:"line,omitempty"`
+	Start   int          `json:"start,omitempty"`
+	End     int          `json:"end,omitempty"`
+	Methods []*SetMethod `json:"methods"`
+}
+
+// SetMethod is a method of a MethodSet. From is the receiver type of the
+// method as declared, and Embedded the path of embedded fields it is promoted
+// through, empty for the methods declared on the type itself. Pointer marks the
+// methods missing from the method set of the type itself, callable only on
+// addressable values or pointers.
+type SetMethod struct {
+	Name     string   `json:"name"`
+	Params   []*Param `json:"params"`
+	Results  []*Param `json:"results"`
+	From     string   `json:"from,omitempty"`
+	Embedded []string `json:"embedded,omitempty"`
+	Pointer  bool     `json:"pointer,omitempty"`
+}
+
+// FuncNumbers lists the numeric literals of a function, including its
+// function literals, in source order, for reviewing magic numbers. Start and
+// End are the byte offsets of the function declaration.
+type FuncNumbers struct {
+	Func    string           `json:"func"`
+	Line    int              `json:"line,omitempty"`
+	Start   int              `json:"start,omitempty"`
+	End     int              `json:"end,omitempty"`
+	Numbers []*NumberLiteral `json:"numbers"`
+}
+
+// NumberLiteral is a numeric literal as written, with a leading minus if it is
+// negated. Context tells how it is used: time for durations, comparison,
+// array_size, size for the lengths and capacities given to make, index, case,
+// argument, assignment, return, composite for elements of composite literals,
+// arithmetic, or other.
+type NumberLiteral struct {
+	Value   string `json:"value"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Context string `json:"context"`
+}
@@ -0,0 +1,98 @@
+package astjson
+
+import (
+	"go/ast"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// fieldRole returns the structural role of child in parent: the name of the
+// field of parent holding it, in snake_case, such as cond for the condition of
+// an if statement or type_params for the type parameters of a type spec.
+// It returns "" if parent is nil or no field of parent holds child.
+func fieldRole(parent, child ast.Node) string {
+	if parent == nil {
+		return ""
+	}
+	v := reflect.ValueOf(parent)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return ""
+	}
+	v = v.Elem()
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		value := v.Field(i)
+		switch value.Kind() {
+		case reflect.Interface, reflect.Pointer:
+			if !value.IsNil() && value.Interface() == child {
+				return snakeCase(field.Name)
+			}
+		case reflect.Slice:
+			for j := 0; j < value.Len(); j++ {
+				if elem := value.Index(j); elem.Kind() != reflect.Struct && !elem.IsNil() && elem.Interface() == child {
+					return snakeCase(field.Name)
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// snakeCase converts a Go field name such as TypeParams into type_params.
+func snakeCase(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// skippedFields lists, by node type, the node-valued fields left out of the
+// tree because the nodes they hold are found elsewhere in it.
+var skippedFields = map[reflect.Type]map[string]bool{
+	reflect.TypeOf(&ast.File{}): {"Imports": true, "Unresolved": true, "Comments": true},
+}
+
+// childNodes returns the nodes held by the fields of node, in field order,
+// except for its skippedFields. Since the fields of nodes follow the source,
+// so do the nodes returned.
+func childNodes(node ast.Node) []ast.Node {
+	v := reflect.ValueOf(node)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	var children []ast.Node
+	add := func(value reflect.Value) {
+		if (value.Kind() == reflect.Interface || value.Kind() == reflect.Pointer) && !value.IsNil() {
+			if child, ok := value.Interface().(ast.Node); ok {
+				children = append(children, child)
+			}
+		}
+	}
+	skipped := skippedFields[v.Type()]
+	v = v.Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if field := v.Type().Field(i); !field.IsExported() || skipped[field.Name] {
+			continue
+		}
+		value := v.Field(i)
+		if value.Kind() == reflect.Slice {
+			for j := 0; j < value.Len(); j++ {
+				add(value.Index(j))
+			}
+			continue
+		}
+		add(value)
+	}
+	return children
+}
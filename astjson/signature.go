@@ -0,0 +1,59 @@
+package astjson
+
+import (
+	"go/ast"
+	"go/types"
+	"strings"
+)
+
+// signature returns the canonical signature of a function or method, such as
+// func (*T).Write(p []byte) (n int, err error). With type information it is
+// types.ObjectString of the function, with types of other packages qualified by
+// their import path; otherwise it is FuncSignature.
+func (m *Marshaler) signature(decl *ast.FuncDecl) string {
+	if m.Info != nil {
+		if obj, ok := m.Info.Defs[decl.Name].(*types.Func); ok {
+			return types.ObjectString(obj, types.RelativeTo(obj.Pkg()))
+		}
+	}
+	return FuncSignature(decl)
+}
+
+// FuncSignature returns the signature of a function or method built from its
+// syntax, in the form of types.ObjectString: types as written and parameters
+// declared together (a, b int) listed one by one.
+func FuncSignature(decl *ast.FuncDecl) string {
+	var b strings.Builder
+	b.WriteString("func ")
+	if decl.Recv != nil && len(decl.Recv.List) > 0 {
+		b.WriteString("(" + types.ExprString(decl.Recv.List[0].Type) + ").")
+	}
+	b.WriteString(decl.Name.Name)
+	if decl.Type.TypeParams != nil {
+		b.WriteString("[" + signatureParams(ParamList(decl.Type.TypeParams)) + "]")
+	}
+	b.WriteString("(" + signatureParams(ParamList(decl.Type.Params)) + ")")
+	results := ParamList(decl.Type.Results)
+	if len(results) == 1 && results[0].Name == "" {
+		b.WriteString(" " + results[0].Type)
+	} else if len(results) > 0 {
+		b.WriteString(" (" + signatureParams(results) + ")")
+	}
+	return b.String()
+}
+
+// signatureParams formats a parameter list of a signature.
+func signatureParams(params []*Param) string {
+	var list []string
+	for _, param := range params {
+		typ := param.Type
+		if param.Variadic {
+			typ = "..." + typ
+		}
+		if param.Name != "" {
+			typ = param.Name + " " + typ
+		}
+		list = append(list, typ)
+	}
+	return strings.Join(list, ", ")
+}
@@ -0,0 +1,101 @@
+package astjson
+
+import (
+	"bufio"
+	"go/ast"
+	"io"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// MarshalStream converts node like Marshal, but writes the JSON document of the
+// tree to w as its nodes are converted instead of returning the tree, so that
+// memory use grows with the depth of the tree rather than its size. head, if
+// set, is called with the root node before it is written, to fill in fields
+// such as Source and Meta. Streamed documents are compact and list the children
+// of each node after its other fields.
+func (m *Marshaler) MarshalStream(w io.Writer, node ast.Node, head func(*ASTNode)) error {
+	m.reset(node)
+	_, isFile := node.(*ast.File)
+	m.stream = &nodeStream{w: bufio.NewWriter(w), head: func(astNode *ASTNode) {
+		if isFile {
+			astNode.FormatVersion = FormatVersion
+		}
+		if head != nil {
+			head(astNode)
+		}
+	}}
+	defer func() { m.stream = nil }()
+
+	if m.marshalAST(node) == nil {
+		m.stream.w.WriteString("null")
+	}
+	m.stream.w.WriteByte('\n')
+	if m.stream.err != nil {
+		return m.stream.err
+	}
+	return m.stream.w.Flush()
+}
+
+// nodeStream writes the nodes of a tree as they are converted. A node is
+// written up to its children when it begins; the children follow, and the
+// node is closed when it ends.
+type nodeStream struct {
+	w    *bufio.Writer
+	head func(*ASTNode)
+	err  error
+
+	// open holds the nodes begun but not ended, innermost last.
+	open []streamedNode
+}
+
+// streamedNode is the state of a node being written.
+type streamedNode struct {
+	fields   bool // the node has fields before its children
+	children bool // the children array has been started
+}
+
+// begin writes astNode, a child of the innermost open node if any, up to its
+// children. The methods of a nil stream do nothing, for trees being built.
+func (s *nodeStream) begin(astNode *ASTNode) {
+	if s == nil {
+		return
+	}
+	if n := len(s.open); n > 0 {
+		parent := &s.open[n-1]
+		switch {
+		case parent.children:
+			s.w.WriteByte(',')
+		case parent.fields:
+			s.w.WriteString(`,"children":[`)
+		default:
+			s.w.WriteString(`"children":[`)
+		}
+		parent.children = true
+	} else if s.head != nil {
+		s.head(astNode)
+	}
+
+	var json = jsoniter.ConfigCompatibleWithStandardLibrary
+	data, err := json.Marshal(astNode)
+	if err != nil {
+		if s.err == nil {
+			s.err = err
+		}
+		data = []byte("{}")
+	}
+	s.w.Write(data[:len(data)-1])
+	s.open = append(s.open, streamedNode{fields: len(data) > 2})
+}
+
+// end closes the innermost open node.
+func (s *nodeStream) end() {
+	if s == nil {
+		return
+	}
+	if s.open[len(s.open)-1].children {
+		s.w.WriteByte(']')
+	}
+	s.w.WriteByte('}')
+	s.open = s.open[:len(s.open)-1]
+}
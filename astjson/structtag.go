@@ -0,0 +1,72 @@
+package astjson
+
+import (
+	"strconv"
+	"strings"
+)
+
+// StructTag is the tag of a struct field. Raw is the tag string with its quotes
+// removed. Keys holds its key:"value" pairs in order, parsed the way
+// reflect.StructTag.Get reads them, and is left out if the tag does not follow
+// that convention.
+type StructTag struct {
+	Raw  string    `json:"raw"`
+	Keys []*TagKey `json:"keys,omitempty"`
+}
+
+// TagKey is a key:"value" pair of a struct tag, with the value unquoted.
+type TagKey struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// NewStructTag returns the StructTag of the tag literal lit as written in the
+// source, including its quotes, or nil if lit is not a string literal.
+func NewStructTag(lit string) *StructTag {
+	raw, err := strconv.Unquote(lit)
+	if err != nil {
+		return nil
+	}
+	return &StructTag{Raw: raw, Keys: parseStructTag(raw)}
+}
+
+// parseStructTag splits a conventional struct tag into its key:"value" pairs.
+// It returns nil if the tag is malformed.
+func parseStructTag(tag string) []*TagKey {
+	var keys []*TagKey
+	for {
+		tag = strings.TrimLeft(tag, " ")
+		if tag == "" {
+			return keys
+		}
+		// The key runs up to the colon and is made of non-control characters
+		// other than space, quote and colon.
+		i := 0
+		for i < len(tag) && tag[i] > ' ' && tag[i] != ':' && tag[i] != '"' && tag[i] != 0x7f {
+			i++
+		}
+		if i == 0 || i+1 >= len(tag) || tag[i] != ':' || tag[i+1] != '"' {
+			return nil
+		}
+		key := tag[:i]
+		tag = tag[i+1:]
+
+		// The value is a quoted string, ending at the first unescaped quote.
+		i = 1
+		for i < len(tag) && tag[i] != '"' {
+			if tag[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(tag) {
+			return nil
+		}
+		value, err := strconv.Unquote(tag[:i+1])
+		if err != nil {
+			return nil
+		}
+		keys = append(keys, &TagKey{Key: key, Value: value})
+		tag = tag[i+1:]
+	}
+}
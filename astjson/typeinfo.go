@@ -0,0 +1,129 @@
+package astjson
+
+import (
+	"go/ast"
+	"go/types"
+)
+
+// annotateTypes records the type information of node on astNode: the type of
+// an expression in GoType and, for identifiers, the kind of object they denote
+// or declare in ObjKind. Expressions the type checker has no type for, such as
+// those in erroneous code, are left unannotated.
+func (m *Marshaler) annotateTypes(node ast.Node, astNode *ASTNode) {
+	expr, ok := node.(ast.Expr)
+	if !ok {
+		return
+	}
+	if tv, ok := m.Info.Types[expr]; ok && tv.Type != nil {
+		astNode.GoType = tv.Type.String()
+	}
+	ident, ok := expr.(*ast.Ident)
+	if !ok {
+		return
+	}
+	obj := m.Info.ObjectOf(ident)
+	if obj == nil {
+		return
+	}
+	astNode.ObjKind = objectKind(obj)
+	// Declaring identifiers are not recorded in Types.
+	if astNode.GoType == "" && obj.Type() != nil {
+		if _, ok := obj.(*types.PkgName); !ok {
+			astNode.GoType = obj.Type().String()
+		}
+	}
+}
+
+// objectKind returns the kind of a types.Object: var, const, func, type,
+// package, label, builtin or nil.
+func objectKind(obj types.Object) string {
+	switch obj.(type) {
+	case *types.Var:
+		return "var"
+	case *types.Const:
+		return "const"
+	case *types.Func:
+		return "func"
+	case *types.TypeName:
+		return "type"
+	case *types.PkgName:
+		return "package"
+	case *types.Label:
+		return "label"
+	case *types.Builtin:
+		return "builtin"
+	case *types.Nil:
+		return "nil"
+	}
+	return ""
+}
+
+// recordUnusedParams remembers the named parameters and results of a function
+// that its body never refers to. Named results count as used by a bare return.
+func (m *Marshaler) recordUnusedParams(funcType *ast.FuncType, body *ast.BlockStmt) {
+	if body == nil {
+		return
+	}
+	used := make(map[types.Object]bool)
+	bareReturn := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Ident:
+			if obj := m.Info.Uses[n]; obj != nil {
+				used[obj] = true
+			}
+		case *ast.ReturnStmt:
+			if len(n.Results) == 0 {
+				bareReturn = true
+			}
+		case *ast.FuncLit:
+			// Function literals are visited for their uses of the enclosing
+			// parameters; their own bare returns refer to their own results.
+			ast.Inspect(n.Body, func(n ast.Node) bool {
+				if ident, ok := n.(*ast.Ident); ok {
+					if obj := m.Info.Uses[ident]; obj != nil {
+						used[obj] = true
+					}
+				}
+				return true
+			})
+			return false
+		}
+		return true
+	})
+	record := func(fields *ast.FieldList) {
+		if fields == nil {
+			return
+		}
+		for _, field := range fields.List {
+			for _, name := range field.Names {
+				if obj := m.Info.Defs[name]; obj != nil && name.Name != "_" && !used[obj] {
+					m.unusedParams[name] = true
+				}
+			}
+		}
+	}
+	record(funcType.Params)
+	if !bareReturn {
+		record(funcType.Results)
+	}
+}
+
+// markUnusedParams sets Unused on the entries of params, as made by ParamList
+// from fields, whose parameter recordUnusedParams found unused.
+func (m *Marshaler) markUnusedParams(params []*Param, fields *ast.FieldList) {
+	if fields == nil {
+		return
+	}
+	i := 0
+	for _, field := range fields.List {
+		if len(field.Names) == 0 {
+			i++
+			continue
+		}
+		for _, name := range field.Names {
+			params[i].Unused = m.unusedParams[name]
+			i++
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"runtime/metrics"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/kobi2187/go2json/astjson"
+)
+
+func init() {
+	subcommands["bench"] = runBench
+}
+
+const benchUsage = `usage:
+  go2json bench [-n N] [-backends LIST] [-indent STRING] PATH`
+
+// jsonEncoder is the part of an encoder backend used by the bench command.
+type jsonEncoder interface {
+	Encode(v interface{}) error
+}
+
+// benchBackends are the JSON encoders the bench command can compare.
+var benchBackends = map[string]func(w io.Writer, indent string) jsonEncoder{
+	"jsoniter": func(w io.Writer, indent string) jsonEncoder {
+		encoder := jsoniter.ConfigCompatibleWithStandardLibrary.NewEncoder(w)
+		encoder.SetIndent("", indent)
+		return encoder
+	},
+	"jsoniter-fastest": func(w io.Writer, indent string) jsonEncoder {
+		encoder := jsoniter.ConfigFastest.NewEncoder(w)
+		encoder.SetIndent("", indent)
+		return encoder
+	},
+	"encoding/json": func(w io.Writer, indent string) jsonEncoder {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", indent)
+		return encoder
+	},
+}
+
+// benchResult is the measurement of one encoder backend.
+type benchResult struct {
+	backend  string
+	files    int
+	nodes    int
+	bytes    int64
+	elapsed  time.Duration
+	allocs   uint64
+	peakHeap uint64
+}
+
+// runBench repeatedly converts the Go files at a path with each encoder backend
+// and reports throughput, allocations and peak heap usage. Output is discarded.
+func runBench(args []string) error {
+	flags := flag.NewFlagSet("bench", flag.ContinueOnError)
+	iterations := flags.Int("n", 5, "number of times every file is converted per backend")
+	backends := flags.String("backends", "jsoniter,jsoniter-fastest,encoding/json", "comma-separated encoder backends to compare")
+	indent := flags.String("indent", "  ", "indentation passed to the encoders; empty for compact output")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 || *iterations < 1 {
+		fmt.Fprintln(os.Stderr, benchUsage)
+		return errors.New("expected a single path and a positive -n")
+	}
+
+	paths, err := benchInputs(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("no Go files found in %s", flags.Arg(0))
+	}
+
+	var results []*benchResult
+	for _, backend := range strings.Split(*backends, ",") {
+		newEncoder, ok := benchBackends[backend]
+		if !ok {
+			return fmt.Errorf("unknown encoder backend %q", backend)
+		}
+		result, err := benchBackend(backend, newEncoder, *indent, paths, *iterations)
+		if err != nil {
+			return err
+		}
+		results = append(results, result)
+	}
+	return writeBenchResults(os.Stdout, results)
+}
+
+// benchInputs lists the Go files to convert: path itself or the files of a folder.
+func benchInputs(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("error accessing path %s: %w", path, err)
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	var paths []string
+	err = walkGoFiles(path, func(path string) error {
+		paths = append(paths, path)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing folder %s: %w", path, err)
+	}
+	return paths, nil
+}
+
+// benchBackend converts and encodes every file iterations times with one backend.
+func benchBackend(backend string, newEncoder func(io.Writer, string) jsonEncoder,
+	indent string, paths []string, iterations int) (*benchResult, error) {
+	result := &benchResult{backend: backend}
+	counter := &countingWriter{}
+	encoder := newEncoder(counter, indent)
+
+	runtime.GC()
+	var before runtime.MemStats
+	runtime.ReadMemStats(&before)
+	sampler := startHeapSampler()
+	start := time.Now()
+	for i := 0; i < iterations; i++ {
+		for _, path := range paths {
+			astNode, err := convertFile(path)
+			if err != nil {
+				sampler.stop()
+				return nil, err
+			}
+			if err := encoder.Encode(astNode); err != nil {
+				sampler.stop()
+				return nil, fmt.Errorf("error encoding %s with %s: %w", path, backend, err)
+			}
+			result.files++
+			result.nodes += countNodes(astNode)
+		}
+	}
+	result.elapsed = time.Since(start)
+	result.peakHeap = sampler.stop()
+	var after runtime.MemStats
+	runtime.ReadMemStats(&after)
+	result.allocs = after.Mallocs - before.Mallocs
+	result.bytes = counter.n
+	return result, nil
+}
+
+// countNodes returns the number of nodes in an ASTNode tree.
+func countNodes(astNode *astjson.ASTNode) int {
+	n := 1
+	for _, child := range astNode.Children {
+		n += countNodes(child)
+	}
+	return n
+}
+
+// writeBenchResults prints one row per backend.
+func writeBenchResults(w io.Writer, results []*benchResult) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "backend\tfiles/s\tnodes/s\tMB/s\tallocs/file\tpeak heap MB\t")
+	for _, r := range results {
+		seconds := r.elapsed.Seconds()
+		fmt.Fprintf(tw, "%s\t%.1f\t%.0f\t%.2f\t%d\t%.1f\t\n",
+			r.backend,
+			float64(r.files)/seconds,
+			float64(r.nodes)/seconds,
+			float64(r.bytes)/seconds/1e6,
+			r.allocs/uint64(r.files),
+			float64(r.peakHeap)/1e6)
+	}
+	return tw.Flush()
+}
+
+// countingWriter discards its input, counting the bytes written.
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
+// heapSampler polls the live heap size in the background to find its peak.
+type heapSampler struct {
+	done chan struct{}
+	wg   sync.WaitGroup
+	peak uint64
+}
+
+const heapMetric = "/memory/classes/heap/objects:bytes"
+
+func startHeapSampler() *heapSampler {
+	s := &heapSampler{done: make(chan struct{})}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		sample := []metrics.Sample{{Name: heapMetric}}
+		ticker := time.NewTicker(time.Millisecond)
+		defer ticker.Stop()
+		for {
+			metrics.Read(sample)
+			s.peak = max(s.peak, sample[0].Value.Uint64())
+			select {
+			case <-s.done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return s
+}
+
+// stop ends sampling and returns the peak heap size observed.
+func (s *heapSampler) stop() uint64 {
+	close(s.done)
+	s.wg.Wait()
+	return s.peak
+}
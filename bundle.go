@@ -0,0 +1,339 @@
+package main
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/kobi2187/go2json/astjson"
+)
+
+// bundleFormat is the version of the bundle layout, recorded in its manifest.
+const bundleFormat = 1
+
+// Names of the fixed entries of a bundle.
+const (
+	bundleManifestEntry = "manifest.json"
+	bundleIndexEntry    = "index.json"
+	bundleGoModEntry    = "go.mod"
+	bundleFilesDir      = "files/"
+)
+
+var bundleCompress = flag.Bool("bundle-compress", true, "compress the entries of bundle outputs")
+
+// BundleManifest describes the documents held by a bundle.
+type BundleManifest struct {
+	Format int           `json:"format"`
+	Module string        `json:"module,omitempty"`
+	Files  []*BundleFile `json:"files"`
+}
+
+// BundleFile is a converted source file in a bundle. Size and SHA256 describe the
+// source file, so a bundle can be checked against a source tree; for the chunks
+// of a file converted in chunks, they describe the whole file.
+type BundleFile struct {
+	Path       string `json:"path"`
+	Document   string `json:"document"`
+	Package    string `json:"package"`
+	ImportPath string `json:"import_path,omitempty"`
+	Size       int64  `json:"size"`
+	SHA256     string `json:"sha256"`
+}
+
+// BundleIndex groups the files of a bundle by package.
+type BundleIndex struct {
+	Packages []*BundlePackage `json:"packages"`
+}
+
+// BundlePackage lists the files of one package in a bundle.
+type BundlePackage struct {
+	Dir        string   `json:"dir"`
+	Name       string   `json:"name"`
+	ImportPath string   `json:"import_path,omitempty"`
+	Files      []string `json:"files"`
+}
+
+// bundleTarget writes all documents into a single zip container, followed by a
+// manifest, a package index and the go.mod of the converted module.
+type bundleTarget struct {
+	path   string
+	file   *os.File
+	zip    *zip.Writer
+	module *astjson.Module
+	files  []*BundleFile
+}
+
+func (target *bundleTarget) spec() string {
+	return formatBundle + ":" + target.path
+}
+
+func (target *bundleTarget) write(sourceFilePath, rel string, astNode *astjson.ASTNode) error {
+	if target.zip == nil {
+		if err := os.MkdirAll(filepath.Dir(target.path), 0o755); err != nil {
+			return err
+		}
+		file, err := os.Create(target.path)
+		if err != nil {
+			return fmt.Errorf("error creating bundle %s: %w", target.path, err)
+		}
+		target.file = file
+		target.zip = zip.NewWriter(file)
+		if dir, err := filepath.Abs(filepath.Dir(sourceFilePath)); err == nil {
+			target.module = astjson.FindModule(dir)
+		}
+	}
+
+	rel = filepath.ToSlash(rel)
+	entry := &BundleFile{Path: rel, Document: bundleFilesDir + rel + ".json"}
+	// Documents name the size and hash of their source, which is not
+	// necessarily at sourceFilePath, as for chunks. Sources read from stdin
+	// or downloaded are held in memory.
+	if header := astNode.Source; header != nil {
+		entry.Size, entry.SHA256 = int64(header.Size), header.SHA256
+	} else {
+		source, err := readSource(sourceFilePath)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(source)
+		entry.Size, entry.SHA256 = int64(len(source)), hex.EncodeToString(sum[:])
+	}
+	if name, ok := astNode.Value.(string); ok {
+		entry.Package = name
+	}
+	if astNode.Meta != nil {
+		entry.ImportPath = astNode.Meta.ImportPath
+	}
+
+	if err := target.writeEntry(entry.Document, astNode, ""); err != nil {
+		return err
+	}
+	target.files = append(target.files, entry)
+	slog.Info("AST generated", "file", sourceFilePath, "output", target.path+"#"+entry.Document)
+	return nil
+}
+
+// writeEntry adds a JSON entry to the bundle.
+func (target *bundleTarget) writeEntry(name string, v interface{}, indent string) error {
+	w, err := target.createEntry(name)
+	if err != nil {
+		return err
+	}
+	return encodeAST(w, v, indent)
+}
+
+// createEntry starts a new entry, compressed unless -bundle-compress=false.
+func (target *bundleTarget) createEntry(name string) (io.Writer, error) {
+	method := zip.Store
+	if *bundleCompress {
+		method = zip.Deflate
+	}
+	return target.zip.CreateHeader(&zip.FileHeader{Name: name, Method: method})
+}
+
+// close writes the manifest, the package index and go.mod, and finishes the container.
+func (target *bundleTarget) close() error {
+	if target.zip == nil {
+		return nil
+	}
+	defer target.file.Close()
+
+	manifest := &BundleManifest{Format: bundleFormat, Files: target.files}
+	if target.module != nil {
+		manifest.Module = target.module.Path
+	}
+	if err := target.writeEntry(bundleManifestEntry, manifest, "  "); err != nil {
+		return err
+	}
+	if err := target.writeEntry(bundleIndexEntry, bundleIndex(target.files), "  "); err != nil {
+		return err
+	}
+
+	if target.module != nil {
+		gomod, err := os.ReadFile(filepath.Join(target.module.Dir, "go.mod"))
+		if err != nil {
+			return err
+		}
+		w, err := target.createEntry(bundleGoModEntry)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(gomod); err != nil {
+			return err
+		}
+	}
+
+	if err := target.zip.Close(); err != nil {
+		return fmt.Errorf("error finishing bundle %s: %w", target.path, err)
+	}
+	target.zip = nil
+	return target.file.Close()
+}
+
+// bundleIndex groups bundle files by folder and package name.
+func bundleIndex(files []*BundleFile) *BundleIndex {
+	index := &BundleIndex{Packages: []*BundlePackage{}}
+	byKey := make(map[string]*BundlePackage)
+	for _, file := range files {
+		dir := path.Dir(file.Path)
+		key := dir + "\x00" + file.Package
+		pkg := byKey[key]
+		if pkg == nil {
+			pkg = &BundlePackage{Dir: dir, Name: file.Package, ImportPath: file.ImportPath}
+			byKey[key] = pkg
+			index.Packages = append(index.Packages, pkg)
+		}
+		pkg.Files = append(pkg.Files, file.Path)
+	}
+	sort.Slice(index.Packages, func(i, j int) bool {
+		if index.Packages[i].Dir != index.Packages[j].Dir {
+			return index.Packages[i].Dir < index.Packages[j].Dir
+		}
+		return index.Packages[i].Name < index.Packages[j].Name
+	})
+	return index
+}
+
+func init() {
+	subcommands["bundle"] = runBundle
+}
+
+const bundleUsage = `usage:
+  go2json bundle ls FILE
+  go2json bundle extract [-package PKG] [-format FORMAT] -o DIR FILE`
+
+// runBundle lists or extracts the contents of a bundle without access to the source tree.
+func runBundle(args []string) error {
+	if len(args) == 0 {
+		return bundleUsageError("missing bundle command")
+	}
+	switch args[0] {
+	case "ls":
+		if len(args) != 2 {
+			return bundleUsageError("expected a single bundle file")
+		}
+		return listBundle(os.Stdout, args[1])
+	case "extract":
+		flags := flag.NewFlagSet("bundle extract", flag.ContinueOnError)
+		pkg := flags.String("package", "", "only extract the package with this import path or folder")
+		format := flags.String("format", formatPretty, "output format: pretty, compact, ndjson or cas")
+		outDir := flags.String("o", "", "output folder")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		if flags.NArg() != 1 || *outDir == "" {
+			return bundleUsageError("expected -o DIR and a single bundle file")
+		}
+		if *format == formatBundle {
+			return fmt.Errorf("cannot extract into another bundle")
+		}
+		target, err := newOutputTarget(*format, *outDir)
+		if err != nil {
+			return err
+		}
+		return extractBundle(flags.Arg(0), *pkg, target)
+	default:
+		return bundleUsageError(fmt.Sprintf("unknown bundle command %q", args[0]))
+	}
+}
+
+// bundleUsageError prints the usage of the bundle command and returns msg as an error.
+func bundleUsageError(msg string) error {
+	fmt.Fprintln(os.Stderr, bundleUsage)
+	return errors.New(msg)
+}
+
+// openBundle opens a bundle and reads its manifest.
+func openBundle(bundlePath string) (*zip.ReadCloser, *BundleManifest, error) {
+	reader, err := zip.OpenReader(bundlePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error opening bundle %s: %w", bundlePath, err)
+	}
+	manifest := &BundleManifest{}
+	if err := readBundleEntry(&reader.Reader, bundleManifestEntry, manifest); err != nil {
+		reader.Close()
+		return nil, nil, err
+	}
+	if manifest.Format > bundleFormat {
+		reader.Close()
+		return nil, nil, fmt.Errorf("bundle %s has format %d, newer than the supported format %d", bundlePath, manifest.Format, bundleFormat)
+	}
+	return reader, manifest, nil
+}
+
+// readBundleEntry decodes the JSON entry with the given name into v.
+func readBundleEntry(reader *zip.Reader, name string, v interface{}) error {
+	entry, err := reader.Open(name)
+	if err != nil {
+		return fmt.Errorf("error reading bundle entry %s: %w", name, err)
+	}
+	defer entry.Close()
+	var json = jsoniter.ConfigCompatibleWithStandardLibrary
+	if err := json.NewDecoder(entry).Decode(v); err != nil {
+		return fmt.Errorf("error parsing bundle entry %s: %w", name, err)
+	}
+	return nil
+}
+
+// listBundle prints the packages of a bundle.
+func listBundle(w io.Writer, bundlePath string) error {
+	reader, manifest, err := openBundle(bundlePath)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	if manifest.Module != "" {
+		fmt.Fprintf(w, "module %s\n", manifest.Module)
+	}
+	for _, pkg := range bundleIndex(manifest.Files).Packages {
+		name := pkg.ImportPath
+		if name == "" {
+			name = pkg.Dir
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d files\n", name, pkg.Name, len(pkg.Files))
+	}
+	return nil
+}
+
+// extractBundle writes the documents of a bundle to target. If pkg is not empty, only
+// files whose import path or folder equals pkg are extracted.
+func extractBundle(bundlePath, pkg string, target outputTarget) error {
+	reader, manifest, err := openBundle(bundlePath)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	count := 0
+	for _, file := range manifest.Files {
+		if pkg != "" && file.ImportPath != pkg && path.Dir(file.Path) != path.Clean(pkg) {
+			continue
+		}
+		astNode := &astjson.ASTNode{}
+		if err := readBundleEntry(&reader.Reader, file.Document, astNode); err != nil {
+			return err
+		}
+		if err := target.write(bundlePath+"#"+file.Document, filepath.FromSlash(file.Path), astNode); err != nil {
+			return fmt.Errorf("error writing %s output for file %s: %w", target.spec(), file.Path, err)
+		}
+		count++
+	}
+	if err := target.close(); err != nil {
+		return err
+	}
+	if pkg != "" && count == 0 {
+		return fmt.Errorf("bundle %s has no package %s", bundlePath, pkg)
+	}
+	return nil
+}
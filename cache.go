@@ -0,0 +1,159 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/kobi2187/go2json/astjson"
+)
+
+var cacheDir = flag.String("cache", "", "keep the documents of converted files in the given folder, such as .go2json-cache, keyed by the content of each file and the flags, so a later run only converts the files that changed (not with -types, -packages, -owners, -annotations, -coverprofile, -chunk-above, -stream or run-wide reports)")
+
+// uncachedFlags are the flags that do not change the documents of a run, so
+// changing them keeps the cache valid.
+var uncachedFlags = map[string]bool{
+	"cache":       true,
+	"jobs":        true,
+	"log-format":  true,
+	"log-level":   true,
+	"max-memory":  true,
+	"report":      true,
+	"upload-jobs": true,
+}
+
+// cacheSupported reports whether the document of a file depends on nothing but
+// its source and the flags. Type information, ownership, annotations and
+// coverage come from other files, and run-wide reports need every file
+// converted.
+func cacheSupported() bool {
+	return !*typesMode && !*mergePackages && !*ownersMode && *annotationsFile == "" &&
+		coverProfile == nil && chunkAbove == 0 && !streamOutput &&
+		*coverageReport == "" && *vocabReport == "" && *concurrencyReport == "" && *apiReport == "" && *deadReport == ""
+}
+
+// processCachedFile writes the document of a source file from the cache if it
+// holds one for the current content of the file, and converts the file and
+// adds its document to the cache otherwise.
+func processCachedFile(root, sourceFilePath string) error {
+	src, err := readSource(sourceFilePath)
+	if err != nil {
+		return err
+	}
+	key, err := cacheKey(sourceFilePath, src)
+	if err != nil {
+		return err
+	}
+	entryPath := filepath.Join(*cacheDir, key[:2], key+".json")
+
+	astNode, err := loadCachedDocument(entryPath)
+	if err != nil {
+		slog.Warn("ignoring unreadable cache entry", "file", sourceFilePath, "entry", entryPath, "error", err)
+	}
+	if astNode != nil {
+		slog.Debug("cache hit", "file", sourceFilePath, "entry", entryPath)
+		return writeDocument(root, sourceFilePath, astNode)
+	}
+
+	astNode, err = convertFile(sourceFilePath)
+	if err != nil {
+		return err
+	}
+	if err := storeCachedDocument(entryPath, astNode); err != nil {
+		slog.Warn("error adding document to cache", "file", sourceFilePath, "error", err)
+	}
+	return writeDocument(root, sourceFilePath, astNode)
+}
+
+// cacheKey returns the key of the document of a source file: the hex-encoded
+// SHA-256 of the running executable, the flags that change documents, the
+// paths and module the document records and the source src. A rebuilt
+// go2json thus starts over with an empty cache.
+func cacheKey(sourceFilePath string, src []byte) (string, error) {
+	executable, err := executableHash()
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	io.WriteString(h, executable+"\n")
+	io.WriteString(h, strconv.Itoa(astjson.FormatVersion)+"\n")
+	flag.VisitAll(func(f *flag.Flag) {
+		if !uncachedFlags[f.Name] {
+			io.WriteString(h, f.Name+"="+f.Value.String()+"\n")
+		}
+	})
+	io.WriteString(h, sourceFilePath+"\n"+sourceHeaderPath(sourceFilePath)+"\n")
+	if dir, err := filepath.Abs(filepath.Dir(sourceFilePath)); err == nil {
+		if module := astjson.FindModule(dir); module != nil {
+			io.WriteString(h, module.Dir+"\n"+module.Path+"\n")
+		}
+	}
+	h.Write(src)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+var executableHash = sync.OnceValues(func() (string, error) {
+	path, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("error locating the go2json executable for the cache: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("error reading the go2json executable for the cache: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+})
+
+// loadCachedDocument reads the cache entry at path, returning nil if there is
+// none.
+func loadCachedDocument(path string) (*astjson.ASTNode, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading cache entry %s: %w", path, err)
+	}
+	var json = jsoniter.ConfigCompatibleWithStandardLibrary
+	astNode := &astjson.ASTNode{}
+	if err := json.Unmarshal(data, astNode); err != nil {
+		return nil, fmt.Errorf("error parsing cache entry %s: %w", path, err)
+	}
+	return astNode, nil
+}
+
+// storeCachedDocument writes the cache entry at path. The entry is written to
+// a temporary file first and renamed into place, so concurrent runs never read
+// a partial entry.
+func storeCachedDocument(path string, astNode *astjson.ASTNode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("error creating cache folder %s: %w", filepath.Dir(path), err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".entry-*")
+	if err != nil {
+		return fmt.Errorf("error creating cache entry %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name())
+	if err := encodeAST(tmp, astNode, ""); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error writing cache entry %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error writing cache entry %s: %w", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("error writing cache entry %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kobi2187/go2json/astjson"
+)
+
+// chunkAbove is the size above which source files are converted in chunks,
+// set with -chunk-above, or 0 to convert every file whole.
+var chunkAbove byteSize
+
+func init() {
+	flag.Var(&chunkAbove, "chunk-above", "convert source files larger than the given size, such as 8MiB, one top-level declaration at a time, writing each as a chunk document and the file document as an index of its chunks (not with -packages)")
+}
+
+// isLargeFile reports whether a source file is to be converted in chunks.
+func isLargeFile(sourceFilePath string) (bool, error) {
+	if chunkAbove == 0 || *mergePackages {
+		return false, nil
+	}
+	size, err := sourceSize(sourceFilePath)
+	if err != nil {
+		return false, err
+	}
+	return size > uint64(chunkAbove), nil
+}
+
+// sourceSize returns the size of a source file, which may be held in memory.
+func sourceSize(sourceFilePath string) (uint64, error) {
+	if memorySource != nil && sourceFilePath == memoryFilename {
+		return uint64(len(memorySource)), nil
+	}
+	info, err := os.Stat(sourceFilePath)
+	if err != nil {
+		return 0, fmt.Errorf("error reading Go source file %s: %w", sourceFilePath, err)
+	}
+	return uint64(info.Size()), nil
+}
+
+// processChunkedFile converts a large source file one top-level declaration
+// at a time. Each chunk is written as soon as it is converted, as the document
+// of a source file named after the chunk, foo.chunk0001.go for the first chunk
+// of foo.go, so that -out-name and the -out targets name and place it. The
+// document of the file itself holds everything but the declarations and lists
+// the chunks in order. Chunk documents carry the source header of the file,
+// whose positions they refer to, since the chunk paths do not exist.
+func processChunkedFile(root, sourceFilePath string) error {
+	src, err := readSource(sourceFilePath)
+	if err != nil {
+		return err
+	}
+	chunks := &chunkWriter{root: root, path: sourceFilePath}
+	chunks.header = astjson.NewSourceHeader(sourceHeaderPath(sourceFilePath), src)
+	astNode, _, err := convertFileChunks(sourceFilePath, chunks)
+	if err != nil {
+		return err
+	}
+	astNode.Chunks = chunks.chunks
+	return writeDocument(root, sourceFilePath, astNode)
+}
+
+// chunkWriter writes the chunks of a source file converted in chunks.
+type chunkWriter struct {
+	root, path string
+	header     *astjson.SourceHeader
+
+	fset   *token.FileSet
+	decls  map[ast.Decl][]string // declaration keys, by top-level declaration
+	chunks []*astjson.Chunk
+}
+
+// start prepares the writing of the chunks of file.
+func (w *chunkWriter) start(fset *token.FileSet, file *ast.File) {
+	w.fset = fset
+	w.decls = make(map[ast.Decl][]string)
+	for _, d := range fileDeclarations(file) {
+		for _, decl := range file.Decls {
+			if decl.Pos() <= d.node.Pos() && d.node.End() <= decl.End() {
+				w.decls[decl] = append(w.decls[decl], d.key)
+				break
+			}
+		}
+	}
+}
+
+// emit writes the chunk of a top-level declaration.
+func (w *chunkWriter) emit(decl ast.Decl, astNode *astjson.ASTNode) error {
+	chunkPath := chunkSourcePath(w.path, len(w.chunks)+1)
+	document, err := outputName(chunkPath)
+	if err != nil {
+		return err
+	}
+	if sidecarAnnotations != nil {
+		sidecarAnnotations.merge(astNode)
+	}
+	if *stringTable {
+		internKinds(astNode)
+	}
+	astNode.Source = w.header
+	if err := writeDocument(w.root, chunkPath, astNode); err != nil {
+		return err
+	}
+	chunk := &astjson.Chunk{
+		Document: document,
+		Decls:    w.decls[decl],
+		Line:     sourcePosition(w.fset, decl.Pos()).Line,
+	}
+	chunk.Start, chunk.End = sourceRange(w.fset, decl)
+	w.chunks = append(w.chunks, chunk)
+	return nil
+}
+
+// chunkSourcePath returns the path the chunk n of a source file is written
+// under, as if it were a source file of its own.
+func chunkSourcePath(sourceFilePath string, n int) string {
+	ext := filepath.Ext(sourceFilePath)
+	return fmt.Sprintf("%s.chunk%04d%s", strings.TrimSuffix(sourceFilePath, ext), n, ext)
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+var concurrencyInventory = &concurrencyCollector{packages: make(map[vocabularyKey]*PackageConcurrency)}
+
+// concurrencyCollector gathers the concurrency operations of every package during a run.
+type concurrencyCollector struct {
+	packages map[vocabularyKey]*PackageConcurrency
+}
+
+// ConcurrencyReport is the result of -concurrency.
+type ConcurrencyReport struct {
+	Packages []*PackageConcurrency `json:"packages"`
+}
+
+// PackageConcurrency lists the concurrency operations of one package in source order.
+type PackageConcurrency struct {
+	Dir        string           `json:"dir"`
+	Name       string           `json:"name"`
+	Operations []*ConcurrencyOp `json:"operations"`
+}
+
+// ConcurrencyOp is a goroutine launch (go), channel creation (make), send,
+// receive or select statement. Func is the enclosing top-level function, ID the
+// node ID of the operation with -ids, and Detail the launched call, the channel
+// type or the number of select cases. Receives by range loops over channels are
+// only found with -types.
+type ConcurrencyOp struct {
+	Kind   string `json:"kind"`
+	File   string `json:"file"`
+	Func   string `json:"func,omitempty"`
+	Line   int    `json:"line,omitempty"`
+	Column int    `json:"column,omitempty"`
+	ID     string `json:"id,omitempty"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// record adds the concurrency operations of file. ids and info may be nil.
+func (c *concurrencyCollector) record(sourceFilePath string, fset *token.FileSet, info *types.Info, ids *nodeIDs, file *ast.File) {
+	key := vocabularyKey{dir: filepath.Dir(sourceFilePath), name: file.Name.Name}
+	pkg := c.packages[key]
+	if pkg == nil {
+		pkg = &PackageConcurrency{Dir: key.dir, Name: key.name, Operations: []*ConcurrencyOp{}}
+		c.packages[key] = pkg
+	}
+
+	funcName := ""
+	add := func(kind string, node ast.Node, detail string) {
+		position := sourcePosition(fset, node.Pos())
+		op := &ConcurrencyOp{
+			Kind:   kind,
+			File:   filepath.Base(sourceFilePath),
+			Func:   funcName,
+			Line:   position.Line,
+			Column: position.Column,
+			Detail: detail,
+		}
+		if ids != nil {
+			op.ID = ids.id(node)
+		}
+		pkg.Operations = append(pkg.Operations, op)
+	}
+
+	for _, decl := range file.Decls {
+		funcName = ""
+		if fn, ok := decl.(*ast.FuncDecl); ok {
+			funcName = fn.Name.Name
+			if recv := receiverTypeName(fn.Recv); recv != "" {
+				funcName = recv + "." + funcName
+			}
+		}
+		ast.Inspect(decl, func(node ast.Node) bool {
+			switch n := node.(type) {
+			case *ast.GoStmt:
+				add("go", n, types.ExprString(n.Call.Fun))
+			case *ast.SendStmt:
+				add("send", n, "")
+			case *ast.UnaryExpr:
+				if n.Op == token.ARROW {
+					add("receive", n, "")
+				}
+			case *ast.SelectStmt:
+				add("select", n, fmt.Sprintf("%d cases", len(n.Body.List)))
+			case *ast.CallExpr:
+				if fun, ok := n.Fun.(*ast.Ident); ok && fun.Name == "make" && len(n.Args) > 0 {
+					if _, ok := ast.Unparen(n.Args[0]).(*ast.ChanType); ok {
+						add("make", n, types.ExprString(n.Args[0]))
+					}
+				}
+			case *ast.RangeStmt:
+				if info == nil {
+					break
+				}
+				if t := info.TypeOf(n.X); t != nil {
+					if _, ok := t.Underlying().(*types.Chan); ok {
+						add("receive", n, "range")
+					}
+				}
+			}
+			return true
+		})
+	}
+}
+
+// report lists the packages in order of folder and name.
+func (c *concurrencyCollector) report() *ConcurrencyReport {
+	report := &ConcurrencyReport{Packages: []*PackageConcurrency{}}
+	for _, pkg := range c.packages {
+		report.Packages = append(report.Packages, pkg)
+	}
+	sort.Slice(report.Packages, func(i, j int) bool {
+		a, b := report.Packages[i], report.Packages[j]
+		if a.Dir != b.Dir {
+			return a.Dir < b.Dir
+		}
+		return a.Name < b.Name
+	})
+	return report
+}
+
+// write saves the report to path.
+func (c *concurrencyCollector) write(path string) error {
+	outputFile, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("error creating concurrency report %s: %w", path, err)
+	}
+	defer outputFile.Close()
+	return encodeAST(outputFile, c.report(), "  ")
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"os"
+	"reflect"
+	"sort"
+)
+
+// attributeFields lists, by node kind, the fields that do not hold nodes but
+// that astjson.Marshaler records on the node itself, such as the name of an
+// identifier or the operator of an expression. Fields holding nodes need no
+// entry: every node they hold is converted as a child, with the field as its
+// role. Position fields are listed if their presence means something, such as
+// the = of an alias type spec or the ... of a call passing a variadic
+// argument. Keep this table in sync with the attributes of the marshaler.
+var attributeFields = map[string][]string{
+	"*ast.Ident":      {"Name"},
+	"*ast.BasicLit":   {"Value"},
+	"*ast.Comment":    {"Text"},
+	"*ast.GenDecl":    {"Tok", "Lparen", "Rparen"},
+	"*ast.TypeSpec":   {"Assign"},
+	"*ast.AssignStmt": {"Tok"},
+	"*ast.IncDecStmt": {"Tok"},
+	"*ast.BranchStmt": {"Tok"},
+	"*ast.RangeStmt":  {"Tok"},
+	"*ast.CallExpr":   {"Ellipsis"},
+	"*ast.ChanType":   {"Dir", "Arrow"},
+	"*ast.UnaryExpr":  {"Op"},
+	"*ast.BinaryExpr": {"Op"},
+}
+
+// positionFields lists, by node kind, the position fields that hold the start
+// or the end of the node, such as the keyword of a statement or the closing
+// brace of a block, which every node records in pos and end. Other position
+// fields, such as that of the operator of a binary expression, are reported
+// as lost unless they are attributes.
+var positionFields = map[string][]string{
+	"*ast.ArrayType":      {"Lbrack"},
+	"*ast.BadDecl":        {"From", "To"},
+	"*ast.BadExpr":        {"From", "To"},
+	"*ast.BadStmt":        {"From", "To"},
+	"*ast.BasicLit":       {"ValuePos", "ValueEnd"},
+	"*ast.BlockStmt":      {"Lbrace", "Rbrace"},
+	"*ast.BranchStmt":     {"TokPos"},
+	"*ast.CallExpr":       {"Rparen"},
+	"*ast.CaseClause":     {"Case"},
+	"*ast.ChanType":       {"Begin"},
+	"*ast.CommClause":     {"Case"},
+	"*ast.Comment":        {"Slash"},
+	"*ast.CompositeLit":   {"Rbrace"},
+	"*ast.DeferStmt":      {"Defer"},
+	"*ast.Ellipsis":       {"Ellipsis"},
+	"*ast.EmptyStmt":      {"Semicolon"},
+	"*ast.FieldList":      {"Opening", "Closing"},
+	"*ast.File":           {"Package"},
+	"*ast.ForStmt":        {"For"},
+	"*ast.FuncType":       {"Func"},
+	"*ast.GenDecl":        {"TokPos"},
+	"*ast.GoStmt":         {"Go"},
+	"*ast.Ident":          {"NamePos"},
+	"*ast.IfStmt":         {"If"},
+	"*ast.ImportSpec":     {"EndPos"},
+	"*ast.IncDecStmt":     {"TokPos"},
+	"*ast.IndexExpr":      {"Rbrack"},
+	"*ast.IndexListExpr":  {"Rbrack"},
+	"*ast.InterfaceType":  {"Interface"},
+	"*ast.MapType":        {"Map"},
+	"*ast.ParenExpr":      {"Lparen", "Rparen"},
+	"*ast.RangeStmt":      {"For"},
+	"*ast.ReturnStmt":     {"Return"},
+	"*ast.SelectStmt":     {"Select"},
+	"*ast.SliceExpr":      {"Rbrack"},
+	"*ast.StarExpr":       {"Star"},
+	"*ast.StructType":     {"Struct"},
+	"*ast.SwitchStmt":     {"Switch"},
+	"*ast.TypeAssertExpr": {"Rparen"},
+	"*ast.TypeSwitchStmt": {"Switch"},
+	"*ast.UnaryExpr":      {"OpPos"},
+}
+
+// ignoredFields are fields that are intentionally not serialized, as they duplicate
+// information found elsewhere in the tree (deprecated object resolution, the file's
+// import and comment lists).
+var ignoredFields = map[string]bool{
+	"Obj":        true,
+	"Scope":      true,
+	"Imports":    true,
+	"Unresolved": true,
+	"Comments":   true,
+}
+
+var (
+	nodeType     = reflect.TypeOf((*ast.Node)(nil)).Elem()
+	posType      = reflect.TypeOf(token.NoPos)
+	kindCoverage = &coverageCollector{kinds: make(map[string]*kindStats)}
+)
+
+// coverageCollector counts the node kinds encountered during a run and the
+// fields that held data for each of them.
+type coverageCollector struct {
+	kinds map[string]*kindStats
+}
+
+// kindStats counts the nodes of one kind and, per field, how many of them had a non-zero value.
+type kindStats struct {
+	typ       reflect.Type
+	count     int
+	fields    map[string]int
+	positions map[string]bool // the positionFields of the kind
+}
+
+// CoverageReport is the result of -coverage-report.
+type CoverageReport struct {
+	Kinds []*KindCoverage `json:"kinds"`
+}
+
+// KindCoverage describes how completely one node kind is represented in the output.
+// Children lists the fields holding nodes, which are converted as children with
+// the field as their role; Attributes the other fields recorded on the node
+// itself; Lost the fields whose data does not appear at all.
+type KindCoverage struct {
+	Kind       string          `json:"kind"`
+	Count      int             `json:"count"`
+	Children   []*FieldCounter `json:"children"`
+	Attributes []*FieldCounter `json:"attributes"`
+	Lost       []*FieldCounter `json:"lost"`
+}
+
+// FieldCounter is the number of nodes in which a field held data.
+type FieldCounter struct {
+	Field string `json:"field"`
+	Count int    `json:"count"`
+}
+
+// record counts every node of file.
+func (c *coverageCollector) record(file *ast.File) {
+	ast.Inspect(file, func(node ast.Node) bool {
+		if node == nil {
+			return true
+		}
+		kind := fmt.Sprintf("%T", node)
+		stats := c.kinds[kind]
+		if stats == nil {
+			stats = &kindStats{typ: reflect.TypeOf(node).Elem(), fields: make(map[string]int), positions: make(map[string]bool)}
+			for _, name := range positionFields[kind] {
+				stats.positions[name] = true
+			}
+			c.kinds[kind] = stats
+		}
+		stats.count++
+
+		value := reflect.ValueOf(node).Elem()
+		for i := 0; i < value.NumField(); i++ {
+			field := stats.typ.Field(i)
+			if !field.IsExported() || stats.positions[field.Name] || ignoredFields[field.Name] {
+				continue
+			}
+			if !value.Field(i).IsZero() {
+				stats.fields[field.Name]++
+			}
+		}
+		return true
+	})
+}
+
+// report classifies the recorded fields of every kind as children, attributes
+// or lost.
+func (c *coverageCollector) report() *CoverageReport {
+	report := &CoverageReport{Kinds: []*KindCoverage{}}
+	for kind, stats := range c.kinds {
+		coverage := &KindCoverage{
+			Kind:       kind,
+			Count:      stats.count,
+			Children:   []*FieldCounter{},
+			Attributes: []*FieldCounter{},
+			Lost:       []*FieldCounter{},
+		}
+		isAttribute := make(map[string]bool)
+		for _, name := range attributeFields[kind] {
+			isAttribute[name] = true
+		}
+
+		for name, count := range stats.fields {
+			counter := &FieldCounter{Field: name, Count: count}
+			switch {
+			case holdsNodes(stats.typ, name):
+				coverage.Children = append(coverage.Children, counter)
+			case isAttribute[name]:
+				coverage.Attributes = append(coverage.Attributes, counter)
+			default:
+				coverage.Lost = append(coverage.Lost, counter)
+			}
+		}
+		sortCounters(coverage.Children)
+		sortCounters(coverage.Attributes)
+		sortCounters(coverage.Lost)
+		report.Kinds = append(report.Kinds, coverage)
+	}
+	sort.Slice(report.Kinds, func(i, j int) bool { return report.Kinds[i].Kind < report.Kinds[j].Kind })
+	return report
+}
+
+// write saves the report to path.
+func (c *coverageCollector) write(path string) error {
+	outputFile, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("error creating coverage report %s: %w", path, err)
+	}
+	defer outputFile.Close()
+	return encodeAST(outputFile, c.report(), "  ")
+}
+
+// holdsNodes reports whether the named field of a node struct type holds AST nodes,
+// which are converted as children.
+func holdsNodes(structType reflect.Type, name string) bool {
+	field, ok := structType.FieldByName(name)
+	if !ok {
+		return false
+	}
+	fieldType := field.Type
+	if fieldType.Kind() == reflect.Slice {
+		fieldType = fieldType.Elem()
+	}
+	return fieldType.Implements(nodeType)
+}
+
+// sortCounters orders counters by field name.
+func sortCounters(counters []*FieldCounter) {
+	sort.Slice(counters, func(i, j int) bool { return counters[i].Field < counters[j].Field })
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/kobi2187/go2json/astjson"
+)
+
+// coverProfile holds the profile loaded with -coverprofile, or nil.
+var coverProfile *coverageProfile
+
+// coverageProfile is a coverage profile as written by go test -coverprofile.
+type coverageProfile struct {
+	mode  string
+	files map[string][]*profileBlock // blocks by file name as recorded in the profile
+}
+
+// profileBlock is a range of statements and the number of times it executed.
+// Lines and columns are 1-based; the end is exclusive.
+type profileBlock struct {
+	startLine, startCol int
+	endLine, endCol     int
+	count               int
+}
+
+// loadCoverProfile parses a coverage profile. Profiles merged from several runs
+// may list the same block more than once.
+func loadCoverProfile(profilePath string) (*coverageProfile, error) {
+	f, err := os.Open(profilePath)
+	if err != nil {
+		return nil, fmt.Errorf("error opening coverage profile %s: %w", profilePath, err)
+	}
+	defer f.Close()
+
+	profile := &coverageProfile{files: make(map[string][]*profileBlock)}
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if mode, ok := strings.CutPrefix(line, "mode:"); ok {
+			profile.mode = strings.TrimSpace(mode)
+			continue
+		}
+		// file.go:startLine.startCol,endLine.endCol numStmts count
+		colon := strings.LastIndex(line, ":")
+		if colon < 0 {
+			return nil, fmt.Errorf("error parsing coverage profile %s:%d: missing file name", profilePath, lineNo)
+		}
+		block := &profileBlock{}
+		var numStmts int
+		_, err := fmt.Sscanf(line[colon+1:], "%d.%d,%d.%d %d %d",
+			&block.startLine, &block.startCol, &block.endLine, &block.endCol, &numStmts, &block.count)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing coverage profile %s:%d: %w", profilePath, lineNo, err)
+		}
+		name := line[:colon]
+		profile.files[name] = append(profile.files[name], block)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading coverage profile %s: %w", profilePath, err)
+	}
+	return profile, nil
+}
+
+// forFile returns the coverage of a source file, or nil if the profile does not
+// mention it. Profiles name files by import path, or by absolute path prefixed with
+// "_" for packages outside of a module.
+func (p *coverageProfile) forFile(fset *token.FileSet, sourceFilePath string, meta *astjson.FileMeta) *fileCoverage {
+	var names []string
+	if meta.ImportPath != "" {
+		names = append(names, path.Join(meta.ImportPath, filepath.Base(sourceFilePath)))
+	}
+	if abs, err := filepath.Abs(sourceFilePath); err == nil {
+		names = append(names, "_"+filepath.ToSlash(abs), filepath.ToSlash(abs))
+	}
+	for _, name := range names {
+		if blocks, ok := p.files[name]; ok {
+			return &fileCoverage{fset: fset, set: p.mode == "set", blocks: blocks}
+		}
+	}
+	return nil
+}
+
+// fileCoverage looks up the execution counts of one file's nodes.
+type fileCoverage struct {
+	fset   *token.FileSet
+	set    bool // the profile only records whether blocks ran
+	blocks []*profileBlock
+}
+
+// count returns the number of times the block containing pos ran, or nil if pos
+// is not part of any block. Counts of a block listed several times are added up,
+// or combined into 0 or 1 for set-mode profiles.
+func (c *fileCoverage) count(pos token.Pos) *int {
+	position := c.fset.Position(pos)
+	var total *int
+	for _, block := range c.blocks {
+		if !block.contains(position.Line, position.Column) {
+			continue
+		}
+		if total == nil {
+			total = new(int)
+		}
+		if c.set {
+			*total = max(*total, min(block.count, 1))
+		} else {
+			*total += block.count
+		}
+	}
+	return total
+}
+
+// coveragePos returns the position whose block gives the execution count of stmt.
+// Profile blocks start at the first statement of a block or clause body rather
+// than at its opening brace or case keyword.
+func coveragePos(stmt ast.Stmt) token.Pos {
+	var body []ast.Stmt
+	switch s := stmt.(type) {
+	case *ast.BlockStmt:
+		body = s.List
+	case *ast.CaseClause:
+		body = s.Body
+	case *ast.CommClause:
+		body = s.Body
+	}
+	if len(body) > 0 {
+		return body[0].Pos()
+	}
+	return stmt.Pos()
+}
+
+// contains reports whether the block covers the given line and column.
+func (b *profileBlock) contains(line, col int) bool {
+	afterStart := line > b.startLine || (line == b.startLine && col >= b.startCol)
+	beforeEnd := line < b.endLine || (line == b.endLine && col < b.endCol)
+	return afterStart && beforeEnd
+}
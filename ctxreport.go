@@ -0,0 +1,133 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strconv"
+
+	"github.com/kobi2187/go2json/astjson"
+)
+
+// contextPropagationReport collects the functions of file that accept a
+// context.Context and the calls creating root contexts. With type information,
+// parameters and calls are recognized by their types and objects; without it, by
+// the name context is imported as.
+func contextPropagationReport(fset *token.FileSet, info *types.Info, file *ast.File) *astjson.ContextReport {
+	contextName := ""
+	for _, imp := range file.Imports {
+		if path, err := strconv.Unquote(imp.Path.Value); err == nil && path == "context" {
+			contextName = importName(imp.Name, path)
+		}
+	}
+	report := &astjson.ContextReport{Funcs: []*astjson.ContextFunc{}, Roots: []*astjson.ContextRoot{}}
+	if contextName == "" && info == nil {
+		return report
+	}
+
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		name := fn.Name.Name
+		if recv := receiverTypeName(fn.Recv); recv != "" {
+			name = recv + "." + name
+		}
+
+		var param *ast.Ident
+		for _, field := range fn.Type.Params.List {
+			if len(field.Names) > 0 && isContextType(info, contextName, field.Type) {
+				param = field.Names[0]
+				break
+			}
+		}
+		ctxFunc := &astjson.ContextFunc{Func: name, Line: sourcePosition(fset, fn.Pos()).Line}
+		ctxFunc.Start, ctxFunc.End = sourceRange(fset, fn)
+		if param != nil {
+			ctxFunc.Param = param.Name
+			report.Funcs = append(report.Funcs, ctxFunc)
+		}
+
+		if fn.Body == nil {
+			continue
+		}
+		ast.Inspect(fn.Body, func(node ast.Node) bool {
+			call, ok := node.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			if root := contextRootCall(info, contextName, call); root != "" {
+				position := sourcePosition(fset, call.Pos())
+				report.Roots = append(report.Roots, &astjson.ContextRoot{
+					Func:   name,
+					Call:   root,
+					Line:   position.Line,
+					Column: position.Column,
+				})
+			}
+			if param == nil || param.Name == "_" {
+				return true
+			}
+			for _, arg := range call.Args {
+				if isSameVar(info, param, ast.Unparen(arg)) {
+					ctxFunc.Passed++
+					break
+				}
+			}
+			return true
+		})
+	}
+	return report
+}
+
+// isContextType reports whether expr denotes context.Context.
+func isContextType(info *types.Info, contextName string, expr ast.Expr) bool {
+	if info != nil {
+		if t := info.TypeOf(expr); t != nil {
+			named, ok := t.(*types.Named)
+			return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "context" && named.Obj().Name() == "Context"
+		}
+	}
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Context" {
+		return false
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	return ok && contextName != "" && pkg.Name == contextName
+}
+
+// contextRootCall returns "context.Background" or "context.TODO" if call creates
+// a root context, and "" otherwise.
+func contextRootCall(info *types.Info, contextName string, call *ast.CallExpr) string {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || (sel.Sel.Name != "Background" && sel.Sel.Name != "TODO") {
+		return ""
+	}
+	if info != nil {
+		if obj, ok := info.Uses[sel.Sel].(*types.Func); ok {
+			if obj.Pkg() != nil && obj.Pkg().Path() == "context" {
+				return "context." + obj.Name()
+			}
+			return ""
+		}
+	}
+	if pkg, ok := sel.X.(*ast.Ident); ok && contextName != "" && pkg.Name == contextName {
+		return "context." + sel.Sel.Name
+	}
+	return ""
+}
+
+// isSameVar reports whether expr refers to the variable declared by decl. Without
+// type information, any identifier with the same name does.
+func isSameVar(info *types.Info, decl *ast.Ident, expr ast.Expr) bool {
+	ident, ok := expr.(*ast.Ident)
+	if !ok || ident.Name != decl.Name {
+		return false
+	}
+	if info == nil {
+		return true
+	}
+	obj := info.Defs[decl]
+	return obj == nil || info.Uses[ident] == obj
+}
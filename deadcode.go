@@ -0,0 +1,268 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kobi2187/go2json/astjson"
+)
+
+var deadDecls = &deadCodeCollector{used: make(map[string]bool), selectors: make(map[string]bool)}
+
+// deadCodeCollector gathers the package-level declarations of the converted
+// files and the references to them during a run, so that declarations no
+// converted file refers to can be reported at the end.
+type deadCodeCollector struct {
+	decls     []*deadCandidate
+	used      map[string]bool // declaration keys referred to
+	selectors map[string]bool // names used after a dot, for methods
+}
+
+// deadCandidate is a declaration that may turn out to be unused. method is
+// the name of a method, which selectors of the name refer to.
+type deadCandidate struct {
+	key    string
+	method string
+	decl   *DeadDecl
+}
+
+// DeadReport is the result of -dead.
+type DeadReport struct {
+	Decls []*DeadDecl `json:"decls"`
+}
+
+// DeadDecl is a package-level constant, variable, type, function or method no
+// converted file refers to. Package is the import path of the declaring
+// package, or its folder outside a module. Name is qualified by the receiver
+// type for methods, as in T.M.
+type DeadDecl struct {
+	Kind     string `json:"kind"`
+	Name     string `json:"name"`
+	Package  string `json:"package"`
+	File     string `json:"file"`
+	Line     int    `json:"line,omitempty"`
+	Exported bool   `json:"exported"`
+}
+
+// record adds the declarations of file and its references to declarations of
+// any package. Entry points, such as main, init and the test functions of
+// _test.go files, are never reported.
+//
+// The type checker cannot import the other packages of a module, so
+// references to them are matched by name: pkg.Name refers to the declaration
+// Name of the package imported as pkg. Methods are counted as referenced by any
+// selector of their name, and by any interface method of their name the type
+// checker has seen, since calls through interfaces and on values of types it
+// could not import are not resolved.
+func (c *deadCodeCollector) record(sourceFilePath string, fset *token.FileSet, pkg *typedPackage, file *ast.File, meta *astjson.FileMeta) {
+	if pkg == nil {
+		return
+	}
+	localPath := meta.ImportPath
+	if localPath == "" {
+		localPath = filepath.Dir(sourceFilePath)
+	}
+	key := func(obj types.Object) string {
+		path := ""
+		if obj.Pkg() != nil {
+			path = obj.Pkg().Path()
+			if obj.Pkg() == pkg.pkg {
+				path = localPath
+			}
+		}
+		return declKey(path, obj)
+	}
+
+	for _, obj := range pkg.info.Uses {
+		if isPackageLevel(obj) {
+			c.used[key(obj)] = true
+		}
+	}
+	for _, tv := range pkg.info.Types {
+		if tv.Type != nil {
+			c.recordInterface(tv.Type)
+		}
+	}
+	ast.Inspect(file, func(node ast.Node) bool {
+		sel, ok := node.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		c.selectors[sel.Sel.Name] = true
+		if x, ok := sel.X.(*ast.Ident); ok {
+			if name, ok := pkg.info.Uses[x].(*types.PkgName); ok {
+				c.used[name.Imported().Path()+"."+sel.Sel.Name] = true
+			}
+		}
+		return true
+	})
+
+	isTest := strings.HasSuffix(sourceFilePath, "_test.go")
+	for _, ident := range packageLevelNames(file) {
+		obj := pkg.info.Defs[ident]
+		if obj == nil || ident.Name == "_" || isEntryPoint(obj, file.Name.Name, isTest) {
+			continue
+		}
+		candidate := &deadCandidate{key: key(obj), decl: &DeadDecl{
+			Kind:     declKind(obj),
+			Name:     ident.Name,
+			Package:  localPath,
+			File:     sourceFilePath,
+			Line:     sourcePosition(fset, ident.Pos()).Line,
+			Exported: ident.IsExported(),
+		}}
+		if recv := receiverName(obj); recv != "" {
+			candidate.method = ident.Name
+			candidate.decl.Name = recv + "." + ident.Name
+		}
+		c.decls = append(c.decls, candidate)
+	}
+}
+
+// declKind returns the kind of a package-level declaration: const, var, type,
+// func or method.
+func declKind(obj types.Object) string {
+	switch obj.(type) {
+	case *types.Const:
+		return "const"
+	case *types.Var:
+		return "var"
+	case *types.TypeName:
+		return "type"
+	}
+	if receiverName(obj) != "" {
+		return "method"
+	}
+	return "func"
+}
+
+// recordInterface counts the methods of t, if it is an interface, as
+// referenced by name.
+func (c *deadCodeCollector) recordInterface(t types.Type) {
+	iface, ok := t.Underlying().(*types.Interface)
+	if !ok {
+		return
+	}
+	for i := 0; i < iface.NumMethods(); i++ {
+		c.selectors[iface.Method(i).Name()] = true
+	}
+}
+
+// packageLevelNames returns the names declared at package level in file,
+// methods included.
+func packageLevelNames(file *ast.File) []*ast.Ident {
+	var names []*ast.Ident
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			names = append(names, decl.Name)
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					names = append(names, spec.Name)
+				case *ast.ValueSpec:
+					names = append(names, spec.Names...)
+				}
+			}
+		}
+	}
+	return names
+}
+
+// isPackageLevel reports whether obj is declared at package level, or is a method.
+func isPackageLevel(obj types.Object) bool {
+	if obj == nil || obj.Pkg() == nil {
+		return false
+	}
+	if receiverName(obj) != "" {
+		return true
+	}
+	return obj.Parent() == obj.Pkg().Scope()
+}
+
+// receiverName returns the name of the receiver base type of a method, or ""
+// if obj is not a method.
+func receiverName(obj types.Object) string {
+	fn, ok := obj.(*types.Func)
+	if !ok {
+		return ""
+	}
+	recv := fn.Type().(*types.Signature).Recv()
+	if recv == nil {
+		return ""
+	}
+	t := recv.Type()
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	if named, ok := t.(*types.Named); ok {
+		return named.Obj().Name()
+	}
+	return ""
+}
+
+// declKey identifies a package-level declaration across packages by the path
+// of its package, its name and, for methods, the name of its receiver type.
+func declKey(path string, obj types.Object) string {
+	if recv := receiverName(obj); recv != "" {
+		return path + "." + recv + "." + obj.Name()
+	}
+	return path + "." + obj.Name()
+}
+
+// isEntryPoint reports whether obj is called by the Go toolchain rather than
+// by code: main in package main, init, and the tests, benchmarks, examples and
+// fuzz tests of _test.go files.
+func isEntryPoint(obj types.Object, pkgName string, isTest bool) bool {
+	if _, ok := obj.(*types.Func); !ok || receiverName(obj) != "" {
+		return false
+	}
+	name := obj.Name()
+	switch {
+	case name == "init", name == "main" && pkgName == "main":
+		return true
+	case isTest:
+		for _, prefix := range []string{"Test", "Benchmark", "Example", "Fuzz"} {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// report lists the declarations never referred to, by file and line.
+func (c *deadCodeCollector) report() *DeadReport {
+	report := &DeadReport{Decls: []*DeadDecl{}}
+	for _, candidate := range c.decls {
+		if c.used[candidate.key] || (candidate.method != "" && c.selectors[candidate.method]) {
+			continue
+		}
+		report.Decls = append(report.Decls, candidate.decl)
+	}
+	sort.SliceStable(report.Decls, func(i, j int) bool {
+		a, b := report.Decls[i], report.Decls[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+	return report
+}
+
+// write saves the report to path.
+func (c *deadCodeCollector) write(path string) error {
+	outputFile, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("error creating dead declaration report %s: %w", path, err)
+	}
+	defer outputFile.Close()
+	return encodeAST(outputFile, c.report(), "  ")
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/types"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/kobi2187/go2json/astjson"
+)
+
+// deltaDocument lists the declarations that changed since the previous run.
+type deltaDocument struct {
+	Changed []*deltaEntry     `json:"changed"`
+	Removed []*deltaTombstone `json:"removed"`
+}
+
+// deltaEntry is a declaration that was added or whose fingerprint changed.
+// Start and End are the byte offsets of the declaration in its file, so its
+// source is src[Start:End]; they are left out with -no-positions.
+type deltaEntry struct {
+	Key         string           `json:"key"`
+	File        string           `json:"file"`
+	Status      string           `json:"status"`
+	Fingerprint string           `json:"fingerprint"`
+	Start       int              `json:"start,omitempty"`
+	End         int              `json:"end,omitempty"`
+	AST         *astjson.ASTNode `json:"ast"`
+}
+
+// deltaTombstone marks a declaration that no longer exists.
+type deltaTombstone struct {
+	Key         string `json:"key"`
+	File        string `json:"file"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+// deltaState holds the declaration fingerprints recorded by a run, keyed by declaration key.
+type deltaState struct {
+	Files map[string]map[string]string `json:"files"`
+}
+
+// processDelta fingerprints every declaration under path and writes <deltaDir>/delta.json
+// containing the declarations that were added or changed since the state saved in
+// <deltaDir>/state.json, plus tombstones for removed ones. The state is then replaced.
+func processDelta(path string, isDir bool, deltaDir string) error {
+	statePath := filepath.Join(deltaDir, "state.json")
+	previous, err := loadDeltaState(statePath)
+	if err != nil {
+		return err
+	}
+
+	root := path
+	if !isDir {
+		root = filepath.Dir(path)
+	}
+
+	current := &deltaState{Files: make(map[string]map[string]string)}
+	delta := &deltaDocument{Changed: []*deltaEntry{}, Removed: []*deltaTombstone{}}
+	collect := func(sourceFilePath string) error {
+		rel, err := filepath.Rel(root, sourceFilePath)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		fset, file, err := parseFile(sourceFilePath)
+		if err != nil {
+			return err
+		}
+		var ids *nodeIDs
+		if *idsMode {
+			if ids, err = newNodeIDs(fset, sourceFilePath); err != nil {
+				return err
+			}
+		}
+		fingerprints := make(map[string]string)
+		for _, decl := range fileDeclarations(file) {
+			astNode := newMarshaler(nil, nil).Marshal(decl.node)
+			fingerprint, err := fingerprintAST(astNode)
+			if err != nil {
+				return fmt.Errorf("error fingerprinting %s in %s: %w", decl.key, sourceFilePath, err)
+			}
+			// Fingerprints leave out positions and node IDs, which change
+			// whenever the code before the declaration does, and the
+			// formatted source, which only repeats the tree.
+			if ids != nil || !*noPositions || *declSource {
+				m := newMarshaler(fset, nil)
+				if ids != nil {
+					m.Annotate = (&nodeAnnotator{ids: ids}).annotate
+				}
+				astNode = m.Marshal(decl.node)
+				if sidecarAnnotations != nil {
+					sidecarAnnotations.merge(astNode)
+				}
+			}
+			fingerprints[decl.key] = fingerprint
+
+			old, existed := previous.Files[rel][decl.key]
+			if existed && old == fingerprint {
+				continue
+			}
+			status := "added"
+			if existed {
+				status = "modified"
+			}
+			entry := &deltaEntry{
+				Key:         decl.key,
+				File:        rel,
+				Status:      status,
+				Fingerprint: fingerprint,
+				AST:         astNode,
+			}
+			entry.Start, entry.End = sourceRange(fset, decl.node)
+			delta.Changed = append(delta.Changed, entry)
+		}
+		current.Files[rel] = fingerprints
+		return nil
+	}
+
+	isolatedCollect := func(sourceFilePath string) error {
+		return isolateFile(sourceFilePath, collect)
+	}
+	if isDir {
+		err = walkGoFiles(path, isolatedCollect)
+	} else {
+		err = isolatedCollect(path)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Anything recorded previously but not seen in this run has been removed.
+	for file, fingerprints := range previous.Files {
+		for key, fingerprint := range fingerprints {
+			if _, ok := current.Files[file][key]; !ok {
+				delta.Removed = append(delta.Removed, &deltaTombstone{Key: key, File: file, Fingerprint: fingerprint})
+			}
+		}
+	}
+	sort.Slice(delta.Removed, func(i, j int) bool {
+		if delta.Removed[i].File != delta.Removed[j].File {
+			return delta.Removed[i].File < delta.Removed[j].File
+		}
+		return delta.Removed[i].Key < delta.Removed[j].Key
+	})
+
+	if err := os.MkdirAll(deltaDir, 0o755); err != nil {
+		return err
+	}
+	deltaPath := filepath.Join(deltaDir, "delta.json")
+	deltaFile, err := os.Create(deltaPath)
+	if err != nil {
+		return fmt.Errorf("error creating output file %s: %w", deltaPath, err)
+	}
+	defer deltaFile.Close()
+
+	var json = jsoniter.ConfigCompatibleWithStandardLibrary
+	jsonEncoder := json.NewEncoder(deltaFile)
+	jsonEncoder.SetIndent("", "  ")
+	if err := jsonEncoder.Encode(delta); err != nil {
+		return fmt.Errorf("error serializing delta to JSON: %w", err)
+	}
+
+	data, err := json.Marshal(current)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(statePath, data, 0o644); err != nil {
+		return fmt.Errorf("error writing state %s: %w", statePath, err)
+	}
+
+	slog.Info("delta generated", "output", deltaPath, "changed", len(delta.Changed), "removed", len(delta.Removed))
+	return nil
+}
+
+// declaration is a top-level declaration of a file together with its stable key.
+type declaration struct {
+	key  string
+	node ast.Node
+}
+
+// fileDeclarations splits a file into its top-level declarations. Grouped
+// declarations are split into their specs so each name gets its own entry.
+// Keys that repeat (e.g. several init functions) get an ordinal suffix.
+func fileDeclarations(file *ast.File) []declaration {
+	var decls []declaration
+	seen := make(map[string]int)
+	add := func(key string, node ast.Node) {
+		seen[key]++
+		if n := seen[key]; n > 1 {
+			key += "#" + strconv.Itoa(n)
+		}
+		decls = append(decls, declaration{key: key, node: node})
+	}
+
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				add("method "+types.ExprString(d.Recv.List[0].Type)+"."+d.Name.Name, d)
+			} else {
+				add("func "+d.Name.Name, d)
+			}
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch sp := spec.(type) {
+				case *ast.ImportSpec:
+					add("import "+sp.Path.Value, sp)
+				case *ast.TypeSpec:
+					add("type "+sp.Name.Name, sp)
+				case *ast.ValueSpec:
+					var names []string
+					for _, name := range sp.Names {
+						names = append(names, name.Name)
+					}
+					add(d.Tok.String()+" "+strings.Join(names, ","), sp)
+				}
+			}
+		default:
+			add("bad", d)
+		}
+	}
+	return decls
+}
+
+// fingerprintAST returns the SHA-256 of the compact JSON encoding of an ASTNode tree.
+func fingerprintAST(astNode *astjson.ASTNode) (string, error) {
+	var json = jsoniter.ConfigCompatibleWithStandardLibrary
+	data, err := json.Marshal(astNode)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// loadDeltaState reads the state at path, returning an empty state if it does not exist yet.
+func loadDeltaState(path string) (*deltaState, error) {
+	state := &deltaState{Files: make(map[string]map[string]string)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading state %s: %w", path, err)
+	}
+	var json = jsoniter.ConfigCompatibleWithStandardLibrary
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("error parsing state %s: %w", path, err)
+	}
+	if state.Files == nil {
+		state.Files = make(map[string]map[string]string)
+	}
+	return state, nil
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+// unifiedDiff returns a unified diff turning old into new, or "" if they are equal.
+// Lines common to the start and end of both versions are trimmed before comparing
+// the rest line by line, which keeps the comparison cheap for local edits.
+func unifiedDiff(path string, old, new []byte) string {
+	if bytes.Equal(old, new) {
+		return ""
+	}
+	a, b := splitLines(old), splitLines(new)
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	ops := diffLines(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])
+
+	// Surround the changed lines with the common lines and group them into hunks.
+	var lines []diffLine
+	for _, line := range a[:prefix] {
+		lines = append(lines, diffLine{' ', line})
+	}
+	lines = append(lines, ops...)
+	for _, line := range a[len(a)-suffix:] {
+		lines = append(lines, diffLine{' ', line})
+	}
+
+	// Line numbers of every line in the old and new version.
+	oldNo, newNo := make([]int, len(lines)+1), make([]int, len(lines)+1)
+	oldNo[0], newNo[0] = 1, 1
+	for k, line := range lines {
+		oldNo[k+1], newNo[k+1] = oldNo[k], newNo[k]
+		if line.op != '+' {
+			oldNo[k+1]++
+		}
+		if line.op != '-' {
+			newNo[k+1]++
+		}
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", path, path)
+	for i := 0; i < len(lines); {
+		if lines[i].op == ' ' {
+			i++
+			continue
+		}
+		// Changes separated by at most twice the context share a hunk.
+		last := i
+		for j := i + 1; j < len(lines); j++ {
+			if lines[j].op == ' ' {
+				continue
+			}
+			if j-last-1 > 2*diffContext {
+				break
+			}
+			last = j
+		}
+		start, end := max(i-diffContext, 0), min(last+diffContext+1, len(lines))
+
+		oldStart, oldCount := oldNo[start], oldNo[end]-oldNo[start]
+		newStart, newCount := newNo[start], newNo[end]-newNo[start]
+		if oldCount == 0 {
+			oldStart--
+		}
+		if newCount == 0 {
+			newStart--
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+		for _, line := range lines[start:end] {
+			out.WriteByte(line.op)
+			out.WriteString(line.text)
+			out.WriteByte('\n')
+		}
+		i = end
+	}
+	return out.String()
+}
+
+// diffLine is a line of a diff: ' ' for unchanged, '-' for removed, '+' for added.
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// diffLines computes a minimal line diff from the longest common subsequence.
+func diffLines(a, b []string) []diffLine {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	var ops []diffLine
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, diffLine{' ', a[i]})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffLine{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffLine{'+', b[j]})
+			j++
+		}
+	}
+	return ops
+}
+
+// splitLines splits src into lines without their line terminators.
+func splitLines(src []byte) []string {
+	text := strings.TrimSuffix(string(src), "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"go/ast"
+	"go/scanner"
+	"go/token"
+	"strings"
+
+	"github.com/kobi2187/go2json/astjson"
+)
+
+// directiveReport lists the directive comments of a file, such as //go:build,
+// //go:generate, //go:embed and //go:linkname. A directive is attached to the
+// declaration starting on the line after the comment group holding it, named
+// like the declarations of -delta. The source is scanned directly, so
+// directives are found whether or not comments are kept in the tree.
+func directiveReport(fset *token.FileSet, file *ast.File, src []byte) []*astjson.Directive {
+	declLines := make(map[int]string)
+	for _, decl := range fileDeclarations(file) {
+		line := fset.Position(decl.node.Pos()).Line
+		if _, ok := declLines[line]; !ok {
+			declLines[line] = decl.key
+		}
+	}
+
+	var s scanner.Scanner
+	tokenFile := token.NewFileSet().AddFile(fset.File(file.Pos()).Name(), -1, len(src))
+	s.Init(tokenFile, src, nil, scanner.ScanComments)
+
+	directives := []*astjson.Directive{}
+	var group []*astjson.Directive // directives of the current comment group
+	lastLine := 0
+	for {
+		pos, tok, lit := s.Scan()
+		line := 0
+		if tok != token.EOF {
+			line = tokenFile.Line(pos)
+		}
+		if tok != token.COMMENT || line > lastLine+1 {
+			// The comment group ended; attach its directives to the
+			// declaration on the next line, if any.
+			for _, directive := range group {
+				directive.Decl = declLines[lastLine+1]
+			}
+			group = nil
+		}
+		if tok == token.EOF {
+			break
+		}
+		if tok != token.COMMENT {
+			continue
+		}
+		lastLine = line + strings.Count(lit, "\n")
+		if name, args, ok := parseDirective(lit); ok {
+			directive := &astjson.Directive{Name: name, Args: args}
+			if !*noPositions {
+				directive.Line = line
+				directive.Start = tokenFile.Offset(pos)
+				directive.End = directive.Start + len(lit)
+			}
+			directives = append(directives, directive)
+			group = append(group, directive)
+		}
+	}
+	return directives
+}
+
+// parseDirective splits a directive comment //name:verb args into its name and
+// arguments. Like the go command, it only accepts line comments with no space
+// after the slashes whose name starts with a lower-case letter or digit.
+func parseDirective(comment string) (name, args string, ok bool) {
+	text, ok := strings.CutPrefix(comment, "//")
+	if !ok || text == "" {
+		return "", "", false
+	}
+	name, args, _ = strings.Cut(text, " ")
+	prefix, verb, ok := strings.Cut(name, ":")
+	if !ok || prefix == "" || verb == "" {
+		return "", "", false
+	}
+	for _, r := range prefix {
+		if !('a' <= r && r <= 'z' || '0' <= r && r <= '9') {
+			return "", "", false
+		}
+	}
+	return name, strings.TrimSpace(args), true
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+
+	"github.com/kobi2187/go2json/astjson"
+)
+
+// enumReport lists the enumerations declared in file: const blocks whose first
+// constant has a named type and an iota-based value. The members are the
+// constants of the block that have the enum type, whether stated or implied by
+// an omitted value list. Blank constants are skipped.
+func enumReport(fset *token.FileSet, info *types.Info, file *ast.File) []*astjson.Enum {
+	enums := []*astjson.Enum{}
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST || len(gen.Specs) == 0 {
+			continue
+		}
+		first := gen.Specs[0].(*ast.ValueSpec)
+		typeName, ok := first.Type.(*ast.Ident)
+		if !ok || !usesIota(first.Values) {
+			continue
+		}
+
+		enum := &astjson.Enum{Type: typeName.Name, Line: sourcePosition(fset, gen.Pos()).Line, Members: []*astjson.EnumMember{}}
+		enum.Start, enum.End = sourceRange(fset, gen)
+		var values []ast.Expr
+		var valueType ast.Expr
+		for iota, spec := range gen.Specs {
+			valueSpec := spec.(*ast.ValueSpec)
+			if valueSpec.Values != nil {
+				values, valueType = valueSpec.Values, valueSpec.Type
+			}
+			if ident, ok := valueType.(*ast.Ident); !ok || ident.Name != typeName.Name {
+				continue
+			}
+			for i, name := range valueSpec.Names {
+				if name.Name == "_" {
+					continue
+				}
+				member := &astjson.EnumMember{Name: name.Name}
+				if info != nil {
+					if c, ok := info.Defs[name].(*types.Const); ok {
+						member.Value = c.Val().ExactString()
+					}
+				}
+				if member.Value == "" && i < len(values) {
+					if value, ok := evalIota(values[i], int64(iota)); ok {
+						member.Value = value.ExactString()
+					}
+				}
+				enum.Members = append(enum.Members, member)
+			}
+		}
+		enums = append(enums, enum)
+	}
+	return enums
+}
+
+// usesIota reports whether any of exprs refers to iota.
+func usesIota(exprs []ast.Expr) bool {
+	found := false
+	for _, expr := range exprs {
+		ast.Inspect(expr, func(node ast.Node) bool {
+			if ident, ok := node.(*ast.Ident); ok && ident.Name == "iota" {
+				found = true
+			}
+			return !found
+		})
+	}
+	return found
+}
+
+// evalIota evaluates an integer constant expression made of literals, iota and
+// arithmetic, as found in enum declarations, for the given value of iota. It
+// returns false for any other expression.
+func evalIota(expr ast.Expr, iota int64) (constant.Value, bool) {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		if e.Kind != token.INT && e.Kind != token.CHAR {
+			return nil, false
+		}
+		value := constant.MakeFromLiteral(e.Value, e.Kind, 0)
+		if e.Kind == token.CHAR {
+			value = constant.ToInt(value)
+		}
+		return value, value.Kind() == constant.Int
+	case *ast.Ident:
+		if e.Name != "iota" {
+			return nil, false
+		}
+		return constant.MakeInt64(iota), true
+	case *ast.ParenExpr:
+		return evalIota(e.X, iota)
+	case *ast.UnaryExpr:
+		x, ok := evalIota(e.X, iota)
+		if !ok || (e.Op != token.SUB && e.Op != token.ADD && e.Op != token.XOR) {
+			return nil, false
+		}
+		return constant.UnaryOp(e.Op, x, 0), true
+	case *ast.BinaryExpr:
+		x, ok := evalIota(e.X, iota)
+		if !ok {
+			return nil, false
+		}
+		y, ok := evalIota(e.Y, iota)
+		if !ok {
+			return nil, false
+		}
+		switch e.Op {
+		case token.SHL, token.SHR:
+			shift, ok := constant.Uint64Val(y)
+			if !ok || shift > 1023 {
+				return nil, false
+			}
+			return constant.Shift(x, e.Op, uint(shift)), true
+		case token.QUO, token.REM:
+			if constant.Sign(y) == 0 {
+				return nil, false
+			}
+			if e.Op == token.QUO {
+				return constant.BinaryOp(x, token.QUO_ASSIGN, y), true
+			}
+			return constant.BinaryOp(x, token.REM, y), true
+		case token.ADD, token.SUB, token.MUL, token.AND, token.OR, token.XOR, token.AND_NOT:
+			return constant.BinaryOp(x, e.Op, y), true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strconv"
+	"strings"
+
+	"github.com/kobi2187/go2json/astjson"
+)
+
+// errorType is the predeclared error interface.
+var errorType = types.Universe.Lookup("error").Type().Underlying().(*types.Interface)
+
+// errorHandlingReport summarizes the error handling of every function declared
+// in file, in source order. With type information, error values and results are
+// recognized by their types; without it, by the err naming convention and the
+// convention that errors are returned last.
+func errorHandlingReport(fset *token.FileSet, info *types.Info, file *ast.File) []*astjson.ErrorHandling {
+	fmtName := ""
+	for _, imp := range file.Imports {
+		if path, err := strconv.Unquote(imp.Path.Value); err == nil && path == "fmt" {
+			fmtName = importName(imp.Name, path)
+		}
+	}
+
+	report := []*astjson.ErrorHandling{}
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+		name := fn.Name.Name
+		if recv := receiverTypeName(fn.Recv); recv != "" {
+			name = recv + "." + name
+		}
+		handling := &astjson.ErrorHandling{Func: name, Line: sourcePosition(fset, fn.Pos()).Line}
+		handling.Start, handling.End = sourceRange(fset, fn)
+		ast.Inspect(fn.Body, func(node ast.Node) bool {
+			switch n := node.(type) {
+			case *ast.IfStmt:
+				if isErrorCheck(info, n.Cond) {
+					handling.Checks++
+					if wrapsError(n.Body, fmtName) {
+						handling.Wrapped++
+					}
+				}
+			case *ast.AssignStmt:
+				handling.Ignored += ignoredErrors(info, n.Lhs, n.Rhs)
+			case *ast.ValueSpec:
+				handling.Ignored += ignoredErrors(info, identExprs(n.Names), n.Values)
+			}
+			return true
+		})
+		report = append(report, handling)
+	}
+	return report
+}
+
+// isErrorCheck reports whether cond compares an error with nil using !=.
+func isErrorCheck(info *types.Info, cond ast.Expr) bool {
+	binary, ok := ast.Unparen(cond).(*ast.BinaryExpr)
+	if !ok || binary.Op != token.NEQ {
+		return false
+	}
+	x, y := ast.Unparen(binary.X), ast.Unparen(binary.Y)
+	if isNil(x) {
+		x, y = y, x
+	}
+	if !isNil(y) {
+		return false
+	}
+	if info != nil {
+		if tv, ok := info.Types[x]; ok && tv.Type != nil {
+			return types.Implements(tv.Type, errorType)
+		}
+	}
+	ident, ok := x.(*ast.Ident)
+	return ok && (ident.Name == "err" || strings.HasSuffix(ident.Name, "Err") || strings.HasSuffix(ident.Name, "err"))
+}
+
+// isNil reports whether expr is the identifier nil.
+func isNil(expr ast.Expr) bool {
+	ident, ok := expr.(*ast.Ident)
+	return ok && ident.Name == "nil"
+}
+
+// wrapsError reports whether body calls fmt.Errorf with a format containing %w.
+// fmtName is the name fmt is imported as, or "" if the file does not import it.
+func wrapsError(body *ast.BlockStmt, fmtName string) bool {
+	if fmtName == "" {
+		return false
+	}
+	wraps := false
+	ast.Inspect(body, func(node ast.Node) bool {
+		call, ok := node.(*ast.CallExpr)
+		if !ok || len(call.Args) == 0 {
+			return !wraps
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || sel.Sel.Name != "Errorf" {
+			return true
+		}
+		if pkg, ok := sel.X.(*ast.Ident); !ok || pkg.Name != fmtName {
+			return true
+		}
+		if lit, ok := call.Args[0].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+			format, err := strconv.Unquote(lit.Value)
+			wraps = wraps || (err == nil && strings.Contains(format, "%w"))
+		}
+		return !wraps
+	})
+	return wraps
+}
+
+// ignoredErrors returns the number of error values assigned to the blank
+// identifier by an assignment of rhs to lhs.
+func ignoredErrors(info *types.Info, lhs, rhs []ast.Expr) int {
+	// Blank identifiers paired with the results of a single call.
+	var results []types.Type
+	if len(rhs) == 1 && len(lhs) > 1 {
+		call, ok := ast.Unparen(rhs[0]).(*ast.CallExpr)
+		if !ok {
+			return 0
+		}
+		if info != nil {
+			if tuple, ok := info.TypeOf(call).(*types.Tuple); ok && tuple.Len() == len(lhs) {
+				for i := 0; i < tuple.Len(); i++ {
+					results = append(results, tuple.At(i).Type())
+				}
+			}
+		}
+	} else if len(rhs) == len(lhs) && info != nil {
+		for _, expr := range rhs {
+			results = append(results, info.TypeOf(expr))
+		}
+	}
+
+	ignored := 0
+	for i, expr := range lhs {
+		if ident, ok := expr.(*ast.Ident); !ok || ident.Name != "_" {
+			continue
+		}
+		if results != nil {
+			if results[i] != nil && types.Implements(results[i], errorType) {
+				ignored++
+			}
+			continue
+		}
+		// Without types, only a blank last result of a call is taken for an error.
+		if i == len(lhs)-1 && len(rhs) == 1 {
+			if _, ok := ast.Unparen(rhs[0]).(*ast.CallExpr); ok {
+				ignored++
+			}
+		}
+	}
+	return ignored
+}
+
+// identExprs returns idents as a list of expressions.
+func identExprs(idents []*ast.Ident) []ast.Expr {
+	exprs := make([]ast.Expr, len(idents))
+	for i, ident := range idents {
+		exprs[i] = ident
+	}
+	return exprs
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"go/ast"
+	"sort"
+	"strconv"
+
+	"github.com/kobi2187/go2json/astjson"
+)
+
+// externalTypesReport lists, per imported package, the types of that package
+// file refers to: qualified identifiers such as io.Reader in type positions
+// (declared types, fields, parameters, composite literals, type
+// assertions and switches, and the arguments of new and make). It works on the
+// syntax alone, so types reached through dot-imports are not listed.
+func externalTypesReport(file *ast.File) []*astjson.ExternalTypes {
+	paths := make(map[string]string) // import name to path
+	for _, imp := range file.Imports {
+		path, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+		if name := importName(imp.Name, path); name != "_" && name != "." {
+			paths[name] = path
+		}
+	}
+
+	used := make(map[string]map[string]bool) // import name to type names
+	var walk func(expr ast.Expr)
+	walkFields := func(fields *ast.FieldList) {
+		if fields == nil {
+			return
+		}
+		for _, field := range fields.List {
+			walk(field.Type)
+		}
+	}
+	walk = func(expr ast.Expr) {
+		switch e := expr.(type) {
+		case *ast.SelectorExpr:
+			if pkg, ok := e.X.(*ast.Ident); ok && paths[pkg.Name] != "" {
+				if used[pkg.Name] == nil {
+					used[pkg.Name] = make(map[string]bool)
+				}
+				used[pkg.Name][e.Sel.Name] = true
+			}
+		case *ast.ParenExpr:
+			walk(e.X)
+		case *ast.StarExpr:
+			walk(e.X)
+		case *ast.Ellipsis:
+			walk(e.Elt)
+		case *ast.ArrayType:
+			walk(e.Elt)
+		case *ast.MapType:
+			walk(e.Key)
+			walk(e.Value)
+		case *ast.ChanType:
+			walk(e.Value)
+		case *ast.FuncType:
+			walkFields(e.TypeParams)
+			walkFields(e.Params)
+			walkFields(e.Results)
+		case *ast.StructType:
+			walkFields(e.Fields)
+		case *ast.InterfaceType:
+			walkFields(e.Methods)
+		case *ast.IndexExpr:
+			walk(e.X)
+			walk(e.Index)
+		case *ast.IndexListExpr:
+			walk(e.X)
+			for _, index := range e.Indices {
+				walk(index)
+			}
+		case *ast.BinaryExpr:
+			// Union elements of constraints, such as ~int | big.Word.
+			walk(e.X)
+			walk(e.Y)
+		case *ast.UnaryExpr:
+			walk(e.X)
+		}
+	}
+
+	ast.Inspect(file, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.Field:
+			walk(n.Type)
+		case *ast.ValueSpec:
+			walk(n.Type)
+		case *ast.TypeSpec:
+			walk(n.Type)
+		case *ast.CompositeLit:
+			walk(n.Type)
+		case *ast.TypeAssertExpr:
+			walk(n.Type)
+		case *ast.TypeSwitchStmt:
+			for _, clause := range n.Body.List {
+				for _, expr := range clause.(*ast.CaseClause).List {
+					walk(expr)
+				}
+			}
+		case *ast.CallExpr:
+			if fun, ok := n.Fun.(*ast.Ident); ok && (fun.Name == "new" || fun.Name == "make") && len(n.Args) > 0 {
+				walk(n.Args[0])
+			}
+		}
+		return true
+	})
+
+	refs := []*astjson.ExternalTypes{}
+	for name, typeNames := range used {
+		ref := &astjson.ExternalTypes{Path: paths[name], Name: name, Types: []string{}}
+		for typeName := range typeNames {
+			ref.Types = append(ref.Types, typeName)
+		}
+		sort.Strings(ref.Types)
+		refs = append(refs, ref)
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].Path != refs[j].Path {
+			return refs[i].Path < refs[j].Path
+		}
+		return refs[i].Name < refs[j].Name
+	})
+	return refs
+}
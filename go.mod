@@ -0,0 +1,15 @@
+module github.com/kobi2187/go2json
+
+go 1.22.0
+
+require (
+	github.com/json-iterator/go v1.1.12
+	golang.org/x/tools v0.30.0
+)
+
+require (
+	github.com/modern-go/concurrent v0.0.0-20180228061459-e0a39a4cb421 // indirect
+	github.com/modern-go/reflect2 v1.0.2 // indirect
+	golang.org/x/mod v0.23.0 // indirect
+	golang.org/x/sync v0.11.0 // indirect
+)
@@ -1,735 +1,808 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"go/ast"
+	"go/importer"
 	"go/parser"
 	"go/token"
+	"go/types"
+	"go/version"
+	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"runtime/debug"
 	"strings"
+	"text/template"
+	"time"
 
 	jsoniter "github.com/json-iterator/go"
+	"github.com/kobi2187/go2json/astjson"
 )
 
-// ASTNode represents a node in the abstract syntax tree.
-type ASTNode struct {
-	Name     string      `json:"name,omitempty"`
-	Type     string      `json:"type"`
-	Children []*ASTNode  `json:"children,omitempty"`
-	Value    interface{} `json:"value,omitempty"`
-	Comments []string    `json:"comments,omitempty"`
-}
+// Command-line flags.
+var (
+	langVersion       = flag.String("lang", "", "Go language version to check the source against (e.g. go1.20)")
+	modcacheOut       = flag.String("modcache", "", "convert every module version in GOMODCACHE into the given output folder")
+	deltaDir          = flag.String("delta", "", "write only declarations changed since the previous run to the given folder")
+	unresolved        = flag.Bool("unresolved", false, "list identifiers that do not resolve to a declaration in the file or a dot-import")
+	initReport        = flag.Bool("init", false, "report init functions and package-level variable initializers")
+	instancesReport   = flag.Bool("instances", false, "report the instantiations of generic functions and types (requires -types)")
+	typesMode         = flag.Bool("types", false, "type-check the package of each file to enable type-aware output and annotate expressions with their type, identifiers with their object kind and unused parameters as unused")
+	testsMode         = flag.String("tests", "include", "handling of _test.go files (including external test packages) in folders: include, exclude or only")
+	outName           = flag.String("out-name", "{{.Base}}.json", "template for output file names; fields: .Name, .Base, .Ext")
+	outExt            = flag.String("out-ext", "", "output file extension, shorthand for -out-name '{{.Base}}<ext>'")
+	coverageReport    = flag.String("coverage-report", "", "write a report of the node kinds encountered and the fields the output drops to the given file")
+	dropParens        = flag.Bool("drop-parens", false, "remove ParenExpr nodes, counting them in the parens field of their operand")
+	vocabReport       = flag.String("vocab", "", "write identifier token frequencies per package and overall to the given file")
+	vocabSplit        = flag.Bool("vocab-split", false, "split identifiers into lower-case camelCase and snake_case subtokens for -vocab")
+	idsMode           = flag.Bool("ids", false, "give every node a stable ID derived from the file hash, its position and its kind")
+	annotationsFile   = flag.String("annotations", "", "merge a JSON object mapping node IDs to annotations into the output (requires -ids)")
+	coverProfilePath  = flag.String("coverprofile", "", "annotate statements with their execution counts from the given go test coverage profile")
+	stringTable       = flag.Bool("string-table", false, "list node kinds once in the kind_table of each document and refer to them by index in the kind field of nodes")
+	ownersMode        = flag.Bool("owners", false, "tag declarations with their region (from // region: NAME comments) and CODEOWNERS owners")
+	noPositions       = flag.Bool("no-positions", false, "leave out all source positions so the output does not change with whitespace or comment edits")
+	withComments      = flag.Bool("comments", false, "parse comments and list each one in the comments field of the node it belongs to, such as the declaration it documents, with its kind, position and doc group in comment_info, and doc comments in the doc field of declarations")
+	skipBodies        = flag.Bool("skip-bodies", false, "leave out the bodies of functions and function literals")
+	maxDepth          = flag.Int("max-depth", 0, "replace nodes nested deeper than the given depth by childless nodes marked truncated, bounding the size of the output for machine-generated code; 0 converts the whole tree")
+	indent            = flag.String("indent", "  ", "indentation of the JSON written next to source files; empty for single-line output")
+	foldStrings       = flag.Bool("fold-strings", false, "record the value of concatenations of string literals in the folded field of the concatenation")
+	errorsReport      = flag.Bool("errors", false, "report the error handling of each function: if err != nil checks, checks wrapping with %w and errors discarded with _")
+	concurrencyReport = flag.String("concurrency", "", "write the goroutine launches, channel makes, sends, receives and selects of each package to the given file")
+	contextReport     = flag.Bool("context", false, "report the functions accepting a context.Context, whether they pass it on, and calls of context.Background and context.TODO")
+	mergePackages     = flag.Bool("packages", false, "write one document per package, holding the documents of its files and tables of their imports and declarations")
+	structsReport     = flag.Bool("structs", false, "report the fields of every struct type, with sizes, alignments and offsets under -types")
+	enumsReport       = flag.Bool("enums", false, "report enumerations: typed constants declared with iota in a const block")
+	interfacesReport  = flag.Bool("interfaces", false, "report the method signatures of every interface type, including embedded methods under -types, for mock generators")
+	workspaceOut      = flag.String("workspace-manifest", "", "file to write the module manifest of a converted go.work workspace to (default go.work.json next to go.work)")
+	sourceRoot        = flag.String("source-root", "", "folder the source paths in document headers are relative to (default the current folder)")
+	declSource        = flag.Bool("decl-source", false, "record the gofmt-formatted source of every declaration in its code field")
+	directivesReport  = flag.Bool("directives", false, "report directive comments such as //go:generate and //go:embed with their arguments and the declaration they are attached to")
+	shadowsReport     = flag.Bool("shadows", false, "report declarations shadowing a declaration of an enclosing scope (requires -types)")
+	switchesReport    = flag.Bool("switches", false, "report switch, type switch and select statements with their cases and, under -types, the constants or types no case handles")
+	apiReport         = flag.String("api", "", "write the exported symbols of each package with their signatures, doc synopses and stability to the given file")
+	externalTypes     = flag.Bool("external-types", false, "report the types of imported packages each file refers to, found without type checking")
+	methodSets        = flag.Bool("method-sets", false, "report the method sets of named types, including methods promoted from embedded fields with their embedding path (requires -types)")
+	deadReport        = flag.String("dead", "", "write the package-level declarations no converted file refers to, except entry points such as main and tests, to the given file (requires -types)")
+	numbersReport     = flag.Bool("numbers", false, "report the numeric literals of every function with the context they are used in, such as comparisons, array sizes and durations, for magic number reviews")
+	logFormat         = flag.String("log-format", "text", "log output format: text or json")
+	logLevel          = flag.String("log-level", "info", "minimum log level: debug, info, warn or error")
+)
 
-// marshalAST converts an ast.Node into an ASTNode.
-func marshalAST(node ast.Node, visited map[ast.Node]bool) *ASTNode {
-	if node == nil {
-		return nil
+// conversionOptions returns the conversion options set by the command-line flags.
+func conversionOptions() astjson.Options {
+	return astjson.Options{
+		Indent:      *indent,
+		Comments:    *withComments,
+		SkipBodies:  *skipBodies,
+		MaxDepth:    *maxDepth,
+		DropParens:  *dropParens,
+		FoldStrings: *foldStrings,
+		Positions:   !*noPositions,
+		DeclSource:  *declSource,
 	}
+}
 
-	// Check if the node has been visited before to avoid cycles.
-	if visited[node] {
-		return nil
+// newMarshaler creates a marshaler configured by the command-line flags. fset may
+// be nil to leave out source positions, and info if no type information is available.
+func newMarshaler(fset *token.FileSet, info *types.Info) *astjson.Marshaler {
+	return &astjson.Marshaler{Info: info, Fset: fset, Options: conversionOptions()}
+}
+
+// nodeAnnotator adds the per-node data enabled by command-line flags to every
+// node a marshaler creates. Each field is nil if its flag is not set.
+type nodeAnnotator struct {
+	ids    *nodeIDs       // node IDs, for -ids
+	cover  *fileCoverage  // statement execution counts, for -coverprofile
+	owners *fileOwnership // declaration ownership, for -owners
+
+	path     string    // source file, for errors
+	deadline time.Time // end of the conversion time of -untrusted, if not zero
+}
+
+func (a *nodeAnnotator) annotate(node ast.Node, astNode *astjson.ASTNode) {
+	if !a.deadline.IsZero() && time.Now().After(a.deadline) {
+		panic(untrustedTimeError(a.path))
 	}
-	visited[node] = true
-
-	astNode := &ASTNode{Type: fmt.Sprintf("%T", node)}
-
-	// Handle different types of AST nodes.
-	// Handle different types of AST nodes.
-	switch n := node.(type) {
-	case *ast.Ident:
-		astNode.Value = n.Name
-	case *ast.BasicLit:
-		astNode.Value = n.Value
-	case *ast.File:
-		astNode.Value = n.Name.Name
-	case *ast.Ellipsis:
-		if n.Elt != nil {
-			eltNode := marshalAST(n.Elt, visited)
-			if eltNode != nil {
-				astNode.Children = append(astNode.Children, eltNode)
-			}
-		}
-	case *ast.GenDecl:
-		for _, spec := range n.Specs {
-			childNode := marshalAST(spec, visited)
-			if childNode != nil {
-				astNode.Children = append(astNode.Children, childNode)
-			}
-		}
-	case *ast.FuncDecl:
-		astNode.Name = n.Name.Name
-		if n.Recv != nil {
-			recvNode := marshalAST(n.Recv, visited)
-			if recvNode != nil {
-				astNode.Children = append(astNode.Children, recvNode)
-			}
-		}
-		if n.Type != nil {
-			typeNode := marshalAST(n.Type, visited)
-			if typeNode != nil {
-				astNode.Children = append(astNode.Children, typeNode)
-			}
-		}
-		if n.Body != nil {
-			bodyNode := marshalAST(n.Body, visited)
-			if bodyNode != nil {
-				astNode.Children = append(astNode.Children, bodyNode)
-			}
-		}
-	case *ast.TypeSpec:
-		astNode.Name = n.Name.Name
-		typeNode := marshalAST(n.Type, visited)
-		if typeNode != nil {
-			astNode.Children = append(astNode.Children, typeNode)
-		}
-	case *ast.ValueSpec:
-		for _, name := range n.Names {
-			nameNode := marshalAST(name, visited)
-			if nameNode != nil {
-				astNode.Children = append(astNode.Children, nameNode)
-			}
-		}
-		if n.Type != nil {
-			typeNode := marshalAST(n.Type, visited)
-			if typeNode != nil {
-				astNode.Children = append(astNode.Children, typeNode)
-			}
-		}
-		for _, value := range n.Values {
-			valueNode := marshalAST(value, visited)
-			if valueNode != nil {
-				astNode.Children = append(astNode.Children, valueNode)
-			}
-		}
-	case *ast.AssignStmt:
-		for _, lhs := range n.Lhs {
-			lhsNode := marshalAST(lhs, visited)
-			if lhsNode != nil {
-				astNode.Children = append(astNode.Children, lhsNode)
-			}
-		}
-		for _, rhs := range n.Rhs {
-			rhsNode := marshalAST(rhs, visited)
-			if rhsNode != nil {
-				astNode.Children = append(astNode.Children, rhsNode)
-			}
-		}
-	case *ast.ReturnStmt:
-		for _, result := range n.Results {
-			resultNode := marshalAST(result, visited)
-			if resultNode != nil {
-				astNode.Children = append(astNode.Children, resultNode)
-			}
-		}
-	case *ast.IfStmt:
-		if n.Init != nil {
-			initNode := marshalAST(n.Init, visited)
-			if initNode != nil {
-				astNode.Children = append(astNode.Children, initNode)
-			}
-		}
-		if n.Cond != nil {
-			condNode := marshalAST(n.Cond, visited)
-			if condNode != nil {
-				astNode.Children = append(astNode.Children, condNode)
-			}
-		}
-		if n.Body != nil {
-			bodyNode := marshalAST(n.Body, visited)
-			if bodyNode != nil {
-				astNode.Children = append(astNode.Children, bodyNode)
-			}
-		}
-		if n.Else != nil {
-			elseNode := marshalAST(n.Else, visited)
-			if elseNode != nil {
-				astNode.Children = append(astNode.Children, elseNode)
-			}
-		}
-	case *ast.ForStmt:
-		if n.Init != nil {
-			initNode := marshalAST(n.Init, visited)
-			if initNode != nil {
-				astNode.Children = append(astNode.Children, initNode)
-			}
-		}
-		if n.Cond != nil {
-			condNode := marshalAST(n.Cond, visited)
-			if condNode != nil {
-				astNode.Children = append(astNode.Children, condNode)
-			}
-		}
-		if n.Post != nil {
-			postNode := marshalAST(n.Post, visited)
-			if postNode != nil {
-				astNode.Children = append(astNode.Children, postNode)
-			}
-		}
-		if n.Body != nil {
-			bodyNode := marshalAST(n.Body, visited)
-			if bodyNode != nil {
-				astNode.Children = append(astNode.Children, bodyNode)
-			}
-		}
-	case *ast.RangeStmt:
-		if n.Key != nil {
-			keyNode := marshalAST(n.Key, visited)
-			if keyNode != nil {
-				astNode.Children = append(astNode.Children, keyNode)
-			}
-		}
-		if n.Value != nil {
-			valueNode := marshalAST(n.Value, visited)
-			if valueNode != nil {
-				astNode.Children = append(astNode.Children, valueNode)
-			}
-		}
-		if n.X != nil {
-			xNode := marshalAST(n.X, visited)
-			if xNode != nil {
-				astNode.Children = append(astNode.Children, xNode)
-			}
-		}
-		if n.Body != nil {
-			bodyNode := marshalAST(n.Body, visited)
-			if bodyNode != nil {
-				astNode.Children = append(astNode.Children, bodyNode)
-			}
-		}
-	case *ast.BlockStmt:
-		for _, stmt := range n.List {
-			stmtNode := marshalAST(stmt, visited)
-			if stmtNode != nil {
-				astNode.Children = append(astNode.Children, stmtNode)
-			}
-		}
-	case *ast.ExprStmt:
-		if n.X != nil {
-			xNode := marshalAST(n.X, visited)
-			if xNode != nil {
-				astNode.Children = append(astNode.Children, xNode)
-			}
-		}
-	case *ast.CallExpr:
-		if n.Fun != nil {
-			funNode := marshalAST(n.Fun, visited)
-			if funNode != nil {
-				astNode.Children = append(astNode.Children, funNode)
-			}
-		}
-		for _, arg := range n.Args {
-			argNode := marshalAST(arg, visited)
-			if argNode != nil {
-				astNode.Children = append(astNode.Children, argNode)
-			}
-		}
-	case *ast.SelectorExpr:
-		if n.X != nil {
-			xNode := marshalAST(n.X, visited)
-			if xNode != nil {
-				astNode.Children = append(astNode.Children, xNode)
-			}
-		}
-		if n.Sel != nil {
-			selNode := marshalAST(n.Sel, visited)
-			if selNode != nil {
-				astNode.Children = append(astNode.Children, selNode)
-			}
-		}
+	if a.ids != nil {
+		astNode.ID = a.ids.id(node)
+	}
+	if decl, ok := node.(ast.Decl); ok && a.owners != nil {
+		astNode.Owner = a.owners.forDecl(decl.Pos())
+	}
+	if stmt, ok := node.(ast.Stmt); ok && a.cover != nil {
+		astNode.Count = a.cover.count(coveragePos(stmt))
+	}
+}
 
-	case *ast.IndexListExpr:
-		if n.X != nil {
-			xNode := marshalAST(n.X, visited)
-			if xNode != nil {
-				astNode.Children = append(astNode.Children, xNode)
-			}
-		}
-		for _, index := range n.Indices {
-			indexNode := marshalAST(index, visited)
-			if indexNode != nil {
-				astNode.Children = append(astNode.Children, indexNode)
-			}
-		}
-	case *ast.IndexExpr:
-		if n.X != nil {
-			xNode := marshalAST(n.X, visited)
-			if xNode != nil {
-				astNode.Children = append(astNode.Children, xNode)
-			}
-		}
-		if n.Index != nil {
-			indexNode := marshalAST(n.Index, visited)
-			if indexNode != nil {
-				astNode.Children = append(astNode.Children, indexNode)
-			}
-		}
-	case *ast.SliceExpr:
-		if n.X != nil {
-			xNode := marshalAST(n.X, visited)
-			if xNode != nil {
-				astNode.Children = append(astNode.Children, xNode)
-			}
-		}
-		if n.Low != nil {
-			lowNode := marshalAST(n.Low, visited)
-			if lowNode != nil {
-				astNode.Children = append(astNode.Children, lowNode)
-			}
-		}
-		if n.High != nil {
-			highNode := marshalAST(n.High, visited)
-			if highNode != nil {
-				astNode.Children = append(astNode.Children, highNode)
-			}
-		}
-		if n.Max != nil {
-			maxNode := marshalAST(n.Max, visited)
-			if maxNode != nil {
-				astNode.Children = append(astNode.Children, maxNode)
-			}
-		}
-	case *ast.StructType:
-		if n.Fields != nil {
-			fieldsNode := marshalAST(n.Fields, visited)
-			if fieldsNode != nil {
-				astNode.Children = append(astNode.Children, fieldsNode)
-			}
-		}
-	case *ast.FuncType:
-		if n.Params != nil {
-			paramsNode := marshalAST(n.Params, visited)
-			if paramsNode != nil {
-				astNode.Children = append(astNode.Children, paramsNode)
-			}
-		}
-		if n.Results != nil {
-			resultsNode := marshalAST(n.Results, visited)
-			if resultsNode != nil {
-				astNode.Children = append(astNode.Children, resultsNode)
-			}
-		}
-	case *ast.InterfaceType:
-		if n.Methods != nil {
-			methodsNode := marshalAST(n.Methods, visited)
-			if methodsNode != nil {
-				astNode.Children = append(astNode.Children, methodsNode)
-			}
-		}
-	case *ast.ArrayType:
-		if n.Elt != nil {
-			eltNode := marshalAST(n.Elt, visited)
-			if eltNode != nil {
-				astNode.Children = append(astNode.Children, eltNode)
-			}
+// processFile processes a single Go source file and outputs its AST in JSON format.
+// Output goes next to the source file unless -out targets are given, in which case
+// it is written to each target under the file's path relative to root.
+func processFile(root, sourceFilePath string) error {
+	if *mergePackages {
+		astNode, c, err := convertFileSyntax(sourceFilePath)
+		if err != nil {
+			return err
 		}
+		return packageDocuments.add(root, sourceFilePath, astNode, c.fset, c.file)
+	}
+	if streamOutput {
+		return processStreamedFile(sourceFilePath)
+	}
+	if overBudget, err := exceedsMemoryBudget(sourceFilePath); err != nil {
+		return err
+	} else if overBudget {
+		return processFileWithinBudget(root, sourceFilePath)
+	}
+	if *cacheDir != "" {
+		return processCachedFile(root, sourceFilePath)
+	}
+	if large, err := isLargeFile(sourceFilePath); err != nil {
+		return err
+	} else if large {
+		return processChunkedFile(root, sourceFilePath)
+	}
 
-	case *ast.SelectStmt:
-		if n.Body != nil {
-			bodyNode := marshalAST(n.Body, visited)
-			if bodyNode != nil {
-				astNode.Children = append(astNode.Children, bodyNode)
-			}
-		}
-	case *ast.CompositeLit:
-		if n.Type != nil {
-			typeNode := marshalAST(n.Type, visited)
-			if typeNode != nil {
-				astNode.Children = append(astNode.Children, typeNode)
-			}
-		}
-		for _, elt := range n.Elts {
-			eltNode := marshalAST(elt, visited)
-			if eltNode != nil {
-				astNode.Children = append(astNode.Children, eltNode)
-			}
-		}
-	case *ast.ParenExpr:
-		if n.X != nil {
-			xNode := marshalAST(n.X, visited)
-			if xNode != nil {
-				astNode.Children = append(astNode.Children, xNode)
-			}
-		}
-	case *ast.TypeAssertExpr:
-		if n.X != nil {
-			xNode := marshalAST(n.X, visited)
-			if xNode != nil {
-				astNode.Children = append(astNode.Children, xNode)
-			}
-		}
-		if n.Type != nil {
-			typeNode := marshalAST(n.Type, visited)
-			if typeNode != nil {
-				astNode.Children = append(astNode.Children, typeNode)
-			}
-		}
+	astNode, err := convertFile(sourceFilePath)
+	if err != nil {
+		return err
+	}
+	return writeDocument(root, sourceFilePath, astNode)
+}
 
-	case *ast.BadDecl:
-		// No specific handling required for BadDecl
-	case *ast.BadExpr:
-		// No specific handling required for BadExpr
-	case *ast.FuncLit:
-		if n.Type != nil {
-			typeNode := marshalAST(n.Type, visited)
-			if typeNode != nil {
-				astNode.Children = append(astNode.Children, typeNode)
-			}
-		}
-		if n.Body != nil {
-			bodyNode := marshalAST(n.Body, visited)
-			if bodyNode != nil {
-				astNode.Children = append(astNode.Children, bodyNode)
-			}
-		}
-	case *ast.StarExpr:
-		if n.X != nil {
-			xNode := marshalAST(n.X, visited)
-			if xNode != nil {
-				astNode.Children = append(astNode.Children, xNode)
-			}
-		}
-	case *ast.UnaryExpr:
-		if n.X != nil {
-			xNode := marshalAST(n.X, visited)
-			if xNode != nil {
-				astNode.Children = append(astNode.Children, xNode)
-			}
-		}
-	case *ast.BinaryExpr:
-		if n.X != nil {
-			xNode := marshalAST(n.X, visited)
-			if xNode != nil {
-				astNode.Children = append(astNode.Children, xNode)
-			}
-		}
-		if n.Y != nil {
-			yNode := marshalAST(n.Y, visited)
-			if yNode != nil {
-				astNode.Children = append(astNode.Children, yNode)
-			}
-		}
-	case *ast.KeyValueExpr:
-		if n.Key != nil {
-			keyNode := marshalAST(n.Key, visited)
-			if keyNode != nil {
-				astNode.Children = append(astNode.Children, keyNode)
-			}
-		}
-		if n.Value != nil {
-			valueNode := marshalAST(n.Value, visited)
-			if valueNode != nil {
-				astNode.Children = append(astNode.Children, valueNode)
-			}
-		}
-	case *ast.BadStmt:
-		// No specific handling required for BadStmt
-	case *ast.DeclStmt:
-		if n.Decl != nil {
-			declNode := marshalAST(n.Decl, visited)
-			if declNode != nil {
-				astNode.Children = append(astNode.Children, declNode)
-			}
-		}
-	case *ast.EmptyStmt:
-		// No specific handling required for EmptyStmt
-	case *ast.LabeledStmt:
-		if n.Label != nil {
-			labelNode := marshalAST(n.Label, visited)
-			if labelNode != nil {
-				astNode.Children = append(astNode.Children, labelNode)
-			}
-		}
-		if n.Stmt != nil {
-			stmtNode := marshalAST(n.Stmt, visited)
-			if stmtNode != nil {
-				astNode.Children = append(astNode.Children, stmtNode)
-			}
-		}
-	case *ast.SendStmt:
-		if n.Chan != nil {
-			chanNode := marshalAST(n.Chan, visited)
-			if chanNode != nil {
-				astNode.Children = append(astNode.Children, chanNode)
-			}
-		}
-		if n.Value != nil {
-			valueNode := marshalAST(n.Value, visited)
-			if valueNode != nil {
-				astNode.Children = append(astNode.Children, valueNode)
-			}
-		}
-	case *ast.IncDecStmt:
-		if n.X != nil {
-			xNode := marshalAST(n.X, visited)
-			if xNode != nil {
-				astNode.Children = append(astNode.Children, xNode)
-			}
-		}
-	case *ast.GoStmt:
-		if n.Call != nil {
-			callNode := marshalAST(n.Call, visited)
-			if callNode != nil {
-				astNode.Children = append(astNode.Children, callNode)
-			}
-		}
-	case *ast.DeferStmt:
-		if n.Call != nil {
-			callNode := marshalAST(n.Call, visited)
-			if callNode != nil {
-				astNode.Children = append(astNode.Children, callNode)
-			}
-		}
-	case *ast.CaseClause:
-		for _, expr := range n.List {
-			exprNode := marshalAST(expr, visited)
-			if exprNode != nil {
-				astNode.Children = append(astNode.Children, exprNode)
-			}
-		}
-		for _, stmt := range n.Body {
-			stmtNode := marshalAST(stmt, visited)
-			if stmtNode != nil {
-				astNode.Children = append(astNode.Children, stmtNode)
-			}
+// writeDocument writes the document of a source file to the -out targets, or
+// next to the source file if there are none.
+func writeDocument(root, sourceFilePath string, astNode *astjson.ASTNode) error {
+	if len(outTargets) > 0 {
+		return outTargets.write(root, sourceFilePath, astNode)
+	}
+
+	// Generate the output file path from the output name template.
+	newBaseName, err := outputName(sourceFilePath)
+	if err != nil {
+		return err
+	}
+	newFilePath := filepath.Join(filepath.Dir(sourceFilePath), newBaseName)
+
+	err = writeAST(astNode, newFilePath)
+	if err != nil {
+		return fmt.Errorf("error serializing AST to JSON for file %s: %w", sourceFilePath, err)
+	}
+
+	slog.Info("AST generated", "file", sourceFilePath, "output", newFilePath)
+	return nil
+}
+
+// outputNameData is the data available to the -out-name template.
+type outputNameData struct {
+	Name string // source file name, e.g. foo.go
+	Base string // source file name without extension, e.g. foo
+	Ext  string // source file extension, e.g. .go
+}
+
+// outputNameTemplate generates the output file name for a source file.
+var outputNameTemplate = template.Must(template.New("out-name").Parse("{{.Base}}.json"))
+
+// outputName returns the name of the output file for a source file.
+func outputName(sourceFilePath string) (string, error) {
+	name := filepath.Base(sourceFilePath)
+	ext := filepath.Ext(name)
+	data := outputNameData{Name: name, Base: strings.TrimSuffix(name, ext), Ext: ext}
+
+	var b strings.Builder
+	if err := outputNameTemplate.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("error generating output name for %s: %w", sourceFilePath, err)
+	}
+	if b.Len() == 0 {
+		return "", fmt.Errorf("output name template produced an empty name for %s", sourceFilePath)
+	}
+	return b.String(), nil
+}
+
+// convertFile parses a single Go source file and converts its AST into an ASTNode tree.
+func convertFile(sourceFilePath string) (*astjson.ASTNode, error) {
+	astNode, _, err := convertFileSyntax(sourceFilePath)
+	return astNode, err
+}
+
+// convertFileSyntax is like convertFile, but also returns the parsed file the
+// ASTNode tree was converted from.
+func convertFileSyntax(sourceFilePath string) (*astjson.ASTNode, *fileConversion, error) {
+	return convertFileChunks(sourceFilePath, nil)
+}
+
+// convertFileChunks is like convertFileSyntax, but if chunks is not nil, it
+// hands the top-level declarations to chunks as they are converted and leaves
+// them out of the returned tree.
+func convertFileChunks(sourceFilePath string, chunks *chunkWriter) (*astjson.ASTNode, *fileConversion, error) {
+	c, err := prepareFile(sourceFilePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	var astNode *astjson.ASTNode
+	if chunks != nil {
+		chunks.start(c.fset, c.file)
+		if astNode, err = c.m.MarshalChunks(c.file, chunks.emit); err != nil {
+			return nil, nil, err
 		}
+	} else {
+		astNode = c.m.Marshal(c.file)
+	}
+	if sidecarAnnotations != nil {
+		sidecarAnnotations.merge(astNode)
+	}
+	c.head(astNode)
+	// Merged package documents share a single kind table, built once the package is complete.
+	if *stringTable && !*mergePackages {
+		internKinds(astNode)
+	}
+	return astNode, c, nil
+}
 
-	case *ast.CommentGroup:
-		for _, comment := range n.List {
-			commentNode := marshalAST(comment, visited)
-			if commentNode != nil {
-				astNode.Children = append(astNode.Children, commentNode)
-			}
+// fileConversion is a source file parsed and ready to be converted by m. head
+// fills in the fields of the root node of its document.
+type fileConversion struct {
+	fset *token.FileSet
+	file *ast.File
+	m    *astjson.Marshaler
+	head func(astNode *astjson.ASTNode)
+}
+
+// prepareFile parses a source file, records it with the run-wide reports and
+// sets up its conversion.
+func prepareFile(sourceFilePath string) (*fileConversion, error) {
+	// The time limit of -untrusted covers parsing and type checking too.
+	deadline := untrustedDeadline()
+	parsed, err := runUntil(sourceFilePath, deadline, func() (*fileConversion, error) {
+		fset, file, err := parseFile(sourceFilePath)
+		return &fileConversion{fset: fset, file: file}, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	fset, file := parsed.fset, parsed.file
+	if *coverageReport != "" {
+		kindCoverage.record(file)
+	}
+	if *vocabReport != "" {
+		identVocabulary.record(sourceFilePath, file)
+	}
+
+	// In typed mode, use the syntax tree the type checker has seen so
+	// that type information can be looked up by node.
+	var pkg *typedPackage
+	if *typesMode {
+		pkg, err = loadTypedPackage(sourceFilePath, deadline)
+		if err != nil {
+			return nil, err
 		}
-	case *ast.Comment:
-		astNode.Comments = append(astNode.Comments, n.Text)
+		fset, file = pkg.fset, pkg.file(sourceFilePath)
+	}
 
-	case *ast.TypeSwitchStmt:
-		if n.Init != nil {
-			initNode := marshalAST(n.Init, visited)
-			if initNode != nil {
-				astNode.Children = append(astNode.Children, initNode)
-			}
+	src, err := readSource(sourceFilePath)
+	if err != nil {
+		return nil, err
+	}
+	meta, err := astjson.SourceMetadata(sourceFilePath, src, file)
+	if err != nil {
+		return nil, err
+	}
+	header := astjson.NewSourceHeader(sourceHeaderPath(sourceFilePath), src)
+	if *apiReport != "" {
+		if err := apiSurface.record(sourceFilePath, src); err != nil {
+			return nil, err
 		}
-		if n.Assign != nil {
-			assignNode := marshalAST(n.Assign, visited)
-			if assignNode != nil {
-				astNode.Children = append(astNode.Children, assignNode)
-			}
+	}
+	annotator := &nodeAnnotator{path: sourceFilePath, deadline: deadline}
+	if *idsMode {
+		if annotator.ids, err = newNodeIDs(fset, sourceFilePath); err != nil {
+			return nil, err
 		}
-		if n.Body != nil {
-			bodyNode := marshalAST(n.Body, visited)
-			if bodyNode != nil {
-				astNode.Children = append(astNode.Children, bodyNode)
-			}
+	}
+	if coverProfile != nil {
+		annotator.cover = coverProfile.forFile(fset, sourceFilePath, meta)
+	}
+	if *ownersMode {
+		if annotator.owners, err = newFileOwnership(fset, sourceFilePath); err != nil {
+			return nil, err
 		}
-	case *ast.CommClause:
-		if n.Comm != nil {
-			commNode := marshalAST(n.Comm, visited)
-			if commNode != nil {
-				astNode.Children = append(astNode.Children, commNode)
-			}
+	}
+	if *deadReport != "" {
+		deadDecls.record(sourceFilePath, fset, pkg, file, meta)
+	}
+	if *concurrencyReport != "" {
+		concurrencyInventory.record(sourceFilePath, fset, pkg.typesInfo(), annotator.ids, file)
+	}
+	m := newMarshaler(fset, pkg.typesInfo())
+	m.Annotate = annotator.annotate
+	head := func(astNode *astjson.ASTNode) {
+		astNode.Source, astNode.Meta = header, meta
+		if *unresolved {
+			fileReports(astNode).Unresolved = unresolvedIdents(file)
 		}
-		for _, stmt := range n.Body {
-			stmtNode := marshalAST(stmt, visited)
-			if stmtNode != nil {
-				astNode.Children = append(astNode.Children, stmtNode)
-			}
+		if *initReport {
+			fileReports(astNode).Init = initializationReport(fset, pkg.typesInfo(), file)
 		}
-	case *ast.ImportSpec:
-		if n.Name != nil {
-			nameNode := marshalAST(n.Name, visited)
-			if nameNode != nil {
-				astNode.Children = append(astNode.Children, nameNode)
-			}
+		if *instancesReport {
+			fileReports(astNode).Instances = instantiationReport(fset, pkg.typesInfo(), file)
 		}
-		if n.Path != nil {
-			pathNode := marshalAST(n.Path, visited)
-			if pathNode != nil {
-				astNode.Children = append(astNode.Children, pathNode)
-			}
+		if *errorsReport {
+			fileReports(astNode).Errors = errorHandlingReport(fset, pkg.typesInfo(), file)
 		}
-	// case *ast.Package:
-	// 	if n.Name != nil {
-	// 		nameNode := marshalAST(n.Name, visited)
-	// 		if nameNode != nil {
-	// 			astNode.Children = append(astNode.Children, nameNode)
-	// 		}
-	// 	}
-	case *ast.Field:
-		for _, name := range n.Names {
-			nameNode := marshalAST(name, visited)
-			if nameNode != nil {
-				astNode.Children = append(astNode.Children, nameNode)
-			}
+		if *contextReport {
+			fileReports(astNode).Context = contextPropagationReport(fset, pkg.typesInfo(), file)
 		}
-		if n.Type != nil {
-			typeNode := marshalAST(n.Type, visited)
-			if typeNode != nil {
-				astNode.Children = append(astNode.Children, typeNode)
-			}
+		if *structsReport {
+			fileReports(astNode).Structs = structLayoutReport(fset, pkg.typesInfo(), file)
 		}
-	case *ast.FieldList:
-		for _, field := range n.List {
-			fieldNode := marshalAST(field, visited)
-			if fieldNode != nil {
-				astNode.Children = append(astNode.Children, fieldNode)
-			}
+		if *enumsReport {
+			fileReports(astNode).Enums = enumReport(fset, pkg.typesInfo(), file)
 		}
-	case *ast.MapType:
-		if n.Key != nil {
-			keyNode := marshalAST(n.Key, visited)
-			if keyNode != nil {
-				astNode.Children = append(astNode.Children, keyNode)
-			}
+		if *interfacesReport {
+			fileReports(astNode).Interfaces = interfaceReport(fset, pkg.typesInfo(), file)
 		}
-		if n.Value != nil {
-			valueNode := marshalAST(n.Value, visited)
-			if valueNode != nil {
-				astNode.Children = append(astNode.Children, valueNode)
-			}
+		if *directivesReport {
+			fileReports(astNode).Directives = directiveReport(fset, file, src)
 		}
-	case *ast.ChanType:
-		if n.Value != nil {
-			valueNode := marshalAST(n.Value, visited)
-			if valueNode != nil {
-				astNode.Children = append(astNode.Children, valueNode)
-			}
+		if *shadowsReport {
+			fileReports(astNode).Shadows = shadowReport(fset, pkg.typesInfo(), file)
 		}
-	case *ast.BranchStmt:
-		if n.Label != nil {
-			labelNode := marshalAST(n.Label, visited)
-			if labelNode != nil {
-				astNode.Children = append(astNode.Children, labelNode)
-			}
+		if *switchesReport {
+			fileReports(astNode).Switches = switchReport(fset, pkg.typesInfo(), file)
 		}
-	case *ast.SwitchStmt:
-		if n.Init != nil {
-			initNode := marshalAST(n.Init, visited)
-			if initNode != nil {
-				astNode.Children = append(astNode.Children, initNode)
-			}
+		if *externalTypes {
+			fileReports(astNode).Externals = externalTypesReport(file)
 		}
-		if n.Tag != nil {
-			tagNode := marshalAST(n.Tag, visited)
-			if tagNode != nil {
-				astNode.Children = append(astNode.Children, tagNode)
-			}
+		if *methodSets {
+			fileReports(astNode).MethodSets = methodSetReport(fset, pkg.typesInfo(), file)
 		}
-		if n.Body != nil {
-			bodyNode := marshalAST(n.Body, visited)
-			if bodyNode != nil {
-				astNode.Children = append(astNode.Children, bodyNode)
-			}
+		if *numbersReport {
+			fileReports(astNode).Numbers = numberReport(fset, pkg.typesInfo(), file)
 		}
-
-	default:
-		// Panic with an error message if an unexpected node type is encountered.
-		panic(fmt.Sprintf("unsupported AST node type: %T", node))
 	}
+	return &fileConversion{fset: fset, file: file, m: m, head: head}, nil
+}
 
-	// Traverse child nodes and add them to the current node's children.
-	ast.Inspect(node, func(n ast.Node) bool {
-		if n != nil {
-			childNode := marshalAST(n, visited)
-			if childNode != nil {
-				astNode.Children = append(astNode.Children, childNode)
-			}
-		}
-		return true
-	})
-
-	return astNode
+// sourceHeaderPath returns the path of a source file relative to -source-root,
+// or to the current folder if it is not set. Paths that cannot be made relative
+// are kept as given.
+func sourceHeaderPath(sourceFilePath string) string {
+	root := *sourceRoot
+	if root == "" {
+		root = "."
+	}
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return sourceFilePath
+	}
+	absPath, err := filepath.Abs(sourceFilePath)
+	if err != nil {
+		return sourceFilePath
+	}
+	rel, err := filepath.Rel(absRoot, absPath)
+	if err != nil {
+		return sourceFilePath
+	}
+	return rel
 }
 
-// processFile processes a single Go source file and outputs its AST in JSON format.
-func processFile(sourceFilePath string) error {
+// parseFile parses a single Go source file and reports any syntax
+// not permitted by the requested language version.
+func parseFile(sourceFilePath string) (*token.FileSet, *ast.File, error) {
 	// Parse the Go source file and generate the AST.
+	src, err := readSource(sourceFilePath)
+	if err != nil {
+		return nil, nil, err
+	}
 	fset := token.NewFileSet()
-	file, err := parser.ParseFile(fset, sourceFilePath, nil, parser.AllErrors)
+	file, err := parser.ParseFile(fset, sourceFilePath, src, conversionOptions().ParserMode())
 	if err != nil {
-		return fmt.Errorf("error parsing Go source file %s: %w", sourceFilePath, err)
+		return nil, nil, fmt.Errorf("error parsing Go source file %s: %w", sourceFilePath, err)
+	}
+	if err := checkUntrustedTree(sourceFilePath, file); err != nil {
+		return nil, nil, err
 	}
 
-	// Generate the output file path with a .json extension.
-	dir := filepath.Dir(sourceFilePath)
-	base := filepath.Base(sourceFilePath)
-	ext := filepath.Ext(base)
-	baseNameWithoutExt := strings.TrimSuffix(base, ext)
-	newBaseName := baseNameWithoutExt + ".json"
-	newFilePath := filepath.Join(dir, newBaseName)
+	// Report syntax that is not permitted by the requested language version.
+	if *langVersion != "" {
+		for _, diag := range checkLangVersion(fset, file, *langVersion) {
+			slog.Warn("syntax not permitted by language version", "file", sourceFilePath, "lang", *langVersion, "diagnostic", diag)
+		}
+	}
+	return fset, file, nil
+}
 
+// writeAST serializes an ASTNode tree to JSON and writes it to outputPath.
+func writeAST(astNode *astjson.ASTNode, outputPath string) error {
 	// Create the output file for the JSON representation of the AST.
-	outputFile, err := os.Create(newFilePath)
+	outputFile, err := os.Create(outputPath)
 	if err != nil {
-		return fmt.Errorf("error creating output file %s: %w", newFilePath, err)
+		return fmt.Errorf("error creating output file %s: %w", outputPath, err)
 	}
 	defer outputFile.Close()
 
 	// Serialize the AST to JSON and write it to the output file.
+	return encodeAST(outputFile, astNode, conversionOptions().Indent)
+}
+
+// encodeAST serializes a value to JSON on w, indenting nested elements
+// with indent or producing a single compact line if indent is empty.
+// Near the -max-memory budget, documents are streamed compactly instead.
+func encodeAST(w io.Writer, v interface{}, indent string) error {
+	if memoryPressure() {
+		slog.Debug("memory budget nearly exhausted, streaming output")
+		if streamed, err := streamDocument(w, v); streamed {
+			return err
+		}
+	}
 	var json = jsoniter.ConfigCompatibleWithStandardLibrary
-	jsonEncoder := json.NewEncoder(outputFile)
-	jsonEncoder.SetIndent("", "  ")
+	jsonEncoder := json.NewEncoder(w)
+	jsonEncoder.SetIndent("", indent)
+	return jsonEncoder.Encode(v)
+}
+
+// checkLangVersion type-checks file under the given language version and
+// returns the diagnostics caused by version-gated language features.
+// Unrelated type errors (e.g. references to other files of the package) are ignored.
+func checkLangVersion(fset *token.FileSet, file *ast.File, lang string) []string {
+	var diags []string
+	conf := types.Config{
+		GoVersion: lang,
+		Importer:  importer.Default(),
+		Error: func(err error) {
+			if typeErr, ok := err.(types.Error); ok && strings.Contains(typeErr.Msg, "requires go") {
+				diags = append(diags, typeErr.Error())
+			}
+		},
+	}
+	conf.Check(file.Name.Name, fset, []*ast.File{file}, nil)
+	return diags
+}
 
-	visited := make(map[ast.Node]bool)
-	astNode := marshalAST(file, visited)
-	err = jsonEncoder.Encode(astNode)
+// processFolder processes all .go files in the provided folder.
+// A file that fails to convert does not stop the run; all failures are
+// reported in a summary once every file has been visited.
+func processFolder(folderPath string) error {
+	var paths []string
+	err := walkGoFiles(folderPath, func(path string) error {
+		paths = append(paths, path)
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("error serializing AST to JSON for file %s: %w", sourceFilePath, err)
+		return fmt.Errorf("error processing folder %s: %w", folderPath, err)
 	}
+	failures := processFiles(folderPath, paths)
+	total := len(paths)
 
-	fmt.Println("AST generated and saved to " + newFilePath)
+	if len(failures) > 0 {
+		for _, failure := range failures {
+			slog.Error("failed file", "file", failure.path, "error", failure.err)
+		}
+		slog.Error("conversion finished with errors", "failed", len(failures), "total", total)
+		return fmt.Errorf("%d of %d files in %s failed to convert", len(failures), total, folderPath)
+	}
 	return nil
 }
 
-// processFolder processes all .go files in the provided folder.
-func processFolder(folderPath string) error {
-	err := filepath.Walk(folderPath, func(path string, info os.FileInfo, err error) error {
+// fileFailure records a file that failed to convert during a folder run.
+type fileFailure struct {
+	path string
+	err  error
+}
+
+// isolateFile runs fn for a single file, turning a panic into an error so an
+// internal bug triggered by one file cannot crash a whole batch run. The
+// result is recorded for -report.
+func isolateFile(path string, fn func(path string) error) (err error) {
+	start, panicked := time.Now(), false
+	defer func() {
+		conversionResults.record(path, time.Since(start), err, panicked)
+	}()
+	defer func() {
+		if r := recover(); r != nil {
+			if limitErr, ok := r.(*untrustedLimitError); ok {
+				err = limitErr
+				return
+			}
+			panicked = true
+			slog.Debug("recovered panic", "file", path, "stack", string(debug.Stack()))
+			err = fmt.Errorf("internal error while converting %s: %v", path, r)
+		}
+	}()
+	return fn(path)
+}
+
+// walkGoFiles calls fn for every .go file in the provided folder and its subfolders.
+func walkGoFiles(folderPath string, fn func(path string) error) error {
+	return filepath.Walk(folderPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
-		if !info.IsDir() && strings.HasSuffix(info.Name(), ".go") {
-			return processFile(path)
+		if !info.IsDir() && strings.HasSuffix(info.Name(), ".go") && matchesTestsMode(info.Name()) {
+			return fn(path)
 		}
 		return nil
 	})
-	if err != nil {
-		return fmt.Errorf("error processing folder %s: %w", folderPath, err)
+}
+
+// matchesTestsMode reports whether a .go file found in a folder is processed under the -tests setting.
+// External test packages (package x_test) always live in _test.go files, so the file name suffices.
+func matchesTestsMode(name string) bool {
+	isTest := strings.HasSuffix(name, "_test.go")
+	switch *testsMode {
+	case "only":
+		return isTest
+	case "exclude":
+		return !isTest
+	default:
+		return true
 	}
-	return nil
+}
+
+// subcommands maps subcommand names to their implementations, which receive
+// the arguments following the subcommand name.
+var subcommands = map[string]func(args []string) error{}
+
+// parseArgs parses the command line and returns the arguments that are not
+// flags. Flags may follow the paths, as in "go2json file.go -o -", except after
+// a subcommand, which parses its own arguments.
+func parseArgs() []string {
+	flag.Parse()
+	if _, ok := subcommands[flag.Arg(0)]; ok {
+		return flag.Args()
+	}
+	var args []string
+	for flag.NArg() > 0 {
+		args = append(args, flag.Arg(0))
+		flag.CommandLine.Parse(flag.Args()[1:])
+	}
+	return args
 }
 
 func main() {
+	args := parseArgs()
+
+	logger, err := newLogger(*logFormat, *logLevel)
+	if err != nil {
+		fmt.Printf("Error configuring logging: %s\n", err)
+		os.Exit(1)
+	}
+	slog.SetDefault(logger)
+	if maxMemory > 0 {
+		debug.SetMemoryLimit(int64(maxMemory))
+	}
+
+	switch *testsMode {
+	case "include", "exclude", "only":
+	default:
+		slog.Error("invalid -tests value, expected include, exclude or only", "tests", *testsMode)
+		os.Exit(1)
+	}
+
+	if *outExt != "" {
+		*outName = "{{.Base}}" + *outExt
+	}
+	outputNameTemplate, err = template.New("out-name").Parse(*outName)
+	if err != nil {
+		slog.Error("invalid -out-name template", "error", err)
+		os.Exit(1)
+	}
+
+	if *mergePackages {
+		for _, target := range outTargets {
+			if _, ok := target.(*bundleTarget); ok {
+				slog.Error("-packages cannot be combined with bundle output, whose entries are source files")
+				os.Exit(1)
+			}
+		}
+	}
+	if *maxDepth < 0 {
+		slog.Error("-max-depth must not be negative", "max-depth", *maxDepth)
+		os.Exit(1)
+	}
+	if *instancesReport && !*typesMode {
+		slog.Error("-instances requires -types")
+		os.Exit(1)
+	}
+	if *shadowsReport && !*typesMode {
+		slog.Error("-shadows requires -types")
+		os.Exit(1)
+	}
+	if *methodSets && !*typesMode {
+		slog.Error("-method-sets requires -types")
+		os.Exit(1)
+	}
+	if *deadReport != "" && !*typesMode {
+		slog.Error("-dead requires -types")
+		os.Exit(1)
+	}
+	if streamOutput && (len(outTargets) > 0 || *mergePackages || *stringTable || chunkAbove > 0) {
+		slog.Error("-stream writes documents next to their source files and cannot be combined with -out, -o, -packages, -string-table or -chunk-above")
+		os.Exit(1)
+	}
+	if *cacheDir != "" && !cacheSupported() {
+		slog.Error("-cache cannot be combined with -types, -packages, -owners, -annotations, -coverprofile, -chunk-above, -stream or run-wide reports such as -api")
+		os.Exit(1)
+	}
+	if *noPositions && *idsMode {
+		slog.Error("-ids cannot be combined with -no-positions, node IDs are derived from positions")
+		os.Exit(1)
+	}
+	if *annotationsFile != "" {
+		if !*idsMode {
+			slog.Error("-annotations requires -ids")
+			os.Exit(1)
+		}
+		sidecarAnnotations, err = loadAnnotations(*annotationsFile)
+		if err != nil {
+			slog.Error("error loading annotations", "error", err)
+			os.Exit(1)
+		}
+	}
+	if *coverProfilePath != "" {
+		coverProfile, err = loadCoverProfile(*coverProfilePath)
+		if err != nil {
+			slog.Error("error loading coverage profile", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	if cmd, ok := subcommands[flag.Arg(0)]; ok {
+		// Run a subcommand such as "go2json repl" instead of converting a path.
+		err := cmd(flag.Args()[1:])
+		if err != nil {
+			slog.Error("error running "+flag.Arg(0), "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *modcacheOut != "" {
+		// Convert the module cache instead of a user-provided path.
+		err := processModCache(*modcacheOut)
+		if err != nil {
+			slog.Error("error processing module cache", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Ensure a Go source file or folder path is provided as a command-line argument.
-	if len(os.Args) < 2 {
+	if len(args) < 1 {
 		fmt.Println("Please provide the path to the Go source file or folder as a command-line argument.")
 		os.Exit(1)
 	}
 
-	path := os.Args[1]
+	if *langVersion != "" && !version.IsValid(*langVersion) {
+		slog.Error("invalid language version", "lang", *langVersion)
+		os.Exit(1)
+	}
 
-	// Check if the path is a file or a folder.
-	info, err := os.Stat(path)
-	if err != nil {
-		fmt.Printf("Error accessing the path: %s\n", err)
+	path := args[0]
+
+	// Check if the path is a file or a folder; - stands for the source on stdin
+	// and patterns such as ./... for the packages the go command lists.
+	var info os.FileInfo
+	if isRemoteURL(path) {
+		if *deltaDir != "" {
+			slog.Error("-delta cannot be used with remote inputs")
+			os.Exit(1)
+		}
+	} else if isPackagePattern(path) {
+		if *deltaDir != "" {
+			slog.Error("-delta cannot be used with package patterns")
+			os.Exit(1)
+		}
+	} else if path != "-" {
+		info, err = os.Stat(path)
+		if err != nil {
+			slog.Error("error accessing the path", "path", path, "error", err)
+			os.Exit(1)
+		}
+	} else if *deltaDir != "" || *typesMode {
+		slog.Error("-delta and -types cannot be used with source from stdin, they need the source folder")
 		os.Exit(1)
 	}
 
-	if info.IsDir() {
+	if *deltaDir != "" {
+		// Compare declaration fingerprints against the previous run.
+		err = processDelta(path, info.IsDir(), *deltaDir)
+		if err != nil {
+			slog.Error("error computing delta", "path", path, "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if isRemoteURL(path) {
+		// Download or clone the remote input and process it.
+		err = processRemote(path)
+		if err != nil {
+			slog.Error("error processing remote input", "url", path, "error", err)
+		}
+	} else if isPackagePattern(path) {
+		// Process the files of the packages matching the patterns.
+		err = processPackages(args)
+		if err != nil {
+			slog.Error("error processing packages", "patterns", strings.Join(args, " "), "error", err)
+		}
+	} else if path == "-" {
+		// Process the source read from stdin.
+		err = isolateFile(*stdinFilename, func(string) error { return processStdin() })
+		if err != nil {
+			slog.Error("error processing stdin", "filename", *stdinFilename, "error", err)
+		}
+	} else if info.IsDir() && workspaceFile(path) != "" {
+		// Process the modules of the go.work workspace in the folder.
+		err = processWorkspace(path, *workspaceOut)
+		if err != nil {
+			slog.Error("error processing workspace", "path", path, "error", err)
+		}
+	} else if info.IsDir() {
 		// Process all .go files in the folder.
 		err = processFolder(path)
 		if err != nil {
-			fmt.Printf("Error processing folder: %s\n", err)
-			os.Exit(1)
+			slog.Error("error processing folder", "path", path, "error", err)
 		}
 	} else {
 		// Process the single file.
-		err = processFile(path)
+		err = isolateFile(path, func(path string) error { return processFile(filepath.Dir(path), path) })
 		if err != nil {
-			fmt.Printf("Error processing file: %s\n", err)
-			os.Exit(1)
+			slog.Error("error processing file", "path", path, "error", err)
+		}
+	}
 
+	// Outputs and reports are finalized even if some files failed.
+	if *mergePackages {
+		if flushErr := packageDocuments.flush(nil); flushErr != nil {
+			slog.Error("error writing package documents", "error", flushErr)
+			err = flushErr
+		}
+	}
+	sidecarAnnotations.logUnmatched()
+	if closeErr := outTargets.close(); closeErr != nil {
+		slog.Error("error closing output", "error", closeErr)
+		err = closeErr
+	}
+	if *coverageReport != "" {
+		if reportErr := kindCoverage.write(*coverageReport); reportErr != nil {
+			slog.Error("error writing coverage report", "error", reportErr)
+			err = reportErr
+		}
+	}
+	if *vocabReport != "" {
+		if reportErr := identVocabulary.write(*vocabReport); reportErr != nil {
+			slog.Error("error writing vocabulary report", "error", reportErr)
+			err = reportErr
 		}
 	}
+	if *concurrencyReport != "" {
+		if reportErr := concurrencyInventory.write(*concurrencyReport); reportErr != nil {
+			slog.Error("error writing concurrency report", "error", reportErr)
+			err = reportErr
+		}
+	}
+	if *apiReport != "" {
+		if reportErr := apiSurface.write(*apiReport); reportErr != nil {
+			slog.Error("error writing API report", "error", reportErr)
+			err = reportErr
+		}
+	}
+	if *deadReport != "" {
+		if reportErr := deadDecls.write(*deadReport); reportErr != nil {
+			slog.Error("error writing dead declaration report", "error", reportErr)
+			err = reportErr
+		}
+	}
+	if conversionResults != nil {
+		if reportErr := conversionResults.write(); reportErr != nil {
+			slog.Error("error writing JUnit report", "error", reportErr)
+			err = reportErr
+		}
+	}
+	if err != nil {
+		os.Exit(1)
+	}
 }
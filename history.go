@@ -0,0 +1,383 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	subcommands["history"] = runHistory
+}
+
+const historyUsage = `usage:
+  go2json history [-since REF] [-commits] [-o FILE] [REPO]`
+
+// HistoryReport lists code metrics for a sequence of snapshots of a repository.
+type HistoryReport struct {
+	Snapshots []*Snapshot `json:"snapshots"`
+}
+
+// Snapshot holds the metrics of the Go files of one revision. Complexity is the
+// sum of the cyclomatic complexities of all functions; APISurface counts the
+// exported package-level declarations and the exported methods of exported types.
+type Snapshot struct {
+	Ref        string `json:"ref"`
+	Commit     string `json:"commit"`
+	Date       string `json:"date"`
+	Files      int    `json:"files"`
+	Functions  int    `json:"functions"`
+	Methods    int    `json:"methods"`
+	Complexity int    `json:"complexity"`
+	APISurface int    `json:"api_surface"`
+}
+
+// fileMetrics are the metrics of one file version, cached by blob hash since most
+// files are unchanged between snapshots.
+type fileMetrics struct {
+	functions, methods, complexity, apiSurface int
+}
+
+// runHistory converts the tagged releases (or every commit) of a git repository
+// since a revision and reports how the code metrics evolved.
+func runHistory(args []string) error {
+	flags := flag.NewFlagSet("history", flag.ContinueOnError)
+	since := flags.String("since", "", "first revision to include; defaults to the whole history")
+	commits := flags.Bool("commits", false, "report every first-parent commit instead of tags")
+	outPath := flags.String("o", "", "write the report to FILE instead of standard output")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 1 {
+		fmt.Fprintln(os.Stderr, historyUsage)
+		return errors.New("expected at most one repository")
+	}
+	repo := "."
+	if flags.NArg() == 1 {
+		repo = flags.Arg(0)
+	}
+
+	var refs []string
+	var err error
+	if *commits {
+		refs, err = historyCommits(repo, *since)
+	} else {
+		refs, err = historyTags(repo, *since)
+	}
+	if err != nil {
+		return err
+	}
+
+	blobs, err := newBlobReader(repo)
+	if err != nil {
+		return err
+	}
+	defer blobs.close()
+
+	cache := make(map[string]*fileMetrics)
+	report := &HistoryReport{Snapshots: []*Snapshot{}}
+	for _, ref := range refs {
+		snapshot, err := snapshotMetrics(repo, ref, blobs, cache)
+		if err != nil {
+			return err
+		}
+		slog.Info("snapshot converted", "ref", ref, "files", snapshot.Files)
+		report.Snapshots = append(report.Snapshots, snapshot)
+	}
+
+	if *outPath == "" {
+		return encodeAST(os.Stdout, report, "  ")
+	}
+	outputFile, err := os.Create(*outPath)
+	if err != nil {
+		return fmt.Errorf("error creating history report %s: %w", *outPath, err)
+	}
+	defer outputFile.Close()
+	return encodeAST(outputFile, report, "  ")
+}
+
+// git runs a git command in repo and returns its trimmed output.
+func git(repo string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", repo}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("error running git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// historyTags returns the tags descending from since, oldest first, followed by
+// HEAD unless it is tagged already.
+func historyTags(repo, since string) ([]string, error) {
+	out, err := git(repo, "for-each-ref", "--sort=creatordate", "--format=%(refname:short)", "refs/tags")
+	if err != nil {
+		return nil, err
+	}
+	head, err := git(repo, "rev-parse", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	var refs []string
+	headTagged := false
+	for _, tag := range strings.Fields(out) {
+		if since != "" {
+			if _, err := git(repo, "merge-base", "--is-ancestor", since, tag); err != nil {
+				continue
+			}
+		}
+		commit, err := git(repo, "rev-parse", tag+"^{commit}")
+		if err != nil {
+			return nil, err
+		}
+		headTagged = headTagged || commit == head
+		refs = append(refs, tag)
+	}
+	if !headTagged {
+		refs = append(refs, "HEAD")
+	}
+	return refs, nil
+}
+
+// historyCommits returns the first-parent commits from since to HEAD, oldest first.
+func historyCommits(repo, since string) ([]string, error) {
+	args := []string{"rev-list", "--reverse", "--first-parent", "HEAD"}
+	if since != "" {
+		args = append(args, "^"+since)
+	}
+	out, err := git(repo, args...)
+	if err != nil {
+		return nil, err
+	}
+	refs := strings.Fields(out)
+	if since != "" {
+		commit, err := git(repo, "rev-parse", since+"^{commit}")
+		if err != nil {
+			return nil, err
+		}
+		refs = append([]string{commit}, refs...)
+	}
+	return refs, nil
+}
+
+// snapshotMetrics sums the metrics of the Go files of ref, skipping vendored code
+// and testdata as the go command does.
+func snapshotMetrics(repo, ref string, blobs *blobReader, cache map[string]*fileMetrics) (*Snapshot, error) {
+	info, err := git(repo, "log", "-1", "--format=%H %cI", ref)
+	if err != nil {
+		return nil, err
+	}
+	commit, date, _ := strings.Cut(info, " ")
+	snapshot := &Snapshot{Ref: ref, Commit: commit, Date: date}
+
+	tree, err := git(repo, "ls-tree", "-r", "-z", commit)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range strings.Split(tree, "\x00") {
+		// <mode> SP <type> SP <object> TAB <file>
+		header, file, ok := strings.Cut(entry, "\t")
+		fields := strings.Fields(header)
+		if !ok || len(fields) != 3 || fields[1] != "blob" || !isHistorySource(file) {
+			continue
+		}
+		blob := fields[2]
+		metrics, ok := cache[blob]
+		if !ok {
+			src, err := blobs.read(blob)
+			if err != nil {
+				return nil, err
+			}
+			metrics = measureFile(file, src)
+			cache[blob] = metrics
+		}
+		snapshot.Files++
+		snapshot.Functions += metrics.functions
+		snapshot.Methods += metrics.methods
+		snapshot.Complexity += metrics.complexity
+		snapshot.APISurface += metrics.apiSurface
+	}
+	return snapshot, nil
+}
+
+// isHistorySource reports whether a file of a snapshot is measured.
+func isHistorySource(file string) bool {
+	if !strings.HasSuffix(file, ".go") || !matchesTestsMode(path.Base(file)) {
+		return false
+	}
+	dir := path.Dir(file)
+	if dir == "." {
+		return true
+	}
+	for _, dir := range strings.Split(dir, "/") {
+		if dir == "vendor" || dir == "testdata" || strings.HasPrefix(dir, "_") || strings.HasPrefix(dir, ".") {
+			return false
+		}
+	}
+	return true
+}
+
+// measureFile computes the metrics of a file. Files that do not parse count as
+// empty, since old revisions may contain code that no longer compiles.
+func measureFile(name string, src []byte) *fileMetrics {
+	metrics := &fileMetrics{}
+	file, err := parser.ParseFile(token.NewFileSet(), name, src, parser.SkipObjectResolution)
+	if err != nil {
+		slog.Debug("skipping unparsable file", "file", name, "error", err)
+		return metrics
+	}
+	exportedTypes := make(map[string]bool)
+	for _, decl := range file.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok {
+			for _, spec := range gen.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					if s.Name.IsExported() {
+						exportedTypes[s.Name.Name] = true
+						metrics.apiSurface++
+					}
+				case *ast.ValueSpec:
+					for _, name := range s.Names {
+						if name.IsExported() {
+							metrics.apiSurface++
+						}
+					}
+				}
+			}
+		}
+	}
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		if fn.Recv == nil {
+			metrics.functions++
+			if fn.Name.IsExported() {
+				metrics.apiSurface++
+			}
+		} else {
+			metrics.methods++
+			if fn.Name.IsExported() && exportedTypes[receiverTypeName(fn.Recv)] {
+				metrics.apiSurface++
+			}
+		}
+		metrics.complexity += cyclomaticComplexity(fn)
+	}
+	return metrics
+}
+
+// receiverTypeName returns the name of the type of a method receiver.
+func receiverTypeName(recv *ast.FieldList) string {
+	if recv == nil || len(recv.List) == 0 {
+		return ""
+	}
+	expr := recv.List[0].Type
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.Ident:
+			return e.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// cyclomaticComplexity returns 1 plus the number of decision points of a function.
+func cyclomaticComplexity(fn *ast.FuncDecl) int {
+	complexity := 1
+	ast.Inspect(fn, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt:
+			complexity++
+		case *ast.CaseClause:
+			if n.List != nil {
+				complexity++
+			}
+		case *ast.CommClause:
+			if n.Comm != nil {
+				complexity++
+			}
+		case *ast.BinaryExpr:
+			if n.Op == token.LAND || n.Op == token.LOR {
+				complexity++
+			}
+		}
+		return true
+	})
+	return complexity
+}
+
+// blobReader reads blobs through a single git cat-file --batch process.
+type blobReader struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	out   *bufio.Reader
+}
+
+func newBlobReader(repo string) (*blobReader, error) {
+	cmd := exec.Command("git", "-C", repo, "cat-file", "--batch")
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("error starting git cat-file: %w", err)
+	}
+	return &blobReader{cmd: cmd, stdin: stdin, out: bufio.NewReader(stdout)}, nil
+}
+
+// read returns the content of a blob.
+func (r *blobReader) read(blob string) ([]byte, error) {
+	if _, err := fmt.Fprintln(r.stdin, blob); err != nil {
+		return nil, fmt.Errorf("error requesting blob %s: %w", blob, err)
+	}
+	// <object> SP <type> SP <size> LF <contents> LF
+	header, err := r.out.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("error reading blob %s: %w", blob, err)
+	}
+	fields := strings.Fields(header)
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("error reading blob %s: %s", blob, strings.TrimSpace(header))
+	}
+	size, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return nil, fmt.Errorf("error reading blob %s: bad size %q", blob, fields[2])
+	}
+	content := make([]byte, size+1)
+	if _, err := io.ReadFull(r.out, content); err != nil {
+		return nil, fmt.Errorf("error reading blob %s: %w", blob, err)
+	}
+	return content[:size], nil
+}
+
+func (r *blobReader) close() error {
+	r.stdin.Close()
+	return r.cmd.Wait()
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"go/ast"
+	"go/token"
+)
+
+// nodeIDs derives stable node IDs from the content hash of a source file and
+// the extent and kind of each node. IDs only change when the file does, so they
+// can be used to refer to nodes across output formats and runs.
+type nodeIDs struct {
+	fset     *token.FileSet
+	fileHash [sha256.Size]byte
+}
+
+// newNodeIDs hashes the source file whose nodes are positioned in fset.
+func newNodeIDs(fset *token.FileSet, sourceFilePath string) (*nodeIDs, error) {
+	src, err := readSource(sourceFilePath)
+	if err != nil {
+		return nil, err
+	}
+	return &nodeIDs{fset: fset, fileHash: sha256.Sum256(src)}, nil
+}
+
+// id returns the ID of node: the first 16 hex digits of the SHA-256 of the file
+// hash, the node's start and end offsets and its kind.
+func (ids *nodeIDs) id(node ast.Node) string {
+	h := sha256.New()
+	h.Write(ids.fileHash[:])
+	var offsets [16]byte
+	binary.BigEndian.PutUint64(offsets[:8], uint64(ids.offset(node.Pos())))
+	binary.BigEndian.PutUint64(offsets[8:], uint64(ids.offset(node.End())))
+	h.Write(offsets[:])
+	fmt.Fprintf(h, "%T", node)
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+// offset returns the byte offset of pos in its file, or -1 for nodes without a position.
+func (ids *nodeIDs) offset(pos token.Pos) int {
+	if !pos.IsValid() {
+		return -1
+	}
+	return ids.fset.Position(pos).Offset
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"github.com/kobi2187/go2json/astjson"
+)
+
+// interfaceReport lists the interface type declarations of file, including
+// local ones, in source order.
+func interfaceReport(fset *token.FileSet, info *types.Info, file *ast.File) []*astjson.Interface {
+	interfaces := []*astjson.Interface{}
+	ast.Inspect(file, func(node ast.Node) bool {
+		spec, ok := node.(*ast.TypeSpec)
+		if !ok {
+			return true
+		}
+		ifaceType, ok := spec.Type.(*ast.InterfaceType)
+		if !ok {
+			return true
+		}
+		iface := &astjson.Interface{
+			Name:    spec.Name.Name,
+			Line:    sourcePosition(fset, spec.Pos()).Line,
+			Methods: []*astjson.InterfaceMethod{},
+		}
+		iface.Start, iface.End = sourceRange(fset, spec)
+		if spec.TypeParams != nil {
+			for _, field := range spec.TypeParams.List {
+				for _, name := range field.Names {
+					iface.TypeParams = append(iface.TypeParams, name.Name)
+				}
+			}
+		}
+		for _, field := range ifaceType.Methods.List {
+			if len(field.Names) == 0 {
+				iface.Embedded = append(iface.Embedded, types.ExprString(field.Type))
+			}
+		}
+
+		if typed, qualifier := typedInterface(info, spec.Name); typed != nil {
+			iface.Methods = typedMethods(typed, qualifier)
+		} else {
+			for _, field := range ifaceType.Methods.List {
+				funcType, ok := field.Type.(*ast.FuncType)
+				if !ok || len(field.Names) == 0 {
+					continue
+				}
+				iface.Methods = append(iface.Methods, &astjson.InterfaceMethod{
+					Name:    field.Names[0].Name,
+					Params:  nonNilParams(astjson.ParamList(funcType.Params)),
+					Results: nonNilParams(astjson.ParamList(funcType.Results)),
+				})
+			}
+		}
+		interfaces = append(interfaces, iface)
+		return true
+	})
+	return interfaces
+}
+
+// typedInterface returns the interface type declared by name, or nil without
+// type information, and a qualifier printing the types of the declaring
+// package unqualified and those of other packages by their import path.
+func typedInterface(info *types.Info, name *ast.Ident) (*types.Interface, types.Qualifier) {
+	if info == nil {
+		return nil, nil
+	}
+	obj := info.Defs[name]
+	if obj == nil {
+		return nil, nil
+	}
+	iface, _ := obj.Type().Underlying().(*types.Interface)
+	return iface, types.RelativeTo(obj.Pkg())
+}
+
+// typedMethods lists the complete method set of an interface in the order of
+// go/types, which sorts methods by name.
+func typedMethods(iface *types.Interface, qualifier types.Qualifier) []*astjson.InterfaceMethod {
+	explicit := make(map[*types.Func]bool)
+	for i := 0; i < iface.NumExplicitMethods(); i++ {
+		explicit[iface.ExplicitMethod(i)] = true
+	}
+	methods := []*astjson.InterfaceMethod{}
+	for i := 0; i < iface.NumMethods(); i++ {
+		fn := iface.Method(i)
+		sig := fn.Type().(*types.Signature)
+		method := &astjson.InterfaceMethod{
+			Name:    fn.Name(),
+			Params:  tupleParams(sig.Params(), sig.Variadic(), qualifier),
+			Results: tupleParams(sig.Results(), false, qualifier),
+		}
+		if !explicit[fn] {
+			method.From = embeddingInterface(iface, fn.Name(), qualifier)
+		}
+		methods = append(methods, method)
+	}
+	return methods
+}
+
+// embeddingInterface returns the embedded type of iface whose method set has a
+// method with the given name.
+func embeddingInterface(iface *types.Interface, name string, qualifier types.Qualifier) string {
+	for i := 0; i < iface.NumEmbeddeds(); i++ {
+		embedded := iface.EmbeddedType(i)
+		if inner, ok := embedded.Underlying().(*types.Interface); ok {
+			for j := 0; j < inner.NumMethods(); j++ {
+				if inner.Method(j).Name() == name {
+					return types.TypeString(embedded, qualifier)
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// tupleParams converts a parameter or result tuple into Params, one group per
+// variable. The last parameter of a variadic signature is listed with its
+// element type.
+func tupleParams(tuple *types.Tuple, variadic bool, qualifier types.Qualifier) []*astjson.Param {
+	params := []*astjson.Param{}
+	for i := 0; i < tuple.Len(); i++ {
+		v := tuple.At(i)
+		param := &astjson.Param{Name: v.Name(), Type: types.TypeString(v.Type(), qualifier), Group: i}
+		if variadic && i == tuple.Len()-1 {
+			if slice, ok := v.Type().(*types.Slice); ok {
+				param.Type, param.Variadic = types.TypeString(slice.Elem(), qualifier), true
+			}
+		}
+		params = append(params, param)
+	}
+	return params
+}
+
+// nonNilParams returns params, or an empty list if it is nil.
+func nonNilParams(params []*astjson.Param) []*astjson.Param {
+	if params == nil {
+		return []*astjson.Param{}
+	}
+	return params
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"runtime"
+	"sync"
+)
+
+var jobs = flag.Int("jobs", runtime.NumCPU(), "number of files converted concurrently in folder and package runs; runs with -packages or a run-wide report such as -api convert one file at a time")
+
+// outputMu serializes the writes to the -out targets, which share streams and
+// indexes between files.
+var outputMu sync.Mutex
+
+// conversionJobs returns the number of files to convert concurrently. Merged
+// packages and run-wide reports gather state from every file, so they convert
+// one file at a time.
+func conversionJobs() int {
+	if *mergePackages || *coverageReport != "" || *vocabReport != "" || *concurrencyReport != "" || *apiReport != "" || *deadReport != "" {
+		return 1
+	}
+	return max(*jobs, 1)
+}
+
+// processFiles processes the files at paths, writing their output relative to
+// root, with up to conversionJobs files at a time. A file that fails does not
+// stop the others; the failures are returned in the order of paths, for the
+// caller to report once every file has been visited.
+func processFiles(root string, paths []string) []fileFailure {
+	errs := make([]error, len(paths))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(conversionJobs(), len(paths)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				errs[i] = isolateFile(paths[i], func(path string) error { return processFile(root, path) })
+			}
+		}()
+	}
+	for i := range paths {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	var failures []fileFailure
+	for i, err := range errs {
+		if err != nil {
+			failures = append(failures, fileFailure{path: paths[i], err: err})
+		}
+	}
+	return failures
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/xml"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// reportFormatJUnit is the format of -report, the only one so far.
+const reportFormatJUnit = "junit"
+
+// defaultJUnitPath is the file -report junit writes to if none is given.
+const defaultJUnitPath = "go2json-junit.xml"
+
+// conversionResults collects the result of every file converted in the run
+// for -report, or is nil without it.
+var conversionResults *resultCollector
+
+func init() {
+	flag.Func("report", "write a summary of the per-file conversion results as FORMAT[:FILE], where FORMAT is junit for JUnit XML (default file "+defaultJUnitPath+")", func(value string) error {
+		format, path, _ := strings.Cut(value, ":")
+		if format != reportFormatJUnit {
+			return fmt.Errorf("unknown report format %q", format)
+		}
+		if path == "" {
+			path = defaultJUnitPath
+		}
+		conversionResults = &resultCollector{path: path, start: time.Now()}
+		return nil
+	})
+}
+
+// resultCollector gathers the outcome of every converted file during a run.
+type resultCollector struct {
+	path  string
+	start time.Time
+
+	mu      sync.Mutex
+	results []*fileResult
+}
+
+// fileResult is the outcome of converting one file. Panicked marks the
+// failures caused by an internal error rather than by the file.
+type fileResult struct {
+	path     string
+	duration time.Duration
+	err      error
+	panicked bool
+}
+
+// record adds the result of converting the file at path. Files may be
+// converted concurrently.
+func (c *resultCollector) record(path string, duration time.Duration, err error, panicked bool) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.results = append(c.results, &fileResult{path: path, duration: duration, err: err, panicked: panicked})
+}
+
+// JUnitTestSuites is the root element of a JUnit XML report. Every folder of
+// converted files is a test suite and every file a test case, so that build
+// dashboards ingesting test reports can show the health of a conversion.
+type JUnitTestSuites struct {
+	XMLName  xml.Name          `xml:"testsuites"`
+	Name     string            `xml:"name,attr"`
+	Tests    int               `xml:"tests,attr"`
+	Failures int               `xml:"failures,attr"`
+	Errors   int               `xml:"errors,attr"`
+	Time     string            `xml:"time,attr"`
+	Suites   []*JUnitTestSuite `xml:"testsuite"`
+}
+
+// JUnitTestSuite holds the files of one folder, sorted by name. Time is the
+// total time spent converting them.
+type JUnitTestSuite struct {
+	Name      string           `xml:"name,attr"`
+	Tests     int              `xml:"tests,attr"`
+	Failures  int              `xml:"failures,attr"`
+	Errors    int              `xml:"errors,attr"`
+	Time      string           `xml:"time,attr"`
+	Timestamp string           `xml:"timestamp,attr"`
+	Cases     []*JUnitTestCase `xml:"testcase"`
+}
+
+// JUnitTestCase is the conversion of one file. A file that failed to convert
+// has a Failure, or an Error if the conversion hit an internal error.
+type JUnitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *JUnitProblem `xml:"failure,omitempty"`
+	Error     *JUnitProblem `xml:"error,omitempty"`
+}
+
+// JUnitProblem describes why a test case did not pass: Message is the first
+// line of the error and the text the whole error.
+type JUnitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// junitSeconds formats a duration the way JUnit reports do, in seconds.
+func junitSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', 3, 64)
+}
+
+// report groups the results by folder.
+func (c *resultCollector) report() *JUnitTestSuites {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	report := &JUnitTestSuites{Name: "go2json", Time: junitSeconds(time.Since(c.start)), Suites: []*JUnitTestSuite{}}
+	suites := make(map[string]*JUnitTestSuite)
+	durations := make(map[string]time.Duration)
+	timestamp := c.start.UTC().Format("2006-01-02T15:04:05")
+	for _, result := range c.results {
+		dir := filepath.ToSlash(filepath.Dir(result.path))
+		suite := suites[dir]
+		if suite == nil {
+			suite = &JUnitTestSuite{Name: dir, Timestamp: timestamp}
+			suites[dir] = suite
+			report.Suites = append(report.Suites, suite)
+		}
+		testCase := &JUnitTestCase{Name: filepath.Base(result.path), Classname: dir, Time: junitSeconds(result.duration)}
+		if result.err != nil {
+			message, _, _ := strings.Cut(result.err.Error(), "\n")
+			problem := &JUnitProblem{Message: message, Type: "conversion", Text: result.err.Error()}
+			var limitErr *untrustedLimitError
+			switch {
+			case result.panicked:
+				problem.Type = "internal"
+				testCase.Error = problem
+				suite.Errors++
+				report.Errors++
+			case errors.As(result.err, &limitErr):
+				problem.Type = "limit"
+				fallthrough
+			default:
+				testCase.Failure = problem
+				suite.Failures++
+				report.Failures++
+			}
+		}
+		suite.Cases = append(suite.Cases, testCase)
+		suite.Tests++
+		report.Tests++
+		durations[dir] += result.duration
+	}
+	for _, suite := range report.Suites {
+		suite.Time = junitSeconds(durations[suite.Name])
+		sort.Slice(suite.Cases, func(i, j int) bool { return suite.Cases[i].Name < suite.Cases[j].Name })
+	}
+	sort.Slice(report.Suites, func(i, j int) bool { return report.Suites[i].Name < report.Suites[j].Name })
+	return report
+}
+
+// write saves the report to the -report file.
+func (c *resultCollector) write() error {
+	outputFile, err := os.Create(c.path)
+	if err != nil {
+		return fmt.Errorf("error creating JUnit report %s: %w", c.path, err)
+	}
+	defer outputFile.Close()
+
+	if _, err := outputFile.WriteString(xml.Header); err != nil {
+		return fmt.Errorf("error writing JUnit report %s: %w", c.path, err)
+	}
+	encoder := xml.NewEncoder(outputFile)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(c.report()); err != nil {
+		return fmt.Errorf("error writing JUnit report %s: %w", c.path, err)
+	}
+	if _, err := outputFile.WriteString("\n"); err != nil {
+		return fmt.Errorf("error writing JUnit report %s: %w", c.path, err)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"strings"
+)
+
+func init() {
+	subcommands["match"] = runMatch
+}
+
+const matchUsage = `usage:
+  go2json match [-json] [-where NAME=KIND]... PATTERN PATH...
+
+PATTERN is Go code in which $name matches any expression, e.g. 'errors.New($msg)'.
+PATH is a file, a folder, or a folder followed by /... to include its subfolders.`
+
+// MatchResult is a code fragment matched by a pattern.
+type MatchResult struct {
+	File     string     `json:"file"`
+	Line     int        `json:"line"`
+	Column   int        `json:"column"`
+	Source   string     `json:"source"`
+	Bindings []*Binding `json:"bindings"`
+}
+
+// Binding is the code captured by a metavariable.
+type Binding struct {
+	Name   string `json:"name"`
+	Kind   string `json:"kind"`
+	Source string `json:"source"`
+}
+
+// runMatch reports every structural match of a pattern in the given Go files.
+func runMatch(args []string) error {
+	flags := flag.NewFlagSet("match", flag.ContinueOnError)
+	jsonOutput := flags.Bool("json", false, "print one JSON object per match")
+	where := make(map[string]string)
+	flags.Func("where", "restrict metavariable NAME to nodes of KIND, e.g. msg=*ast.BasicLit; may be repeated", func(value string) error {
+		name, kind, ok := strings.Cut(value, "=")
+		if !ok {
+			return fmt.Errorf("expected NAME=KIND, got %q", value)
+		}
+		where[strings.TrimPrefix(name, "$")] = kind
+		return nil
+	})
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() < 2 {
+		fmt.Fprintln(os.Stderr, matchUsage)
+		return errors.New("expected a pattern and at least one path")
+	}
+
+	p, err := compilePattern(flags.Arg(0), where)
+	if err != nil {
+		return err
+	}
+	var paths []string
+	for _, arg := range flags.Args()[1:] {
+		files, err := goFilesIn(arg)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, files...)
+	}
+
+	for _, path := range paths {
+		results, err := matchFile(p, path)
+		if err != nil {
+			return err
+		}
+		for _, result := range results {
+			if *jsonOutput {
+				err = encodeAST(os.Stdout, result, "")
+			} else {
+				err = writeMatch(os.Stdout, result)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// matchFile returns the matches of a pattern in a Go file.
+func matchFile(p *pattern, path string) ([]*MatchResult, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading Go source file %s: %w", path, err)
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing Go source file %s: %w", path, err)
+	}
+
+	var results []*MatchResult
+	for _, match := range p.find(file) {
+		position := fset.Position(match.node.Pos())
+		result := &MatchResult{
+			File:     path,
+			Line:     position.Line,
+			Column:   position.Column,
+			Source:   nodeSource(fset, src, match.node),
+			Bindings: []*Binding{},
+		}
+		for _, name := range match.sortedBindings() {
+			node := match.bindings[name]
+			result.Bindings = append(result.Bindings, &Binding{
+				Name:   name,
+				Kind:   fmt.Sprintf("%T", node),
+				Source: nodeSource(fset, src, node),
+			})
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// writeMatch prints a match grep-style, followed by its bindings. Only the first
+// line of multi-line code is shown.
+func writeMatch(w io.Writer, result *MatchResult) error {
+	firstLine, _, _ := strings.Cut(result.Source, "\n")
+	if _, err := fmt.Fprintf(w, "%s:%d:%d: %s\n", result.File, result.Line, result.Column, firstLine); err != nil {
+		return err
+	}
+	for _, binding := range result.Bindings {
+		source, _, _ := strings.Cut(binding.Source, "\n")
+		if _, err := fmt.Fprintf(w, "\t$%s = %s\n", binding.Name, source); err != nil {
+			return err
+		}
+	}
+	return nil
+}
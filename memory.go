@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"runtime/metrics"
+	"strconv"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/kobi2187/go2json/astjson"
+)
+
+// maxMemory is the memory budget set with -max-memory, or 0 for none.
+var maxMemory byteSize
+
+func init() {
+	flag.Var(&maxMemory, "max-memory", "memory budget such as 512MiB or 2GiB; the garbage collector works harder near it, files whose tree would not fit are converted without ever holding the whole tree, streamed or in chunks with -out, -o or -string-table (not with -packages), and large outputs are streamed instead of buffered")
+}
+
+// memoryPressureRatio is the share of the budget above which output is streamed.
+const memoryPressureRatio = 0.75
+
+// treeBytesPerSourceByte is roughly the memory the ASTNode tree of a file takes
+// per byte of its source, measured on ordinary Go code with positions.
+const treeBytesPerSourceByte = 150
+
+// byteSize is a flag.Value holding a size with an optional unit: B, KB, MB, GB
+// (powers of 1000) or KiB, MiB, GiB (powers of 1024).
+type byteSize uint64
+
+var byteUnits = []struct {
+	suffix string
+	size   uint64
+}{
+	{"KiB", 1 << 10}, {"MiB", 1 << 20}, {"GiB", 1 << 30}, {"TiB", 1 << 40},
+	{"KB", 1e3}, {"MB", 1e6}, {"GB", 1e9}, {"TB", 1e12},
+	{"B", 1},
+}
+
+func (s *byteSize) String() string {
+	return strconv.FormatUint(uint64(*s), 10)
+}
+
+func (s *byteSize) Set(value string) error {
+	number, unit := strings.TrimSpace(value), uint64(1)
+	for _, u := range byteUnits {
+		if rest, ok := strings.CutSuffix(number, u.suffix); ok {
+			number, unit = strings.TrimSpace(rest), u.size
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(number, 64)
+	if err != nil || n < 0 {
+		return fmt.Errorf("invalid size %q", value)
+	}
+	*s = byteSize(n * float64(unit))
+	return nil
+}
+
+// memoryPressure reports whether the live heap is close to the -max-memory budget.
+func memoryPressure() bool {
+	if maxMemory == 0 {
+		return false
+	}
+	return float64(heapBytes()) > memoryPressureRatio*float64(maxMemory)
+}
+
+// heapBytes returns the size of the live heap.
+func heapBytes() uint64 {
+	sample := []metrics.Sample{{Name: "/memory/classes/heap/objects:bytes"}}
+	metrics.Read(sample)
+	return sample[0].Value.Uint64()
+}
+
+// exceedsMemoryBudget reports whether the tree of the source file at path,
+// estimated from the size of the file, would take the live heap close to the
+// -max-memory budget.
+func exceedsMemoryBudget(sourceFilePath string) (bool, error) {
+	if maxMemory == 0 {
+		return false, nil
+	}
+	size, err := sourceSize(sourceFilePath)
+	if err != nil {
+		return false, err
+	}
+	return float64(heapBytes()+size*treeBytesPerSourceByte) > memoryPressureRatio*float64(maxMemory), nil
+}
+
+// processFileWithinBudget converts a source file whose tree would not fit the
+// -max-memory budget without ever holding the whole tree. The document is
+// streamed if it is written next to the source file; otherwise, or with a kind
+// table, which heads the document, the file is converted in chunks.
+func processFileWithinBudget(root, sourceFilePath string) error {
+	if len(outTargets) == 0 && !*stringTable {
+		slog.Warn("memory budget nearly exhausted, streaming the document", "file", sourceFilePath)
+		return processStreamedFile(sourceFilePath)
+	}
+	slog.Warn("memory budget nearly exhausted, converting in chunks", "file", sourceFilePath)
+	return processChunkedFile(root, sourceFilePath)
+}
+
+// streamDocument writes a document node by node instead of encoding it into a
+// single buffer first. Streamed documents are compact and list the children of
+// each node after its other fields. It returns false for values other than
+// documents, which must be encoded normally.
+func streamDocument(w io.Writer, v interface{}) (bool, error) {
+	bw := bufio.NewWriter(w)
+	var err error
+	switch doc := v.(type) {
+	case *astjson.ASTNode:
+		err = streamNode(bw, doc)
+	case *ndjsonRecord:
+		var json = jsoniter.ConfigCompatibleWithStandardLibrary
+		path, _ := json.Marshal(doc.Path)
+		fmt.Fprintf(bw, `{"path":%s,"ast":`, path)
+		err = streamNode(bw, doc.AST)
+		bw.WriteByte('}')
+	default:
+		return false, nil
+	}
+	if err != nil {
+		return true, err
+	}
+	bw.WriteByte('\n')
+	return true, bw.Flush()
+}
+
+// streamNode writes astNode and, one by one, its children.
+func streamNode(w *bufio.Writer, astNode *astjson.ASTNode) error {
+	if astNode == nil {
+		_, err := w.WriteString("null")
+		return err
+	}
+	shallow := *astNode
+	shallow.Children = nil
+	var json = jsoniter.ConfigCompatibleWithStandardLibrary
+	data, err := json.Marshal(&shallow)
+	if err != nil {
+		return err
+	}
+	if len(astNode.Children) == 0 {
+		_, err = w.Write(data)
+		return err
+	}
+	// The children follow the other fields, if the node has any.
+	w.Write(data[:len(data)-1])
+	if len(data) > 2 {
+		w.WriteByte(',')
+	}
+	w.WriteString(`"children":[`)
+	for i, child := range astNode.Children {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		if err := streamNode(w, child); err != nil {
+			return err
+		}
+	}
+	_, err = w.WriteString("]}")
+	return err
+}
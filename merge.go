@@ -0,0 +1,146 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kobi2187/go2json/astjson"
+)
+
+var packageDocuments = &packageMerger{pending: make(map[vocabularyKey]*mergedPackage)}
+
+// packageMerger collects the documents of the files of each package for
+// -packages and writes a merged document per package once its folder is done.
+type packageMerger struct {
+	pending map[vocabularyKey]*mergedPackage
+}
+
+// mergedPackage is a package whose files are being collected.
+type mergedPackage struct {
+	root    string
+	files   []*astjson.ASTNode
+	imports map[string][]string // import path to importing files
+	symbols []*astjson.PackageSymbol
+}
+
+// add records the document of a source file. Folders are walked depth first,
+// so the packages of folders that do not contain the file are complete and
+// are written first.
+func (m *packageMerger) add(root, sourceFilePath string, astNode *astjson.ASTNode, fset *token.FileSet, file *ast.File) error {
+	dir := filepath.Dir(sourceFilePath)
+	if err := m.flush(func(pending string) bool {
+		return pending != dir && !strings.HasPrefix(dir, pending+string(filepath.Separator))
+	}); err != nil {
+		return err
+	}
+
+	key := vocabularyKey{dir: dir, name: file.Name.Name}
+	pkg := m.pending[key]
+	if pkg == nil {
+		pkg = &mergedPackage{root: root, imports: make(map[string][]string)}
+		m.pending[key] = pkg
+	}
+	name := filepath.Base(sourceFilePath)
+	astNode.FormatVersion = 0
+	astNode.Name = name
+	pkg.files = append(pkg.files, astNode)
+	for _, spec := range file.Imports {
+		if path, err := strconv.Unquote(spec.Path.Value); err == nil {
+			pkg.imports[path] = append(pkg.imports[path], name)
+		}
+	}
+	pkg.symbols = append(pkg.symbols, packageSymbols(fset, name, file)...)
+	return nil
+}
+
+// flush writes the pending packages whose folder matches done, or all of them
+// if done is nil, in order of folder and name.
+func (m *packageMerger) flush(done func(dir string) bool) error {
+	var keys []vocabularyKey
+	for key := range m.pending {
+		if done == nil || done(key.dir) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].dir != keys[j].dir {
+			return keys[i].dir < keys[j].dir
+		}
+		return keys[i].name < keys[j].name
+	})
+	for _, key := range keys {
+		pkg := m.pending[key]
+		delete(m.pending, key)
+		if err := writeDocument(pkg.root, filepath.Join(key.dir, key.name), pkg.document(key.name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// document builds the merged document of a package.
+func (pkg *mergedPackage) document(name string) *astjson.ASTNode {
+	tables := &astjson.PackageTables{Imports: []*astjson.PackageImport{}, Symbols: pkg.symbols}
+	for path, files := range pkg.imports {
+		tables.Imports = append(tables.Imports, &astjson.PackageImport{Path: path, Files: files})
+	}
+	sort.Slice(tables.Imports, func(i, j int) bool {
+		return tables.Imports[i].Path < tables.Imports[j].Path
+	})
+	astNode := &astjson.ASTNode{
+		FormatVersion: astjson.FormatVersion,
+		Type:          "*ast.Package",
+		Name:          name,
+		Children:      pkg.files,
+		Tables:        tables,
+	}
+	if *stringTable {
+		internKinds(astNode)
+	}
+	return astNode
+}
+
+// packageSymbols lists the package-level declarations of a file in source
+// order, with the byte ranges of the declarations or specs declaring them.
+func packageSymbols(fset *token.FileSet, name string, file *ast.File) []*astjson.PackageSymbol {
+	var symbols []*astjson.PackageSymbol
+	add := func(symbolName, kind string, node ast.Node) {
+		symbol := &astjson.PackageSymbol{Name: symbolName, Kind: kind, File: name}
+		symbol.Start, symbol.End = sourceRange(fset, node)
+		symbols = append(symbols, symbol)
+	}
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if recv := receiverTypeName(d.Recv); recv != "" {
+				add(recv+"."+d.Name.Name, "method", d)
+			} else if d.Name.Name != "init" && d.Name.Name != "_" {
+				add(d.Name.Name, "func", d)
+			}
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					if s.Name.Name != "_" {
+						add(s.Name.Name, "type", s)
+					}
+				case *ast.ValueSpec:
+					kind := "var"
+					if d.Tok == token.CONST {
+						kind = "const"
+					}
+					for _, ident := range s.Names {
+						if ident.Name != "_" {
+							add(ident.Name, kind, s)
+						}
+					}
+				}
+			}
+		}
+	}
+	return symbols
+}
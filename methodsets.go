@@ -0,0 +1,87 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"github.com/kobi2187/go2json/astjson"
+)
+
+// methodSetReport lists the method sets of the named types other than
+// interfaces declared at package level in file, in source order, including the
+// methods promoted from embedded fields. It needs the type checker to apply
+// the promotion rules and returns nil if info is nil.
+func methodSetReport(fset *token.FileSet, info *types.Info, file *ast.File) []*astjson.MethodSet {
+	if info == nil {
+		return nil
+	}
+	sets := []*astjson.MethodSet{}
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			spec := spec.(*ast.TypeSpec)
+			obj, ok := info.Defs[spec.Name].(*types.TypeName)
+			if !ok || obj.IsAlias() || types.IsInterface(obj.Type()) {
+				continue
+			}
+			set := &astjson.MethodSet{
+				Type:    spec.Name.Name,
+				Line:    sourcePosition(fset, spec.Pos()).Line,
+				Methods: typedMethodSet(obj.Type(), types.RelativeTo(obj.Pkg())),
+			}
+			set.Start, set.End = sourceRange(fset, spec)
+			sets = append(sets, set)
+		}
+	}
+	return sets
+}
+
+// typedMethodSet lists the methods of the method set of *T for the named type
+// T, sorted by name like go/types does, marking those not in the method set of
+// T itself.
+func typedMethodSet(named types.Type, qualifier types.Qualifier) []*astjson.SetMethod {
+	valueMethods := types.NewMethodSet(named)
+	pointerMethods := types.NewMethodSet(types.NewPointer(named))
+	methods := []*astjson.SetMethod{}
+	for i := 0; i < pointerMethods.Len(); i++ {
+		sel := pointerMethods.At(i)
+		fn := sel.Obj().(*types.Func)
+		sig := fn.Type().(*types.Signature)
+		method := &astjson.SetMethod{
+			Name:     fn.Name(),
+			Params:   tupleParams(sig.Params(), sig.Variadic(), qualifier),
+			Results:  tupleParams(sig.Results(), false, qualifier),
+			Embedded: embeddingPath(named, sel.Index()),
+			Pointer:  valueMethods.Lookup(fn.Pkg(), fn.Name()) == nil,
+		}
+		if recv := sig.Recv(); recv != nil {
+			method.From = types.TypeString(recv.Type(), qualifier)
+		}
+		methods = append(methods, method)
+	}
+	return methods
+}
+
+// embeddingPath returns the names of the embedded fields a method with the
+// given selection index is promoted through, starting at a field of t, or nil
+// for a method declared on t.
+func embeddingPath(t types.Type, index []int) []string {
+	var path []string
+	for _, i := range index[:len(index)-1] {
+		if ptr, ok := t.Underlying().(*types.Pointer); ok {
+			t = ptr.Elem()
+		}
+		st, ok := t.Underlying().(*types.Struct)
+		if !ok {
+			break
+		}
+		field := st.Field(i)
+		path = append(path, field.Name())
+		t = field.Type()
+	}
+	return path
+}
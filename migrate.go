@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/kobi2187/go2json/astjson"
+)
+
+func init() {
+	subcommands["migrate"] = runMigrate
+}
+
+const migrateUsage = `usage:
+  go2json migrate [-from N] [-to N] FILE
+  go2json migrate [-from N] [-to N] -w FILE...
+
+FILE is a document written by go2json, or an .ndjson stream of documents.`
+
+// runMigrate upgrades documents written by older versions of go2json to a newer
+// format version, printing the result or, with -w, rewriting the files.
+func runMigrate(args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	from := flags.Int("from", 0, "format version of the input; detected from each document by default")
+	to := flags.Int("to", astjson.FormatVersion, "format version to migrate to")
+	write := flags.Bool("w", false, "rewrite the files in place instead of printing the result")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 || (!*write && flags.NArg() > 1) {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return errors.New("expected a single file, or -w and one or more files")
+	}
+
+	for _, path := range flags.Args() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("error reading %s: %w", path, err)
+		}
+		var out bytes.Buffer
+		if strings.HasSuffix(path, ".ndjson") {
+			err = migrateStream(&out, data, *from, *to)
+		} else {
+			err = migrateDocument(&out, data, *from, *to)
+		}
+		if err != nil {
+			return fmt.Errorf("error migrating %s: %w", path, err)
+		}
+		if !*write {
+			_, err = out.WriteTo(os.Stdout)
+			return err
+		}
+		if err := os.WriteFile(path, out.Bytes(), 0o644); err != nil {
+			return fmt.Errorf("error writing %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// migrateDocument migrates a single document. Indented documents stay indented.
+func migrateDocument(w io.Writer, data []byte, from, to int) error {
+	var doc astjson.ASTNode
+	var json = jsoniter.ConfigCompatibleWithStandardLibrary
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	if err := migrateAST(&doc, from, to); err != nil {
+		return err
+	}
+	indent := ""
+	if bytes.Contains(bytes.TrimSpace(data), []byte("\n")) {
+		indent = "  "
+	}
+	return encodeAST(w, &doc, indent)
+}
+
+// migrateStream migrates every record of an ndjson stream.
+func migrateStream(w io.Writer, data []byte, from, to int) error {
+	var json = jsoniter.ConfigCompatibleWithStandardLibrary
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var record ndjsonRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if record.AST == nil {
+			return fmt.Errorf("line %d: record has no document", line)
+		}
+		if err := migrateAST(record.AST, from, to); err != nil {
+			return fmt.Errorf("line %d (%s): %w", line, record.Path, err)
+		}
+		if err := encodeAST(w, &record, ""); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// migrateAST migrates a document from version from, or its own version if from is 0.
+func migrateAST(doc *astjson.ASTNode, from, to int) error {
+	if from == 0 {
+		from = astjson.DocumentVersion(doc)
+	}
+	return astjson.Migrate(doc, from, to)
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go/build"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/kobi2187/go2json/astjson"
+)
+
+// modcacheIndex lists the converted module versions and the folders holding their files.
+type modcacheIndex struct {
+	Modules []*modcacheEntry `json:"modules"`
+
+	byKey map[string]*modcacheEntry
+}
+
+// modcacheEntry describes a single converted module version.
+type modcacheEntry struct {
+	Key     string `json:"key"`
+	Path    string `json:"path"`
+	Version string `json:"version"`
+	Hash    string `json:"hash"`
+	Files   int    `json:"files"`
+}
+
+// modCacheDir returns the location of the module cache.
+func modCacheDir() string {
+	if dir := os.Getenv("GOMODCACHE"); dir != "" {
+		return dir
+	}
+	gopath := filepath.SplitList(build.Default.GOPATH)
+	if len(gopath) == 0 {
+		return ""
+	}
+	return filepath.Join(gopath[0], "pkg", "mod")
+}
+
+// processModCache converts each module version found in the module cache once,
+// writing its files to <outDir>/<hash of module@version>/ and recording it in <outDir>/index.json.
+// Module versions already present in the index are skipped.
+func processModCache(outDir string) error {
+	cacheDir := modCacheDir()
+	if cacheDir == "" {
+		return errors.New("cannot determine the module cache location")
+	}
+
+	indexPath := filepath.Join(outDir, "index.json")
+	index, err := loadModcacheIndex(indexPath)
+	if err != nil {
+		return err
+	}
+
+	err = filepath.WalkDir(cacheDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		// The download cache holds zips and metadata, not extracted sources.
+		if path == filepath.Join(cacheDir, "cache") {
+			return filepath.SkipDir
+		}
+		at := strings.LastIndex(d.Name(), "@")
+		if at < 0 {
+			return nil
+		}
+
+		rel, err := filepath.Rel(cacheDir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		modPath := unescapeModulePath(rel[:len(rel)-len(d.Name())+at])
+		modVersion := unescapeModulePath(d.Name()[at+1:])
+		key := modPath + "@" + modVersion
+		if _, ok := index.byKey[key]; ok {
+			return filepath.SkipDir
+		}
+
+		sum := sha256.Sum256([]byte(key))
+		entry := &modcacheEntry{Key: key, Path: modPath, Version: modVersion, Hash: hex.EncodeToString(sum[:])}
+		entry.Files, err = processModule(path, filepath.Join(outDir, entry.Hash))
+		if err != nil {
+			return err
+		}
+		index.add(entry)
+		slog.Info("module converted", "module", key, "files", entry.Files, "output", filepath.Join(outDir, entry.Hash))
+
+		// Save after every module so an interrupted run can resume.
+		if err := saveModcacheIndex(indexPath, index); err != nil {
+			return err
+		}
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return fmt.Errorf("error walking module cache %s: %w", cacheDir, err)
+	}
+	return nil
+}
+
+// processModule converts every .go file of a module version into outDir, mirroring its layout.
+// Directories ignored by the go tool (testdata, _ and . prefixed) are skipped.
+func processModule(moduleDir, outDir string) (int, error) {
+	count := 0
+	err := filepath.WalkDir(moduleDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != moduleDir && (name == "testdata" || strings.HasPrefix(name, "_") || strings.HasPrefix(name, ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(d.Name(), ".go") || !matchesTestsMode(d.Name()) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(moduleDir, path)
+		if err != nil {
+			return err
+		}
+		var astNode *astjson.ASTNode
+		err = isolateFile(path, func(path string) error {
+			var convertErr error
+			astNode, convertErr = convertFile(path)
+			return convertErr
+		})
+		if err != nil {
+			// Broken files exist in the wild; report them without failing the module.
+			slog.Warn("skipping file", "file", path, "error", err)
+			return nil
+		}
+		name, err := outputName(path)
+		if err != nil {
+			return err
+		}
+		outputPath := filepath.Join(outDir, filepath.Dir(rel), name)
+		if err := os.MkdirAll(filepath.Dir(outputPath), 0o755); err != nil {
+			return err
+		}
+		if err := writeAST(astNode, outputPath); err != nil {
+			return fmt.Errorf("error serializing AST to JSON for file %s: %w", path, err)
+		}
+		count++
+		return nil
+	})
+	return count, err
+}
+
+// unescapeModulePath reverses the module cache case encoding, where "!x" stands for "X".
+func unescapeModulePath(escaped string) string {
+	var b strings.Builder
+	bang := false
+	for _, r := range escaped {
+		if bang {
+			r = unicode.ToUpper(r)
+			bang = false
+		} else if r == '!' {
+			bang = true
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// loadModcacheIndex reads the index at path, returning an empty index if it does not exist yet.
+func loadModcacheIndex(path string) (*modcacheIndex, error) {
+	index := &modcacheIndex{byKey: make(map[string]*modcacheEntry)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading index %s: %w", path, err)
+	}
+	var json = jsoniter.ConfigCompatibleWithStandardLibrary
+	if err := json.Unmarshal(data, index); err != nil {
+		return nil, fmt.Errorf("error parsing index %s: %w", path, err)
+	}
+	for _, entry := range index.Modules {
+		index.byKey[entry.Key] = entry
+	}
+	return index, nil
+}
+
+// add records a converted module version, keeping the list sorted by key.
+func (index *modcacheIndex) add(entry *modcacheEntry) {
+	index.byKey[entry.Key] = entry
+	i := sort.Search(len(index.Modules), func(i int) bool { return index.Modules[i].Key >= entry.Key })
+	index.Modules = append(index.Modules, nil)
+	copy(index.Modules[i+1:], index.Modules[i:])
+	index.Modules[i] = entry
+}
+
+// saveModcacheIndex writes the index to path.
+func saveModcacheIndex(path string, index *modcacheIndex) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	indexFile, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("error creating index %s: %w", path, err)
+	}
+	defer indexFile.Close()
+
+	var json = jsoniter.ConfigCompatibleWithStandardLibrary
+	jsonEncoder := json.NewEncoder(indexFile)
+	jsonEncoder.SetIndent("", "  ")
+	return jsonEncoder.Encode(index)
+}
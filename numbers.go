@@ -0,0 +1,170 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"github.com/kobi2187/go2json/astjson"
+)
+
+// numberReport lists the numeric literals of every function declared in file
+// that has any, in source order, with the context each is used in. Literals
+// naming a constant in a const declaration are not listed. Without type
+// information, durations are only recognized next to a time package
+// selector, as in 5 * time.Second.
+func numberReport(fset *token.FileSet, info *types.Info, file *ast.File) []*astjson.FuncNumbers {
+	report := []*astjson.FuncNumbers{}
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+		name := fn.Name.Name
+		if recv := receiverTypeName(fn.Recv); recv != "" {
+			name = recv + "." + name
+		}
+		numbers := &astjson.FuncNumbers{Func: name, Line: sourcePosition(fset, fn.Pos()).Line}
+		numbers.Start, numbers.End = sourceRange(fset, fn)
+
+		var stack []ast.Node
+		ast.Inspect(fn.Body, func(node ast.Node) bool {
+			if node == nil {
+				stack = stack[:len(stack)-1]
+				return true
+			}
+			if gen, ok := node.(*ast.GenDecl); ok && gen.Tok == token.CONST {
+				return false
+			}
+			if lit, ok := node.(*ast.BasicLit); ok && isNumber(lit) {
+				position := sourcePosition(fset, lit.Pos())
+				value, context := numberContext(info, lit, stack)
+				numbers.Numbers = append(numbers.Numbers, &astjson.NumberLiteral{
+					Value:   value,
+					Line:    position.Line,
+					Column:  position.Column,
+					Context: context,
+				})
+			}
+			stack = append(stack, node)
+			return true
+		})
+		if len(numbers.Numbers) > 0 {
+			report = append(report, numbers)
+		}
+	}
+	return report
+}
+
+// isNumber reports whether lit is an integer, floating-point or imaginary literal.
+func isNumber(lit *ast.BasicLit) bool {
+	return lit.Kind == token.INT || lit.Kind == token.FLOAT || lit.Kind == token.IMAG
+}
+
+// numberContext returns the value of a numeric literal, negated if it is the
+// operand of a unary minus, and the context it is used in, given the stack of
+// its enclosing nodes: time for durations, comparison, array_size, size for
+// the lengths and capacities given to make, index, case, argument,
+// assignment, return, composite for elements of composite literals,
+// arithmetic, or other.
+func numberContext(info *types.Info, lit *ast.BasicLit, stack []ast.Node) (string, string) {
+	value := lit.Value
+	var expr ast.Node = lit
+	i := len(stack) - 1
+	for ; i >= 0; i-- {
+		if unary, ok := stack[i].(*ast.UnaryExpr); ok && unary.Op == token.SUB {
+			value = "-" + value
+		} else if _, ok := stack[i].(*ast.ParenExpr); !ok {
+			break
+		}
+		expr = stack[i]
+	}
+	if i < 0 {
+		return value, "other"
+	}
+	if isDuration(info, lit) {
+		return value, "time"
+	}
+
+	switch parent := stack[i].(type) {
+	case *ast.BinaryExpr:
+		switch parent.Op {
+		case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ:
+			return value, "comparison"
+		case token.MUL:
+			other := parent.X
+			if other == expr {
+				other = parent.Y
+			}
+			if isTimeSelector(info, other) {
+				return value, "time"
+			}
+		}
+		return value, "arithmetic"
+	case *ast.ArrayType:
+		return value, "array_size"
+	case *ast.CallExpr:
+		if ident, ok := ast.Unparen(parent.Fun).(*ast.Ident); ok && ident.Name == "make" && (info == nil || isBuiltin(info, ident)) {
+			return value, "size"
+		}
+		if isTimeSelector(info, parent.Fun) {
+			return value, "time"
+		}
+		return value, "argument"
+	case *ast.IndexExpr, *ast.IndexListExpr, *ast.SliceExpr:
+		return value, "index"
+	case *ast.CaseClause:
+		return value, "case"
+	case *ast.AssignStmt, *ast.ValueSpec:
+		return value, "assignment"
+	case *ast.ReturnStmt:
+		return value, "return"
+	case *ast.CompositeLit, *ast.KeyValueExpr:
+		return value, "composite"
+	}
+	return value, "other"
+}
+
+// isDuration reports whether the type checker has given lit the type time.Duration.
+func isDuration(info *types.Info, lit *ast.BasicLit) bool {
+	if info == nil {
+		return false
+	}
+	return isTimeType(info.Types[lit].Type)
+}
+
+// isTimeSelector reports whether expr is a selector of the time package, such
+// as time.Second or time.Duration, or, with type information, an expression
+// of type time.Duration.
+func isTimeSelector(info *types.Info, expr ast.Expr) bool {
+	if info != nil {
+		if tv, ok := info.Types[expr]; ok && isTimeType(tv.Type) {
+			return true
+		}
+	}
+	sel, ok := ast.Unparen(expr).(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	x, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	if info != nil {
+		name, ok := info.Uses[x].(*types.PkgName)
+		return ok && name.Imported().Path() == "time"
+	}
+	return x.Name == "time"
+}
+
+// isTimeType reports whether t is time.Duration.
+func isTimeType(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "time" && named.Obj().Name() == "Duration"
+}
+
+// isBuiltin reports whether ident refers to a predeclared function.
+func isBuiltin(info *types.Info, ident *ast.Ident) bool {
+	_, ok := info.Uses[ident].(*types.Builtin)
+	return ok
+}
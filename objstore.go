@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/kobi2187/go2json/astjson"
+)
+
+var uploadJobs = flag.Int("upload-jobs", 8, "number of parallel uploads to s3:// and gs:// output locations")
+
+// Object storage schemes accepted as output locations by -out and -out-dir.
+const (
+	schemeS3  = "s3"
+	schemeGCS = "gs"
+)
+
+// isObjectStoreURL reports whether an output location is an s3:// or gs:// URL.
+func isObjectStoreURL(location string) bool {
+	return strings.HasPrefix(location, schemeS3+"://") || strings.HasPrefix(location, schemeGCS+"://")
+}
+
+// objectTarget writes one indented JSON object per source file under a bucket
+// prefix, mirroring the source layout like a pretty target. Objects are
+// uploaded with the aws or gcloud command, which take their credentials from
+// the environment as usual, several at a time.
+type objectTarget struct {
+	scheme string // s3 or gs
+	prefix string // bucket and key prefix, without scheme
+
+	jobs chan objectUpload // started on first use
+	wg   sync.WaitGroup
+	mu   sync.Mutex
+	errs []error
+}
+
+// objectUpload is a document waiting to be uploaded.
+type objectUpload struct {
+	url  string
+	data []byte
+}
+
+func (target *objectTarget) spec() string {
+	return target.scheme + "://" + target.prefix
+}
+
+func (target *objectTarget) write(sourceFilePath, rel string, astNode *astjson.ASTNode) error {
+	name, err := outputName(rel)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := encodeAST(&buf, astNode, "  "); err != nil {
+		return err
+	}
+	url := target.spec() + "/" + path.Join(filepath.ToSlash(filepath.Dir(rel)), name)
+
+	if target.jobs == nil {
+		target.start()
+	}
+	target.jobs <- objectUpload{url: url, data: buf.Bytes()}
+	slog.Debug("AST queued for upload", "file", sourceFilePath, "output", url)
+	return nil
+}
+
+// start launches the upload workers.
+func (target *objectTarget) start() {
+	target.jobs = make(chan objectUpload)
+	workers := max(*uploadJobs, 1)
+	for i := 0; i < workers; i++ {
+		target.wg.Add(1)
+		go func() {
+			defer target.wg.Done()
+			for upload := range target.jobs {
+				if err := target.upload(upload); err != nil {
+					target.mu.Lock()
+					target.errs = append(target.errs, err)
+					target.mu.Unlock()
+				}
+			}
+		}()
+	}
+}
+
+// upload copies a document to its object with the command-line client of the store.
+func (target *objectTarget) upload(upload objectUpload) error {
+	var cmd *exec.Cmd
+	switch target.scheme {
+	case schemeS3:
+		cmd = exec.Command("aws", "s3", "cp", "--content-type", "application/json", "-", upload.url)
+	case schemeGCS:
+		cmd = exec.Command("gcloud", "storage", "cp", "--content-type=application/json", "-", upload.url)
+	}
+	cmd.Stdin = bytes.NewReader(upload.data)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("error uploading %s: %w: %s", upload.url, err, strings.TrimSpace(string(out)))
+	}
+	slog.Info("AST uploaded", "output", upload.url)
+	return nil
+}
+
+// close waits for the pending uploads and reports those that failed.
+func (target *objectTarget) close() error {
+	if target.jobs == nil {
+		return nil
+	}
+	close(target.jobs)
+	target.wg.Wait()
+	target.jobs = nil
+	err := errors.Join(target.errs...)
+	target.errs = nil
+	return err
+}
@@ -0,0 +1,391 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/kobi2187/go2json/astjson"
+)
+
+// Output formats accepted by -out.
+const (
+	formatPretty  = "pretty"  // one indented JSON file per source file
+	formatCompact = "compact" // one single-line JSON file per source file
+	formatNDJSON  = "ndjson"  // one line per source file in a shared ast.ndjson file, or on stdout for -
+	formatCAS     = "cas"     // one <sha256>.json file per distinct document, plus index.json
+	formatBundle  = "bundle"  // a single zip container holding all documents and indexes
+)
+
+// outputTarget is a destination given with -out FORMAT:PATH.
+type outputTarget interface {
+	// write stores the AST of a source file whose path relative to the converted root is rel.
+	write(sourceFilePath, rel string, astNode *astjson.ASTNode) error
+	// close finalizes the target once all files have been written.
+	close() error
+	// spec returns the target in FORMAT:PATH form.
+	spec() string
+}
+
+// newOutputTarget creates the target for a format and its location.
+func newOutputTarget(format, location string) (outputTarget, error) {
+	switch format {
+	case formatPretty:
+		return &fileTarget{format: format, dir: location, indent: "  "}, nil
+	case formatCompact:
+		return &fileTarget{format: format, dir: location}, nil
+	case formatNDJSON:
+		return &ndjsonTarget{dir: location}, nil
+	case formatCAS:
+		return &casTarget{dir: location}, nil
+	case formatBundle:
+		return &bundleTarget{path: location}, nil
+	case schemeS3, schemeGCS:
+		// An object storage URL such as s3://bucket/prefix, split at its scheme.
+		return &objectTarget{scheme: format, prefix: strings.TrimSuffix(strings.TrimPrefix(location, "//"), "/")}, nil
+	default:
+		return nil, fmt.Errorf("unknown output format %q", format)
+	}
+}
+
+// outputTargets holds the -out flags. It implements flag.Value so the flag can be repeated.
+type outputTargets []outputTarget
+
+var outTargets outputTargets
+
+func init() {
+	flag.Var(&outTargets, "out", "write output as FORMAT:PATH, where FORMAT is pretty, compact, ndjson, cas (PATH is a folder, or - to stream ndjson to stdout) or bundle (PATH is a file), or to an s3://bucket/prefix or gs://bucket/prefix URL; may be repeated")
+	flag.Func("store", "write output to a content-addressed store in DIR (same as -out cas:DIR)", func(dir string) error {
+		return outTargets.Set(formatCAS + ":" + dir)
+	})
+	flag.Func("out-dir", "write one indented JSON file per source file under DIR, mirroring the source layout (same as -out pretty:DIR); DIR may be an s3:// or gs:// URL", func(dir string) error {
+		if isObjectStoreURL(dir) {
+			return outTargets.Set(dir)
+		}
+		return outTargets.Set(formatPretty + ":" + dir)
+	})
+	flag.Func("bundle", "write output to a single bundle FILE (same as -out bundle:FILE)", func(path string) error {
+		return outTargets.Set(formatBundle + ":" + path)
+	})
+	flag.Func("o", "write the documents of all files one after another to FILE, or to stdout if FILE is -", func(path string) error {
+		outTargets = append(outTargets, &streamTarget{path: path})
+		return nil
+	})
+	flag.BoolFunc("stdout", "write the documents of all files to stdout (same as -o -)", func(string) error {
+		outTargets = append(outTargets, &streamTarget{path: "-"})
+		return nil
+	})
+}
+
+func (t *outputTargets) String() string {
+	var specs []string
+	for _, target := range *t {
+		specs = append(specs, target.spec())
+	}
+	return strings.Join(specs, ",")
+}
+
+func (t *outputTargets) Set(value string) error {
+	format, location, ok := strings.Cut(value, ":")
+	if !ok || location == "" {
+		return errors.New("expected FORMAT:PATH")
+	}
+	target, err := newOutputTarget(format, location)
+	if err != nil {
+		return err
+	}
+	*t = append(*t, target)
+	return nil
+}
+
+// write serializes the AST of a source file to every target under the file's path relative to root.
+func (t outputTargets) write(root, sourceFilePath string, astNode *astjson.ASTNode) error {
+	rel, err := filepath.Rel(root, sourceFilePath)
+	if err != nil {
+		return err
+	}
+	for _, target := range t {
+		if err := writeTarget(target, sourceFilePath, rel, astNode); err != nil {
+			return fmt.Errorf("error writing %s output for file %s: %w", target.spec(), sourceFilePath, err)
+		}
+	}
+	return nil
+}
+
+// writeTarget writes the document of a source file to target. Targets writing
+// a file of its own per source file can be written concurrently; the others
+// share a stream or an index between files, so writes to them are serialized.
+func writeTarget(target outputTarget, sourceFilePath, rel string, astNode *astjson.ASTNode) error {
+	if _, ok := target.(*fileTarget); !ok {
+		outputMu.Lock()
+		defer outputMu.Unlock()
+	}
+	return target.write(sourceFilePath, rel, astNode)
+}
+
+// close finalizes every target.
+func (t outputTargets) close() error {
+	var errs []error
+	for _, target := range t {
+		errs = append(errs, target.close())
+	}
+	return errors.Join(errs...)
+}
+
+// fileTarget writes one JSON file per source file, mirroring the source layout.
+type fileTarget struct {
+	format string
+	dir    string
+	indent string
+}
+
+func (target *fileTarget) spec() string {
+	return target.format + ":" + target.dir
+}
+
+func (target *fileTarget) write(sourceFilePath, rel string, astNode *astjson.ASTNode) error {
+	name, err := outputName(rel)
+	if err != nil {
+		return err
+	}
+	outputPath := filepath.Join(target.dir, filepath.Dir(rel), name)
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0o755); err != nil {
+		return err
+	}
+	outputFile, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("error creating output file %s: %w", outputPath, err)
+	}
+	defer outputFile.Close()
+
+	if err := encodeAST(outputFile, astNode, target.indent); err != nil {
+		return err
+	}
+	slog.Info("AST generated", "file", sourceFilePath, "output", outputPath)
+	return nil
+}
+
+func (target *fileTarget) close() error {
+	return nil
+}
+
+// streamTarget writes the documents of all files one after another to a single
+// file or to stdout, indented like the documents written next to source files.
+type streamTarget struct {
+	path string   // output file, or - for stdout
+	file *os.File // opened on first use, unless writing to stdout
+}
+
+func (target *streamTarget) spec() string {
+	return "-o " + target.path
+}
+
+func (target *streamTarget) write(sourceFilePath, rel string, astNode *astjson.ASTNode) error {
+	w := os.Stdout
+	if target.path != "-" {
+		if target.file == nil {
+			file, err := os.Create(target.path)
+			if err != nil {
+				return fmt.Errorf("error creating output file %s: %w", target.path, err)
+			}
+			target.file = file
+		}
+		w = target.file
+	}
+	return encodeAST(w, astNode, conversionOptions().Indent)
+}
+
+func (target *streamTarget) close() error {
+	if target.file == nil {
+		return nil
+	}
+	err := target.file.Close()
+	target.file = nil
+	return err
+}
+
+// ndjsonTarget writes one line per source file to a shared ast.ndjson file, or
+// to stdout if dir is -. Lines are written as files are converted, so consumers
+// can process the stream while a large tree is still being converted.
+type ndjsonTarget struct {
+	dir    string
+	stream *os.File // opened on first use
+}
+
+// ndjsonRecord is a single line of an ndjson target.
+type ndjsonRecord struct {
+	Path string           `json:"path"`
+	AST  *astjson.ASTNode `json:"ast"`
+}
+
+func (target *ndjsonTarget) spec() string {
+	return formatNDJSON + ":" + target.dir
+}
+
+func (target *ndjsonTarget) write(sourceFilePath, rel string, astNode *astjson.ASTNode) error {
+	if target.stream == nil && target.dir == "-" {
+		target.stream = os.Stdout
+	}
+	if target.stream == nil {
+		if err := os.MkdirAll(target.dir, 0o755); err != nil {
+			return err
+		}
+		stream, err := os.Create(filepath.Join(target.dir, "ast.ndjson"))
+		if err != nil {
+			return err
+		}
+		target.stream = stream
+	}
+	return encodeAST(target.stream, &ndjsonRecord{Path: filepath.ToSlash(rel), AST: astNode}, "")
+}
+
+func (target *ndjsonTarget) close() error {
+	if target.stream == nil || target.stream == os.Stdout {
+		target.stream = nil
+		return nil
+	}
+	err := target.stream.Close()
+	target.stream = nil
+	return err
+}
+
+// casTarget writes each distinct document once as <sha256>.json and maintains an
+// index.json mapping source paths to document hashes. Stored documents leave out
+// the path of their source and the file names of their positions, which the
+// index records, so identical files at different paths share a document.
+type casTarget struct {
+	dir    string
+	hashes map[string]string // source path to document hash, for the index
+}
+
+// casIndex maps source paths to the hashes of their documents in a content-addressed store.
+type casIndex struct {
+	Files []*casEntry `json:"files"`
+}
+
+// casEntry is a single source path of a casIndex.
+type casEntry struct {
+	Path string `json:"path"`
+	Hash string `json:"hash"`
+}
+
+func (target *casTarget) spec() string {
+	return formatCAS + ":" + target.dir
+}
+
+// write stores a document as <sha256>.json. Documents already present are not
+// rewritten, so identical files are stored only once.
+func (target *casTarget) write(sourceFilePath, rel string, astNode *astjson.ASTNode) error {
+	var buf bytes.Buffer
+	if err := encodeAST(&buf, withoutPaths(astNode), ""); err != nil {
+		return err
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	hash := hex.EncodeToString(sum[:])
+
+	if target.hashes == nil {
+		target.hashes = make(map[string]string)
+	}
+	target.hashes[filepath.ToSlash(rel)] = hash
+
+	outputPath := filepath.Join(target.dir, hash+".json")
+	if _, err := os.Stat(outputPath); err == nil {
+		slog.Info("AST already stored", "file", sourceFilePath, "output", outputPath)
+		return nil
+	}
+	if err := os.MkdirAll(target.dir, 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(outputPath, buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+	slog.Info("AST generated", "file", sourceFilePath, "output", outputPath)
+	return nil
+}
+
+// close merges the hashes recorded in this run into the store's index.json,
+// replacing entries for the same source paths.
+func (target *casTarget) close() error {
+	if target.hashes == nil {
+		return nil
+	}
+	indexPath := filepath.Join(target.dir, "index.json")
+	var json = jsoniter.ConfigCompatibleWithStandardLibrary
+	index := &casIndex{}
+	data, err := os.ReadFile(indexPath)
+	if err == nil {
+		if err := json.Unmarshal(data, index); err != nil {
+			return fmt.Errorf("error parsing index %s: %w", indexPath, err)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("error reading index %s: %w", indexPath, err)
+	}
+
+	for _, entry := range index.Files {
+		if _, ok := target.hashes[entry.Path]; !ok {
+			target.hashes[entry.Path] = entry.Hash
+		}
+	}
+	index.Files = index.Files[:0]
+	for path, hash := range target.hashes {
+		index.Files = append(index.Files, &casEntry{Path: path, Hash: hash})
+	}
+	sort.Slice(index.Files, func(i, j int) bool { return index.Files[i].Path < index.Files[j].Path })
+	target.hashes = nil
+
+	indexFile, err := os.Create(indexPath)
+	if err != nil {
+		return fmt.Errorf("error creating index %s: %w", indexPath, err)
+	}
+	defer indexFile.Close()
+	return encodeAST(indexFile, index, "  ")
+}
+
+// withoutPaths returns a copy of the tree of astNode without the path of its
+// source and the file names of its positions. The tree itself is not changed,
+// since other targets may still write it.
+func withoutPaths(astNode *astjson.ASTNode) *astjson.ASTNode {
+	copied := *astNode
+	if copied.Source != nil {
+		source := *copied.Source
+		source.Path = ""
+		copied.Source = &source
+	}
+	copied.Pos, copied.End = withoutFilename(copied.Pos), withoutFilename(copied.End)
+	copied.Lparen, copied.Rparen = withoutFilename(copied.Lparen), withoutFilename(copied.Rparen)
+	if copied.CommentInfo != nil {
+		copied.CommentInfo = make([]*astjson.Comment, len(astNode.CommentInfo))
+		for i, info := range astNode.CommentInfo {
+			comment := *info
+			comment.Pos, comment.End = withoutFilename(comment.Pos), withoutFilename(comment.End)
+			copied.CommentInfo[i] = &comment
+		}
+	}
+	if copied.Children != nil {
+		copied.Children = make([]*astjson.ASTNode, len(astNode.Children))
+		for i, child := range astNode.Children {
+			copied.Children[i] = withoutPaths(child)
+		}
+	}
+	return &copied
+}
+
+// withoutFilename returns a copy of position without its file name.
+func withoutFilename(position *astjson.Position) *astjson.Position {
+	if position == nil {
+		return nil
+	}
+	copied := *position
+	copied.Filename = ""
+	return &copied
+}
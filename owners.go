@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"go/scanner"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/kobi2187/go2json/astjson"
+)
+
+// regionMarker is a region comment: the start of a named region, or its end if
+// name is empty.
+type regionMarker struct {
+	line int
+	name string
+}
+
+var regionPattern = regexp.MustCompile(`^//\s*(region|endregion)\b:?\s*(.*)$`)
+
+// fileOwnership looks up the ownership of the declarations of one file.
+type fileOwnership struct {
+	fset    *token.FileSet
+	regions []regionMarker
+	owners  []string
+}
+
+// newFileOwnership collects the region markers of a source file, whose nodes are
+// positioned in fset, and its CODEOWNERS entry.
+func newFileOwnership(fset *token.FileSet, sourceFilePath string) (*fileOwnership, error) {
+	regions, err := regionMarkers(sourceFilePath)
+	if err != nil {
+		return nil, err
+	}
+	return &fileOwnership{fset: fset, regions: regions, owners: codeOwners(sourceFilePath)}, nil
+}
+
+// regionMarkers scans a source file for region comments. The syntax tree is
+// converted without comments, so they are read from the source directly.
+func regionMarkers(sourceFilePath string) ([]regionMarker, error) {
+	src, err := readSource(sourceFilePath)
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	var s scanner.Scanner
+	s.Init(fset.AddFile(sourceFilePath, -1, len(src)), src, nil, scanner.ScanComments)
+	var regions []regionMarker
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			return regions, nil
+		}
+		if tok != token.COMMENT {
+			continue
+		}
+		m := regionPattern.FindStringSubmatch(lit)
+		if m == nil {
+			continue
+		}
+		marker := regionMarker{line: fset.Position(pos).Line}
+		if m[1] == "region" {
+			marker.name = strings.TrimSpace(m[2])
+		}
+		regions = append(regions, marker)
+	}
+}
+
+// forDecl returns the ownership of the declaration starting at pos, or nil if it
+// has neither region nor owners.
+func (o *fileOwnership) forDecl(pos token.Pos) *astjson.Ownership {
+	line := o.fset.Position(pos).Line
+	region := ""
+	for _, marker := range o.regions {
+		if marker.line >= line {
+			break
+		}
+		region = marker.name
+	}
+	if region == "" && len(o.owners) == 0 {
+		return nil
+	}
+	return &astjson.Ownership{Region: region, Owners: o.owners}
+}
+
+// codeOwnersFiles are the locations of a CODEOWNERS file in a repository.
+var codeOwnersFiles = []string{"CODEOWNERS", ".github/CODEOWNERS", "docs/CODEOWNERS"}
+
+// codeOwnersRule assigns owners to the paths matching a pattern.
+type codeOwnersRule struct {
+	pattern *regexp.Regexp
+	owners  []string
+}
+
+// codeOwnersRoot is a repository root and the rules of its CODEOWNERS file.
+type codeOwnersRoot struct {
+	dir   string
+	rules []codeOwnersRule
+}
+
+// codeOwnersCache remembers the repository root of every folder looked up so
+// far. codeOwnersMu guards it, for -jobs.
+var (
+	codeOwnersCache = make(map[string]*codeOwnersRoot)
+	codeOwnersMu    sync.Mutex
+)
+
+// codeOwners returns the owners of a source file according to the CODEOWNERS file
+// of its repository. As on GitHub, the last matching rule wins.
+func codeOwners(sourceFilePath string) []string {
+	abs, err := filepath.Abs(sourceFilePath)
+	if err != nil {
+		return nil
+	}
+	codeOwnersMu.Lock()
+	root := findCodeOwners(filepath.Dir(abs))
+	codeOwnersMu.Unlock()
+	if root == nil {
+		return nil
+	}
+	rel, err := filepath.Rel(root.dir, abs)
+	if err != nil {
+		return nil
+	}
+	rel = filepath.ToSlash(rel)
+	var owners []string
+	for _, rule := range root.rules {
+		if rule.pattern.MatchString(rel) {
+			owners = rule.owners
+		}
+	}
+	return owners
+}
+
+// findCodeOwners returns the repository containing the absolute folder dir, found
+// by looking for .git in dir and its parents, or nil if there is none.
+func findCodeOwners(dir string) *codeOwnersRoot {
+	if root, ok := codeOwnersCache[dir]; ok {
+		return root
+	}
+	var root *codeOwnersRoot
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+		root = &codeOwnersRoot{dir: dir}
+		for _, name := range codeOwnersFiles {
+			if data, err := os.ReadFile(filepath.Join(dir, name)); err == nil {
+				root.rules = parseCodeOwners(data)
+				break
+			}
+		}
+	} else if parent := filepath.Dir(dir); parent != dir {
+		root = findCodeOwners(parent)
+	}
+	codeOwnersCache[dir] = root
+	return root
+}
+
+// parseCodeOwners parses the rules of a CODEOWNERS file. Patterns follow the
+// gitignore rules GitHub supports: a leading or inner slash anchors a pattern to
+// the repository root, a trailing slash matches a folder and everything in it.
+func parseCodeOwners(data []byte) []codeOwnersRule {
+	var rules []codeOwnersRule
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		rules = append(rules, codeOwnersRule{pattern: codeOwnersPattern(fields[0]), owners: fields[1:]})
+	}
+	return rules
+}
+
+// codeOwnersPattern compiles a CODEOWNERS path pattern.
+func codeOwnersPattern(pattern string) *regexp.Regexp {
+	anchored := strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+	pattern = strings.Trim(pattern, "/")
+
+	var expr strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case strings.HasPrefix(pattern[i:], "**"):
+			expr.WriteString(".*")
+			i++
+		case pattern[i] == '*':
+			expr.WriteString("[^/]*")
+		case pattern[i] == '?':
+			expr.WriteString("[^/]")
+		default:
+			expr.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	prefix := "^(.*/)?"
+	if anchored {
+		prefix = "^"
+	}
+	return regexp.MustCompile(prefix + expr.String() + "(/.*)?$")
+}
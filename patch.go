@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"sort"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/kobi2187/go2json/astjson"
+)
+
+func init() {
+	subcommands["patch"] = runPatch
+}
+
+const patchUsage = `usage:
+  go2json patch [-w] SCRIPT`
+
+// EditScript is a list of structural edits addressed by node ID (see -ids).
+// Replacement and inserted code is given either as Go source or as a JSON
+// subtree in the format of the output, such as a node taken from another
+// document. Comments in subtrees are not restored, and subtrees cut off by
+// -max-depth or interned with -string-table cannot be turned back into code.
+type EditScript struct {
+	Edits []*Edit `json:"edits"`
+}
+
+// Edit is a single operation on the node with the given ID in File: "replace"
+// its source with Source or AST, "delete" it, or "insert_after" it. Statements,
+// declarations and specs are inserted on a new line, other nodes as the next
+// element of a comma-separated list.
+type Edit struct {
+	File   string           `json:"file"`
+	Op     string           `json:"op"`
+	ID     string           `json:"id"`
+	Source string           `json:"source,omitempty"`
+	AST    *astjson.ASTNode `json:"ast,omitempty"`
+}
+
+// code returns the Go source an edit replaces or inserts.
+func (edit *Edit) code() (string, error) {
+	if edit.AST == nil {
+		return edit.Source, nil
+	}
+	if edit.Source != "" {
+		return "", fmt.Errorf("edit of node %s has both source and ast", edit.ID)
+	}
+	node, err := astjson.BuildNode(edit.AST)
+	if err != nil {
+		return "", fmt.Errorf("error building the ast of the edit of node %s: %w", edit.ID, err)
+	}
+	var buf bytes.Buffer
+	if err := format.Node(&buf, token.NewFileSet(), node); err != nil {
+		return "", fmt.Errorf("error printing the ast of the edit of node %s: %w", edit.ID, err)
+	}
+	return buf.String(), nil
+}
+
+// runPatch applies an edit script and prints the resulting files, or writes them
+// back with -w. Every patched file is reformatted and must still parse.
+func runPatch(args []string) error {
+	flags := flag.NewFlagSet("patch", flag.ContinueOnError)
+	write := flags.Bool("w", false, "write the patched files back instead of printing them")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, patchUsage)
+		return errors.New("expected a single edit script")
+	}
+
+	data, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("error reading edit script %s: %w", flags.Arg(0), err)
+	}
+	var script EditScript
+	var json = jsoniter.ConfigCompatibleWithStandardLibrary
+	if err := json.Unmarshal(data, &script); err != nil {
+		return fmt.Errorf("error decoding edit script %s: %w", flags.Arg(0), err)
+	}
+
+	// Group the edits by file, keeping the files in script order.
+	var files []string
+	editsByFile := make(map[string][]*Edit)
+	for _, edit := range script.Edits {
+		if _, ok := editsByFile[edit.File]; !ok {
+			files = append(files, edit.File)
+		}
+		editsByFile[edit.File] = append(editsByFile[edit.File], edit)
+	}
+
+	patched := make(map[string][]byte)
+	for _, path := range files {
+		src, err := patchFile(path, editsByFile[path])
+		if err != nil {
+			return err
+		}
+		patched[path] = src
+	}
+	// Nothing is written unless every file could be patched.
+	for _, path := range files {
+		if *write {
+			if err := os.WriteFile(path, patched[path], 0o644); err != nil {
+				return fmt.Errorf("error writing %s: %w", path, err)
+			}
+			continue
+		}
+		writePatched(os.Stdout, path, patched[path])
+	}
+	return nil
+}
+
+// writePatched prints a patched file under a "-- path --" header.
+func writePatched(w io.Writer, path string, src []byte) {
+	fmt.Fprintf(w, "-- %s --\n", path)
+	w.Write(src)
+}
+
+// span is the byte range of a node in its file.
+type span struct {
+	start, end int
+	node       ast.Node
+}
+
+// patchFile applies edits to a source file and returns the formatted result.
+func patchFile(path string, edits []*Edit) ([]byte, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading Go source file %s: %w", path, err)
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing Go source file %s: %w", path, err)
+	}
+	ids, err := newNodeIDs(fset, path)
+	if err != nil {
+		return nil, err
+	}
+	spans := make(map[string]span)
+	ast.Inspect(file, func(node ast.Node) bool {
+		if node != nil {
+			spans[ids.id(node)] = span{ids.offset(node.Pos()), ids.offset(node.End()), node}
+		}
+		return true
+	})
+
+	// Resolve every edit to a splice of the original source.
+	var splices []splice
+	for _, edit := range edits {
+		s, ok := spans[edit.ID]
+		if !ok {
+			return nil, fmt.Errorf("node %s not found in %s; was the file changed since the IDs were taken?", edit.ID, path)
+		}
+		code, err := edit.code()
+		if err != nil {
+			return nil, err
+		}
+		switch edit.Op {
+		case "replace":
+			splices = append(splices, splice{s.start, s.end, code})
+		case "delete":
+			splices = append(splices, splice{s.start, s.end, ""})
+		case "insert_after":
+			separator := ", "
+			switch s.node.(type) {
+			case ast.Stmt, ast.Decl, ast.Spec:
+				separator = "\n"
+			}
+			splices = append(splices, splice{s.end, s.end, separator + code})
+		default:
+			return nil, fmt.Errorf("unknown edit operation %q for node %s in %s", edit.Op, edit.ID, path)
+		}
+	}
+	return applySplices(path, src, splices)
+}
+
+// splice replaces the bytes [start, end) of a source file by text.
+type splice struct {
+	start, end int
+	text       string
+}
+
+// applySplices applies non-overlapping splices to src, the source of path, and
+// returns the formatted result.
+func applySplices(path string, src []byte, splices []splice) ([]byte, error) {
+	// Apply the splices back to front so earlier offsets stay valid.
+	sort.SliceStable(splices, func(i, j int) bool { return splices[i].start > splices[j].start })
+	for i := 1; i < len(splices); i++ {
+		if splices[i].end > splices[i-1].start {
+			return nil, fmt.Errorf("overlapping edits in %s at offset %d", path, splices[i-1].start)
+		}
+	}
+	out := append([]byte(nil), src...)
+	for _, s := range splices {
+		out = append(out[:s.start], append([]byte(s.text), out[s.end:]...)...)
+	}
+
+	formatted, err := format.Source(out)
+	if err != nil {
+		return nil, fmt.Errorf("patched %s is not valid Go: %w", path, err)
+	}
+	return formatted, nil
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// metavarPrefix is the identifier prefix that stands for a $name metavariable
+// once a pattern has been rewritten into valid Go.
+const metavarPrefix = "go2json_metavar_"
+
+var metavarPattern = regexp.MustCompile(`\$([A-Za-z_][A-Za-z0-9_]*)`)
+
+// pattern is Go code containing $name metavariables, matched structurally
+// against syntax trees. A metavariable matches any expression, or any statement
+// when it stands alone as a statement. A metavariable used more than once must
+// match equal code each time.
+type pattern struct {
+	node  ast.Node
+	where map[string]string // metavariable name to required node kind, such as *ast.BasicLit
+}
+
+// compilePattern parses src, which must be a single expression, statement or
+// declaration. where restricts the node kinds a metavariable may match.
+func compilePattern(src string, where map[string]string) (*pattern, error) {
+	nodes, err := parseSnippet(metavarPattern.ReplaceAllString(src, metavarPrefix+"$1"))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing pattern %q: %w", src, err)
+	}
+	if len(nodes) != 1 {
+		return nil, fmt.Errorf("pattern %q must be a single expression, statement or declaration", src)
+	}
+	p := &pattern{node: nodes[0], where: where}
+	names := p.metavars()
+	for name := range where {
+		if !names[name] {
+			return nil, fmt.Errorf("pattern %q has no metavariable $%s", src, name)
+		}
+	}
+	return p, nil
+}
+
+// metavars returns the names of the metavariables of the pattern.
+func (p *pattern) metavars() map[string]bool {
+	names := make(map[string]bool)
+	ast.Inspect(p.node, func(node ast.Node) bool {
+		if name, ok := metavarName(node); ok {
+			names[name] = true
+		}
+		return true
+	})
+	return names
+}
+
+// metavarName returns the name of the metavariable node stands for, if any.
+func metavarName(node ast.Node) (string, bool) {
+	ident, ok := node.(*ast.Ident)
+	if !ok {
+		return "", false
+	}
+	return strings.CutPrefix(ident.Name, metavarPrefix)
+}
+
+// stmtMetavarName returns the name of the metavariable node stands for if it is
+// a statement consisting of a metavariable alone.
+func stmtMetavarName(node ast.Node) (string, bool) {
+	stmt, ok := node.(*ast.ExprStmt)
+	if !ok {
+		return "", false
+	}
+	return metavarName(stmt.X)
+}
+
+// patternMatch is a node matched by a pattern and the nodes bound to its metavariables.
+type patternMatch struct {
+	node     ast.Node
+	bindings map[string]ast.Node
+}
+
+// find returns the outermost nodes of root matched by the pattern, in source order.
+func (p *pattern) find(root ast.Node) []*patternMatch {
+	var matches []*patternMatch
+	ast.Inspect(root, func(node ast.Node) bool {
+		if node == nil {
+			return false
+		}
+		bindings := make(map[string]ast.Node)
+		if p.match(p.node, node, bindings) {
+			matches = append(matches, &patternMatch{node: node, bindings: bindings})
+			return false
+		}
+		return true
+	})
+	return matches
+}
+
+var (
+	commentGroupType = reflect.TypeOf((*ast.CommentGroup)(nil))
+	objectType       = reflect.TypeOf((*ast.Object)(nil))
+	scopeType        = reflect.TypeOf((*ast.Scope)(nil))
+)
+
+// match reports whether node matches the pattern node pat, recording metavariable
+// bindings. Positions, comments and object resolution are ignored.
+func (p *pattern) match(pat, node ast.Node, bindings map[string]ast.Node) bool {
+	if name, ok := stmtMetavarName(pat); ok {
+		if _, isStmt := node.(ast.Stmt); isStmt {
+			return p.bind(name, node, bindings)
+		}
+		return false
+	}
+	if name, ok := metavarName(pat); ok {
+		if _, isExpr := node.(ast.Expr); isExpr {
+			return p.bind(name, node, bindings)
+		}
+		return false
+	}
+	return p.matchValue(reflect.ValueOf(pat), reflect.ValueOf(node), bindings)
+}
+
+// bind binds name to node, or checks that node equals the node already bound.
+func (p *pattern) bind(name string, node ast.Node, bindings map[string]ast.Node) bool {
+	if kind, ok := p.where[name]; ok && fmt.Sprintf("%T", node) != kind {
+		return false
+	}
+	if bound, ok := bindings[name]; ok {
+		return p.matchValue(reflect.ValueOf(bound), reflect.ValueOf(node), nil)
+	}
+	bindings[name] = node
+	return true
+}
+
+func (p *pattern) matchValue(pat, val reflect.Value, bindings map[string]ast.Node) bool {
+	if pat.Kind() == reflect.Interface {
+		if pat.IsNil() || val.IsNil() {
+			return pat.IsNil() && val.IsNil()
+		}
+		pat, val = pat.Elem(), val.Elem()
+	}
+	// Metavariables match nodes of other kinds, so check them first.
+	if patNode, ok := pat.Interface().(ast.Node); ok && bindings != nil && !pat.IsNil() {
+		valNode, isNode := val.Interface().(ast.Node)
+		_, isMetavar := metavarName(patNode)
+		_, isStmtMetavar := stmtMetavarName(patNode)
+		if isNode && !val.IsNil() && (isMetavar || isStmtMetavar) {
+			return p.match(patNode, valNode, bindings)
+		}
+	}
+	if pat.Type() != val.Type() {
+		return false
+	}
+	switch pat.Kind() {
+	case reflect.Pointer:
+		if pat.IsNil() || val.IsNil() {
+			return pat.IsNil() && val.IsNil()
+		}
+		return p.matchValue(pat.Elem(), val.Elem(), bindings)
+	case reflect.Struct:
+		for i := 0; i < pat.NumField(); i++ {
+			switch pat.Type().Field(i).Type {
+			case posType, commentGroupType, objectType, scopeType:
+				continue
+			}
+			if !p.matchValue(pat.Field(i), val.Field(i), bindings) {
+				return false
+			}
+		}
+		return true
+	case reflect.Slice:
+		if pat.Len() != val.Len() {
+			return false
+		}
+		for i := 0; i < pat.Len(); i++ {
+			if !p.matchValue(pat.Index(i), val.Index(i), bindings) {
+				return false
+			}
+		}
+		return true
+	default:
+		return pat.Interface() == val.Interface()
+	}
+}
+
+// sortedBindings returns the metavariable names of a match in alphabetical order.
+func (m *patternMatch) sortedBindings() []string {
+	names := make([]string, 0, len(m.bindings))
+	for name := range m.bindings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// nodeSource returns the source text of node in src, a file positioned in fset.
+func nodeSource(fset *token.FileSet, src []byte, node ast.Node) string {
+	return string(src[fset.Position(node.Pos()).Offset:fset.Position(node.End()).Offset])
+}
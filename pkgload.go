@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// isPackagePattern reports whether a command-line path is a package pattern
+// such as ./... rather than a file or folder.
+func isPackagePattern(path string) bool {
+	return strings.Contains(path, "...")
+}
+
+// loadPackages loads the packages matching patterns in the current folder
+// with go/packages. The go command applies build constraints for the current
+// platform, so the files of each package are exactly those a build would
+// compile, generated files included. Test variants are loaded unless -tests
+// excludes test files.
+func loadPackages(patterns []string) ([]*packages.Package, error) {
+	cfg := &packages.Config{
+		Mode:  packages.NeedName | packages.NeedFiles,
+		Tests: *testsMode != "exclude",
+	}
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return nil, fmt.Errorf("error loading packages %s: %w", strings.Join(patterns, " "), err)
+	}
+	return pkgs, nil
+}
+
+// packageFiles returns the paths of the source files of pkgs that are
+// converted under the -tests setting, sorted so that the files of a folder
+// and its subfolders are adjacent, and without the files test variants share
+// with the package they test.
+func packageFiles(pkgs []*packages.Package) []string {
+	seen := make(map[string]bool)
+	var paths []string
+	for _, pkg := range pkgs {
+		if pkg.Name == "main" && strings.HasSuffix(pkg.ID, ".test") {
+			// The generated main package of a test binary.
+			continue
+		}
+		for _, path := range pkg.GoFiles {
+			if !seen[path] && matchesTestsMode(filepath.Base(path)) {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// processPackages converts the files of the packages matching patterns, as
+// loaded by go/packages. Output paths are relative to the current folder.
+// Like in folder mode, failures are reported once every file has been visited.
+func processPackages(patterns []string) error {
+	pkgs, err := loadPackages(patterns)
+	if err != nil {
+		return err
+	}
+	root, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	for _, pkg := range pkgs {
+		for _, pkgErr := range pkg.Errors {
+			slog.Warn("error loading package", "package", pkg.PkgPath, "error", pkgErr.Msg)
+		}
+	}
+	paths := packageFiles(pkgs)
+	total := len(paths)
+	if total == 0 {
+		return fmt.Errorf("no Go files in packages matching %s", strings.Join(patterns, " "))
+	}
+
+	failures := processFiles(root, paths)
+	if len(failures) > 0 {
+		for _, failure := range failures {
+			slog.Error("failed file", "file", failure.path, "error", failure.err)
+		}
+		slog.Error("conversion finished with errors", "failed", len(failures), "total", total)
+		return fmt.Errorf("%d of %d files in %s failed to convert", len(failures), total, strings.Join(patterns, " "))
+	}
+	return nil
+}
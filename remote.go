@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Limits of remote inputs.
+var (
+	fetchLimit   = byteSize(10 << 20)
+	fetchTimeout = flag.Duration("fetch-timeout", time.Minute, "time limit for downloading an https:// file or cloning a git+https:// repository")
+)
+
+func init() {
+	flag.Var(&fetchLimit, "fetch-limit", "maximum size of an https:// file, or of the Go files of a git+https:// repository, such as 512KiB or 20MiB")
+}
+
+// isRemoteURL reports whether a command-line path is a remote input: an
+// https:// URL of a Go file or a git+https:// URL of a repository.
+func isRemoteURL(path string) bool {
+	return strings.HasPrefix(path, "https://") || strings.HasPrefix(path, "git+https://")
+}
+
+// processRemote converts a remote input. Documents go to the -out targets if
+// any are given, and to stdout otherwise, since there is no local folder to
+// write them next to.
+func processRemote(rawURL string) error {
+	if repoURL, ok := strings.CutPrefix(rawURL, "git+"); ok {
+		return processRemoteRepository(repoURL)
+	}
+	return processRemoteFile(rawURL)
+}
+
+// processRemoteFile downloads and converts a single Go file, named in positions
+// and metadata after the last element of the URL path. The file has no module,
+// so its metadata leaves out module and import path.
+func processRemoteFile(fileURL string) error {
+	u, err := url.Parse(fileURL)
+	if err != nil {
+		return fmt.Errorf("error parsing URL %s: %w", fileURL, err)
+	}
+	name := path.Base(u.Path)
+	if !strings.HasSuffix(name, ".go") {
+		return fmt.Errorf("URL %s does not name a .go file", fileURL)
+	}
+	if *typesMode {
+		return errors.New("-types cannot be used with an https:// file, it needs the source folder")
+	}
+
+	client := &http.Client{Timeout: *fetchTimeout}
+	resp, err := client.Get(fileURL)
+	if err != nil {
+		return fmt.Errorf("error downloading %s: %w", fileURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("error downloading %s: %s", fileURL, resp.Status)
+	}
+	src, err := io.ReadAll(io.LimitReader(resp.Body, int64(fetchLimit)+1))
+	if err != nil {
+		return fmt.Errorf("error downloading %s: %w", fileURL, err)
+	}
+	if len(src) > int(fetchLimit) {
+		return fmt.Errorf("%s is larger than the -fetch-limit of %d bytes", fileURL, fetchLimit)
+	}
+	slog.Info("downloaded", "url", fileURL, "bytes", len(src))
+	if err := checkUntrustedSize(name, int64(len(src))); err != nil {
+		return err
+	}
+
+	return isolateFile(name, func(name string) error {
+		astNode, err := convertMemorySource(name, src)
+		if err != nil {
+			return err
+		}
+		astNode.Meta.Module, astNode.Meta.ImportPath = "", ""
+		return writeMemoryDocument(name, astNode)
+	})
+}
+
+// processRemoteRepository makes a shallow clone of the Go files of a git
+// repository into a temporary folder and converts it like a local folder, with
+// output paths relative to the repository root. A branch or tag to clone may
+// follow the repository path after an @, as in https://host/repo.git@v1.2.0.
+//
+// The clone is blobless and its checkout sparse, so only the trees and the Go
+// files are downloaded, and it is stopped as soon as the downloaded objects
+// exceed the -fetch-limit.
+func processRemoteRepository(repoURL string) error {
+	u, err := url.Parse(repoURL)
+	if err != nil {
+		return fmt.Errorf("error parsing URL %s: %w", repoURL, err)
+	}
+	ref := ""
+	if i := strings.LastIndex(u.Path, "@"); i >= 0 {
+		u.Path, ref = u.Path[:i], u.Path[i+1:]
+	}
+
+	dir, err := os.MkdirTemp("", "go2json-clone-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, cancel := context.WithTimeout(context.Background(), *fetchTimeout)
+	defer cancel()
+	clone := []string{"clone", "--quiet", "--depth", "1", "--filter=blob:none", "--no-checkout"}
+	if ref != "" {
+		clone = append(clone, "--branch", ref)
+	}
+	clone = append(clone, "--", u.String(), dir)
+	steps := [][]string{
+		clone,
+		{"-C", dir, "sparse-checkout", "set", "--no-cone", "*.go"},
+		{"-C", dir, "checkout", "--quiet"},
+	}
+	for _, args := range steps {
+		err := runFetchingGit(ctx, filepath.Join(dir, ".git"), args...)
+		switch {
+		case errors.Is(ctx.Err(), context.DeadlineExceeded):
+			return fmt.Errorf("cloning %s exceeded the -fetch-timeout of %s", u, *fetchTimeout)
+		case errors.Is(err, errFetchLimit):
+			return fmt.Errorf("the Go files of %s are larger than the -fetch-limit of %d bytes", u, fetchLimit)
+		case err != nil:
+			return fmt.Errorf("error cloning %s: %w", u, err)
+		}
+	}
+	slog.Info("cloned", "url", u.String(), "ref", ref)
+
+	size := int64(0)
+	err = walkGoFiles(dir, func(path string) error {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if size > int64(fetchLimit) {
+		return fmt.Errorf("the Go files of %s are larger than the -fetch-limit of %d bytes", u, fetchLimit)
+	}
+
+	if len(outTargets) == 0 {
+		outTargets = append(outTargets, &streamTarget{path: "-"})
+	}
+	if *sourceRoot == "" {
+		*sourceRoot = dir
+	}
+	return processFolder(dir)
+}
+
+// errFetchLimit reports a clone stopped by runFetchingGit.
+var errFetchLimit = errors.New("downloaded objects exceed the -fetch-limit")
+
+// runFetchingGit runs git with args, stopping it with errFetchLimit once the
+// repository at gitDir grows larger than the -fetch-limit. Objects are
+// compressed, so by then the files they hold are larger than the limit too.
+func runFetchingGit(ctx context.Context, gitDir string, args ...string) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var out bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &out
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case err := <-done:
+			if err != nil {
+				return fmt.Errorf("%w: %s", err, strings.TrimSpace(out.String()))
+			}
+			return nil
+		case <-ticker.C:
+			if folderSize(gitDir) > uint64(fetchLimit) {
+				cancel()
+				<-done
+				return errFetchLimit
+			}
+		}
+	}
+}
+
+// folderSize returns the size of the files in dir and its subfolders. Files
+// removed while it walks the folder are skipped.
+func folderSize(dir string) uint64 {
+	var size uint64
+	filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return nil
+		}
+		if info, err := entry.Info(); err == nil {
+			size += uint64(info.Size())
+		}
+		return nil
+	})
+	return size
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/kobi2187/go2json/astjson"
+)
+
+func init() {
+	subcommands["repl"] = runREPL
+}
+
+const replHelp = `Type a Go expression, declaration or statement to see its JSON AST.
+Input continues on the next line while braces, brackets or parentheses are open.
+Commands:
+  :load FILE   convert a Go source file
+  :history     list previous inputs
+  :redo N      run history entry N again
+  :help        show this help
+  :quit        exit`
+
+// runREPL reads Go snippets from stdin and prints their JSON AST. Inputs are
+// kept in a history that persists in ~/.go2json_history across sessions.
+func runREPL(args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(args, " "))
+	}
+
+	historyPath := ""
+	if home, err := os.UserHomeDir(); err == nil {
+		historyPath = filepath.Join(home, ".go2json_history")
+	}
+	history := loadREPLHistory(historyPath)
+
+	fmt.Println(replHelp)
+	scanner := bufio.NewScanner(os.Stdin)
+	for {
+		input, ok := readREPLInput(scanner)
+		if !ok {
+			fmt.Println()
+			return scanner.Err()
+		}
+		if input == "" {
+			continue
+		}
+
+		command, arg, _ := strings.Cut(input, " ")
+		arg = strings.TrimSpace(arg)
+		switch command {
+		case ":quit", ":q":
+			return nil
+		case ":help":
+			fmt.Println(replHelp)
+			continue
+		case ":history":
+			for i, entry := range history {
+				fmt.Printf("%4d  %s\n", i+1, strings.ReplaceAll(entry, "\n", "\n      "))
+			}
+			continue
+		case ":redo":
+			n, err := strconv.Atoi(arg)
+			if err != nil || n < 1 || n > len(history) {
+				fmt.Println("error: no such history entry")
+				continue
+			}
+			input = history[n-1]
+			fmt.Println(input)
+		}
+
+		history = append(history, input)
+		appendREPLHistory(historyPath, input)
+		if err := evalREPLInput(os.Stdout, input); err != nil {
+			fmt.Println("error:", err)
+		}
+	}
+}
+
+// readREPLInput reads one input, continuing over several lines while
+// brackets are left open. It returns false at the end of stdin.
+func readREPLInput(scanner *bufio.Scanner) (string, bool) {
+	var lines []string
+	prompt := "go2json> "
+	for {
+		fmt.Print(prompt)
+		if !scanner.Scan() {
+			return "", false
+		}
+		lines = append(lines, scanner.Text())
+		input := strings.TrimSpace(strings.Join(lines, "\n"))
+		if strings.HasPrefix(input, ":") || bracketDepth(input) <= 0 {
+			return input, true
+		}
+		prompt = "     ...> "
+	}
+}
+
+// bracketDepth returns the number of brackets left open in src.
+func bracketDepth(src string) int {
+	depth := 0
+	var s scanner.Scanner
+	s.Init(token.NewFileSet().AddFile("", -1, len(src)), []byte(src), nil, 0)
+	for {
+		_, tok, _ := s.Scan()
+		switch tok {
+		case token.EOF:
+			return depth
+		case token.LBRACE, token.LBRACK, token.LPAREN:
+			depth++
+		case token.RBRACE, token.RBRACK, token.RPAREN:
+			depth--
+		}
+	}
+}
+
+// evalREPLInput converts a REPL input, either a :load command or a Go snippet, and prints its AST to w.
+func evalREPLInput(w io.Writer, input string) error {
+	if path, ok := strings.CutPrefix(input, ":load "); ok {
+		astNode, err := convertFile(strings.TrimSpace(path))
+		if err != nil {
+			return err
+		}
+		return encodeAST(w, astNode, "  ")
+	}
+	if strings.HasPrefix(input, ":") {
+		return fmt.Errorf("unknown command %s, type :help for help", input)
+	}
+
+	nodes, err := parseSnippet(input)
+	if err != nil {
+		return err
+	}
+	var astNodes []*astjson.ASTNode
+	for _, node := range nodes {
+		astNodes = append(astNodes, newMarshaler(nil, nil).Marshal(node))
+	}
+	if len(astNodes) == 1 {
+		return encodeAST(w, astNodes[0], "  ")
+	}
+	return encodeAST(w, astNodes, "  ")
+}
+
+// parseSnippet parses src as a Go file, an expression, a list of declarations
+// or a list of statements, in that order, and returns the resulting nodes.
+func parseSnippet(src string) ([]ast.Node, error) {
+	fset := token.NewFileSet()
+	if strings.HasPrefix(src, "package ") {
+		file, err := parser.ParseFile(fset, "snippet.go", src, parser.AllErrors)
+		if err != nil {
+			return nil, err
+		}
+		return []ast.Node{file}, nil
+	}
+
+	if expr, err := parser.ParseExprFrom(fset, "snippet.go", src, parser.AllErrors); err == nil {
+		return []ast.Node{expr}, nil
+	}
+
+	if file, err := parser.ParseFile(fset, "snippet.go", "package snippet\n"+src, parser.AllErrors); err == nil {
+		var nodes []ast.Node
+		for _, decl := range file.Decls {
+			nodes = append(nodes, decl)
+		}
+		return nodes, nil
+	}
+
+	file, err := parser.ParseFile(fset, "snippet.go", "package snippet\nfunc _() {\n"+src+"\n}", parser.AllErrors)
+	if err != nil {
+		return nil, errors.New("input is not a valid Go expression, declaration or statement")
+	}
+	var nodes []ast.Node
+	for _, stmt := range file.Decls[0].(*ast.FuncDecl).Body.List {
+		nodes = append(nodes, stmt)
+	}
+	return nodes, nil
+}
+
+// loadREPLHistory reads the persisted history; entries are separated by NUL bytes
+// because inputs may span several lines.
+func loadREPLHistory(path string) []string {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || len(data) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\x00"), "\x00")
+}
+
+// appendREPLHistory persists a single input. Failures only cost the history, so they are ignored.
+func appendREPLHistory(path, input string) {
+	if path == "" {
+		return
+	}
+	historyFile, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return
+	}
+	defer historyFile.Close()
+	historyFile.WriteString(input + "\x00")
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/token"
+	"go/types"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kobi2187/go2json/astjson"
+)
+
+// fileReports returns the reports of a root node, creating them on first use.
+func fileReports(n *astjson.ASTNode) *astjson.FileReports {
+	if n.Reports == nil {
+		n.Reports = &astjson.FileReports{}
+	}
+	return n.Reports
+}
+
+// unresolvedIdents returns the sorted names of identifiers in file that do not resolve
+// to a declaration in the file, a predeclared identifier or a member of a dot-imported
+// package. This approximates the file's free variables without full type checking;
+// declarations from other files of the same package are reported as well.
+func unresolvedIdents(file *ast.File) []string {
+	var dotScopes []*types.Scope
+	imported := make(map[string]bool)
+	for _, imp := range file.Imports {
+		path, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+		if imp.Name == nil || imp.Name.Name != "." {
+			imported[importName(imp.Name, path)] = true
+			continue
+		}
+		// Packages that cannot be imported are ignored; their members stay unresolved.
+		if pkg, err := importer.Default().Import(path); err == nil {
+			dotScopes = append(dotScopes, pkg.Scope())
+		}
+	}
+
+	seen := make(map[string]bool)
+	names := []string{}
+	for _, ident := range file.Unresolved {
+		if seen[ident.Name] || imported[ident.Name] || types.Universe.Lookup(ident.Name) != nil {
+			continue
+		}
+		seen[ident.Name] = true
+		dotImported := false
+		for _, scope := range dotScopes {
+			if scope.Lookup(ident.Name) != nil {
+				dotImported = true
+				break
+			}
+		}
+		if !dotImported {
+			names = append(names, ident.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// importName returns the name an import declares in the file: the explicit name if
+// given, otherwise the last path element with any major version suffix removed.
+func importName(name *ast.Ident, path string) string {
+	if name != nil {
+		return name.Name
+	}
+	elems := strings.Split(path, "/")
+	last := elems[len(elems)-1]
+	if len(elems) > 1 && isMajorVersion(last) {
+		last = elems[len(elems)-2]
+	}
+	// gopkg.in style paths carry the version after a dot, e.g. yaml.v3.
+	if i := strings.LastIndex(last, "."); i > 0 && isMajorVersion(last[i+1:]) {
+		last = last[:i]
+	}
+	return last
+}
+
+// isMajorVersion reports whether elem looks like a major version suffix such as v2.
+func isMajorVersion(elem string) bool {
+	if len(elem) < 2 || elem[0] != 'v' {
+		return false
+	}
+	_, err := strconv.Atoi(elem[1:])
+	return err == nil
+}
+
+// initializationReport collects the init functions and package-level variable
+// initializers of file. If info is not nil, initializers are annotated with the
+// initialization order determined by the type checker.
+func initializationReport(fset *token.FileSet, info *types.Info, file *ast.File) *astjson.InitReport {
+	order := make(map[ast.Expr]int)
+	if info != nil {
+		for i, initializer := range info.InitOrder {
+			order[initializer.Rhs] = i + 1
+		}
+	}
+
+	report := &astjson.InitReport{Funcs: []*astjson.InitFunc{}, Vars: []*astjson.InitVar{}}
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Recv == nil && d.Name.Name == "init" {
+				initFunc := &astjson.InitFunc{Line: sourcePosition(fset, d.Pos()).Line}
+				initFunc.Start, initFunc.End = sourceRange(fset, d)
+				report.Funcs = append(report.Funcs, initFunc)
+			}
+		case *ast.GenDecl:
+			if d.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range d.Specs {
+				valueSpec := spec.(*ast.ValueSpec)
+				if len(valueSpec.Values) == 0 {
+					continue
+				}
+				if len(valueSpec.Values) == 1 && len(valueSpec.Names) > 1 {
+					// A single multi-value expression initializes all names at once.
+					initVar := &astjson.InitVar{
+						Names: identNames(valueSpec.Names),
+						Value: types.ExprString(valueSpec.Values[0]),
+						Line:  sourcePosition(fset, valueSpec.Pos()).Line,
+						Order: order[valueSpec.Values[0]],
+					}
+					initVar.Start, initVar.End = sourceRange(fset, valueSpec.Values[0])
+					report.Vars = append(report.Vars, initVar)
+					continue
+				}
+				for i, value := range valueSpec.Values {
+					initVar := &astjson.InitVar{
+						Names: identNames(valueSpec.Names[i : i+1]),
+						Value: types.ExprString(value),
+						Line:  sourcePosition(fset, valueSpec.Names[i].Pos()).Line,
+						Order: order[value],
+					}
+					initVar.Start, initVar.End = sourceRange(fset, value)
+					report.Vars = append(report.Vars, initVar)
+				}
+			}
+		}
+	}
+	return report
+}
+
+// sourcePosition returns the position of pos for the output, or the zero
+// Position, which is left out, if -no-positions is set.
+func sourcePosition(fset *token.FileSet, pos token.Pos) token.Position {
+	if *noPositions {
+		return token.Position{}
+	}
+	return fset.Position(pos)
+}
+
+// sourceRange returns the byte offsets of the start and end of node for the
+// output, or zeros, which are left out, if -no-positions is set.
+func sourceRange(fset *token.FileSet, node ast.Node) (start, end int) {
+	return sourcePosition(fset, node.Pos()).Offset, sourcePosition(fset, node.End()).Offset
+}
+
+// identNames returns the names of the given identifiers.
+func identNames(idents []*ast.Ident) []string {
+	names := make([]string, len(idents))
+	for i, ident := range idents {
+		names[i] = ident.Name
+	}
+	return names
+}
+
+// instantiationReport lists the instantiations of generic functions and types
+// that occur in file, in source order. It needs type information and returns
+// nil if info is nil.
+func instantiationReport(fset *token.FileSet, info *types.Info, file *ast.File) []*astjson.Instance {
+	if info == nil {
+		return nil
+	}
+	instances := []*astjson.Instance{}
+	ast.Inspect(file, func(node ast.Node) bool {
+		ident, ok := node.(*ast.Ident)
+		if !ok {
+			return true
+		}
+		inst, ok := info.Instances[ident]
+		if !ok {
+			return true
+		}
+		name := ident.Name
+		if obj := info.Uses[ident]; obj != nil && obj.Pkg() != nil {
+			name = obj.Pkg().Path() + "." + obj.Name()
+		}
+		typeArgs := make([]string, inst.TypeArgs.Len())
+		for i := range typeArgs {
+			typeArgs[i] = inst.TypeArgs.At(i).String()
+		}
+		position := sourcePosition(fset, ident.Pos())
+		instances = append(instances, &astjson.Instance{
+			Name:     name,
+			TypeArgs: typeArgs,
+			Type:     inst.Type.String(),
+			Line:     position.Line,
+			Column:   position.Column,
+		})
+		return true
+	})
+	return instances
+}
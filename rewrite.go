@@ -0,0 +1,198 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+func init() {
+	subcommands["rewrite"] = runRewrite
+}
+
+const rewriteUsage = `usage:
+  go2json rewrite [-w] RULES PATH...
+
+PATH is a file, a folder, or a folder followed by /... to include its subfolders.`
+
+// RuleFile holds rewrite rules, applied one after the other.
+type RuleFile struct {
+	Rules []*RewriteRule `json:"rules"`
+}
+
+// RewriteRule replaces code matching Pattern by Replacement. Both are Go code in
+// which $name stands for a metavariable; the code bound to a metavariable in the
+// pattern is substituted for it in the replacement. Where restricts metavariables
+// to node kinds as named in the JSON output, e.g. {"msg": "*ast.BasicLit"}.
+type RewriteRule struct {
+	Name        string            `json:"name"`
+	Pattern     string            `json:"pattern"`
+	Replacement string            `json:"replacement"`
+	Where       map[string]string `json:"where,omitempty"`
+
+	pattern *pattern
+}
+
+// runRewrite applies a rule file to Go files, printing a diff of the changes or,
+// with -w, writing them back.
+func runRewrite(args []string) error {
+	flags := flag.NewFlagSet("rewrite", flag.ContinueOnError)
+	write := flags.Bool("w", false, "write the rewritten files back instead of printing a diff")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() < 2 {
+		fmt.Fprintln(os.Stderr, rewriteUsage)
+		return errors.New("expected a rule file and at least one path")
+	}
+
+	rules, err := loadRules(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	var paths []string
+	for _, arg := range flags.Args()[1:] {
+		files, err := goFilesIn(arg)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, files...)
+	}
+
+	for _, path := range paths {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("error reading Go source file %s: %w", path, err)
+		}
+		rewritten, err := rewriteFile(path, src, rules)
+		if err != nil {
+			return err
+		}
+		if *write {
+			if string(rewritten) != string(src) {
+				if err := os.WriteFile(path, rewritten, 0o644); err != nil {
+					return fmt.Errorf("error writing %s: %w", path, err)
+				}
+			}
+			continue
+		}
+		fmt.Print(unifiedDiff(path, src, rewritten))
+	}
+	return nil
+}
+
+// loadRules reads and compiles a rule file.
+func loadRules(path string) ([]*RewriteRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading rule file %s: %w", path, err)
+	}
+	var ruleFile RuleFile
+	var json = jsoniter.ConfigCompatibleWithStandardLibrary
+	if err := json.Unmarshal(data, &ruleFile); err != nil {
+		return nil, fmt.Errorf("error decoding rule file %s: %w", path, err)
+	}
+	for i, rule := range ruleFile.Rules {
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule %d", i+1)
+		}
+		rule.pattern, err = compilePattern(rule.Pattern, rule.Where)
+		if err != nil {
+			return nil, fmt.Errorf("error in %s: %w", rule.Name, err)
+		}
+		names := rule.pattern.metavars()
+		for _, m := range metavarPattern.FindAllStringSubmatch(rule.Replacement, -1) {
+			if !names[m[1]] {
+				return nil, fmt.Errorf("error in %s: replacement uses $%s, which the pattern does not bind", rule.Name, m[1])
+			}
+		}
+	}
+	return ruleFile.Rules, nil
+}
+
+// rewriteFile applies the rules in turn to the source of path.
+func rewriteFile(path string, src []byte, rules []*RewriteRule) ([]byte, error) {
+	for _, rule := range rules {
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing Go source file %s: %w", path, err)
+		}
+		matches := rule.pattern.find(file)
+		if len(matches) == 0 {
+			continue
+		}
+		var splices []splice
+		for _, match := range matches {
+			splices = append(splices, splice{
+				start: fset.Position(match.node.Pos()).Offset,
+				end:   fset.Position(match.node.End()).Offset,
+				text:  expandReplacement(rule.Replacement, fset, src, match),
+			})
+		}
+		src, err = applySplices(path, src, splices)
+		if err != nil {
+			return nil, fmt.Errorf("error applying %s: %w", rule.Name, err)
+		}
+		slog.Debug("rewrite rule applied", "file", path, "rule", rule.Name, "matches", len(matches))
+	}
+	return src, nil
+}
+
+// expandReplacement substitutes the code bound by a match for the metavariables
+// of a replacement. Bound expressions that are not operands are parenthesized
+// so they keep their meaning in any context; key-value pairs of composite
+// literals are not, since (k: v) is not valid Go.
+func expandReplacement(replacement string, fset *token.FileSet, src []byte, match *patternMatch) string {
+	return metavarPattern.ReplaceAllStringFunc(replacement, func(metavar string) string {
+		node := match.bindings[metavar[1:]]
+		text := nodeSource(fset, src, node)
+		switch node.(type) {
+		case *ast.BinaryExpr, *ast.UnaryExpr, *ast.StarExpr:
+			return "(" + text + ")"
+		}
+		return text
+	})
+}
+
+// goFilesIn lists the Go files named by a command-line path: a file, the files of
+// a folder, or with a /... suffix the files of a folder and its subfolders.
+func goFilesIn(path string) ([]string, error) {
+	var files []string
+	collect := func(file string) error {
+		files = append(files, file)
+		return nil
+	}
+	if root, ok := strings.CutSuffix(path, "/..."); ok {
+		if err := walkGoFiles(root, collect); err != nil {
+			return nil, fmt.Errorf("error walking %s: %w", root, err)
+		}
+		return files, nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("error accessing path %s: %w", path, err)
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading folder %s: %w", path, err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".go") && matchesTestsMode(entry.Name()) {
+			files = append(files, filepath.Join(path, entry.Name()))
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"flag"
+	"fmt"
+
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/kobi2187/go2json/astjson"
+)
+
+func init() {
+	subcommands["selftest"] = runSelftest
+}
+
+const selftestUsage = `usage:
+  go2json selftest [-keep DIR]
+
+Converts a built-in corpus of tricky Go files with the current flags, checks
+every document against the output schema and that it survives a JSON round trip.`
+
+// selftestCorpus holds the source files converted by the selftest command.
+//
+//go:embed testdata/selftest
+var selftestCorpus embed.FS
+
+// selftestNestingDepth is the nesting depth of the generated deeply nested file,
+// well within the limits of go/parser.
+const selftestNestingDepth = 200
+
+// strictJSON decodes documents, rejecting fields the schema does not define.
+var strictJSON = jsoniter.Config{
+	EscapeHTML:             true,
+	SortMapKeys:            true,
+	ValidateJsonRawMessage: true,
+	DisallowUnknownFields:  true,
+}.Froze()
+
+// runSelftest converts the corpus and reports the result of each file.
+func runSelftest(args []string) error {
+	flags := flag.NewFlagSet("selftest", flag.ContinueOnError)
+	keep := flags.String("keep", "", "write the corpus to the given folder and keep it, instead of a temporary folder")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, selftestUsage)
+		return errors.New("unexpected arguments")
+	}
+
+	dir := *keep
+	if dir == "" {
+		tmp, err := os.MkdirTemp("", "go2json-selftest-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tmp)
+		dir = tmp
+	}
+	files, err := writeSelftestCorpus(dir)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	failed := 0
+	for _, path := range files {
+		var nodes int
+		err := isolateFile(path, func(path string) (err error) {
+			nodes, err = selftestFile(path)
+			return err
+		})
+		if err != nil {
+			failed++
+			fmt.Fprintf(w, "FAIL\t%s\t%s\n", filepath.Base(path), err)
+			continue
+		}
+		fmt.Fprintf(w, "ok\t%s\t%d nodes\n", filepath.Base(path), nodes)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d corpus files failed", failed, len(files))
+	}
+	fmt.Printf("all %d corpus files passed\n", len(files))
+	return nil
+}
+
+// writeSelftestCorpus writes the embedded corpus and the generated nesting file
+// to dir and returns their paths.
+func writeSelftestCorpus(dir string) ([]string, error) {
+	corpus, err := fs.Sub(selftestCorpus, "testdata/selftest")
+	if err != nil {
+		return nil, err
+	}
+	entries, err := fs.ReadDir(corpus, ".")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	var files []string
+	write := func(name string, src []byte) error {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, src, 0o644); err != nil {
+			return fmt.Errorf("error writing corpus file %s: %w", path, err)
+		}
+		files = append(files, path)
+		return nil
+	}
+	for _, entry := range entries {
+		src, err := fs.ReadFile(corpus, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		if err := write(entry.Name(), src); err != nil {
+			return nil, err
+		}
+	}
+	if err := write("nesting.go", nestingSource(selftestNestingDepth)); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// nestingSource generates a file with expressions, literals, function literals
+// and blocks nested depth levels deep.
+func nestingSource(depth int) []byte {
+	var b bytes.Buffer
+	b.WriteString("package nesting\n\n")
+	fmt.Fprintf(&b, "var Parens = %s1%s\n", strings.Repeat("(", depth), strings.Repeat(")", depth))
+	fmt.Fprintf(&b, "var Sum = 1%s\n", strings.Repeat(" + 1", depth))
+	fmt.Fprintf(&b, "var Slices = %s%s%s\n", strings.Repeat("[]", depth), "int", strings.Repeat("{", depth)+strings.Repeat("}", depth))
+	fmt.Fprintf(&b, "var Funcs = %s0%s\n", strings.Repeat("func() int { return ", depth), strings.Repeat(" }()", depth))
+	fmt.Fprintf(&b, "\nfunc Blocks(x int) {\n%sx++\n%s}\n", strings.Repeat("if x > 0 {\n", depth), strings.Repeat("}\n", depth))
+	return b.Bytes()
+}
+
+// selftestFile converts a corpus file, validates the document and checks that
+// decoding and re-encoding it reproduces the same JSON. It returns the number of nodes.
+func selftestFile(path string) (int, error) {
+	astNode, err := convertFile(path)
+	if err != nil {
+		return 0, err
+	}
+	var encoded bytes.Buffer
+	if err := encodeAST(&encoded, astNode, ""); err != nil {
+		return 0, fmt.Errorf("error encoding document: %w", err)
+	}
+
+	decoded := &astjson.ASTNode{}
+	if err := strictJSON.Unmarshal(encoded.Bytes(), decoded); err != nil {
+		return 0, fmt.Errorf("document does not match the schema: %w", err)
+	}
+	if err := validateDocument(decoded); err != nil {
+		return 0, err
+	}
+
+	var reencoded bytes.Buffer
+	if err := encodeAST(&reencoded, decoded, ""); err != nil {
+		return 0, fmt.Errorf("error re-encoding document: %w", err)
+	}
+	if !bytes.Equal(encoded.Bytes(), reencoded.Bytes()) {
+		return 0, fmt.Errorf("round trip changed the document at byte %d", firstDifference(encoded.Bytes(), reencoded.Bytes()))
+	}
+	return countNodes(decoded), nil
+}
+
+// validateDocument checks the invariants of a document that the JSON decoder
+// cannot: the root is a versioned file and every node names its kind exactly once.
+func validateDocument(doc *astjson.ASTNode) error {
+	if doc.FormatVersion != astjson.FormatVersion {
+		return fmt.Errorf("document has format version %d, expected %d", doc.FormatVersion, astjson.FormatVersion)
+	}
+	if doc.Meta == nil {
+		return errors.New("document has no file metadata")
+	}
+	kind := func(astNode *astjson.ASTNode) (string, error) {
+		switch {
+		case astNode.Kind != nil && astNode.Type != "":
+			return "", fmt.Errorf("node %s has both a type and a kind", astNode.Type)
+		case astNode.Kind != nil:
+			if *astNode.Kind < 0 || *astNode.Kind >= len(doc.KindTable) {
+				return "", fmt.Errorf("kind %d is outside the kind table", *astNode.Kind)
+			}
+			return doc.KindTable[*astNode.Kind], nil
+		case astNode.Type == "":
+			return "", errors.New("node has neither a type nor a kind")
+		}
+		return astNode.Type, nil
+	}
+	if root, err := kind(doc); err != nil || root != "*ast.File" {
+		return fmt.Errorf("root node is not a file: %q %v", root, err)
+	}
+
+	var check func(astNode *astjson.ASTNode) error
+	check = func(astNode *astjson.ASTNode) error {
+		if _, err := kind(astNode); err != nil {
+			return err
+		}
+		for _, child := range astNode.Children {
+			if child == nil {
+				return errors.New("null child node")
+			}
+			if child.FormatVersion != 0 || child.KindTable != nil || child.Meta != nil {
+				return errors.New("document fields set on a nested node")
+			}
+			if err := check(child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return check(doc)
+}
+
+// firstDifference returns the offset of the first byte where a and b differ.
+func firstDifference(a, b []byte) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return n
+}
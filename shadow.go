@@ -0,0 +1,49 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"github.com/kobi2187/go2json/astjson"
+)
+
+// shadowReport lists the declarations in file that shadow a declaration of an
+// enclosing scope, in source order: locals shadowing other locals, package
+// members or imports. Shadowing predeclared identifiers such as len is not
+// reported. It needs the scopes of the type checker and returns nil if info is nil.
+func shadowReport(fset *token.FileSet, info *types.Info, file *ast.File) []*astjson.Shadow {
+	if info == nil {
+		return nil
+	}
+	shadows := []*astjson.Shadow{}
+	ast.Inspect(file, func(node ast.Node) bool {
+		ident, ok := node.(*ast.Ident)
+		if !ok || ident.Name == "_" {
+			return true
+		}
+		obj := info.Defs[ident]
+		if obj == nil || obj.Parent() == nil || obj.Parent().Parent() == nil {
+			return true
+		}
+		_, outer := obj.Parent().Parent().LookupParent(ident.Name, ident.Pos())
+		if outer == nil || outer.Parent() == types.Universe {
+			return true
+		}
+		position := sourcePosition(fset, ident.Pos())
+		shadowed := sourcePosition(fset, outer.Pos())
+		shadow := &astjson.Shadow{
+			Name:           ident.Name,
+			Line:           position.Line,
+			Column:         position.Column,
+			ShadowedLine:   shadowed.Line,
+			ShadowedColumn: shadowed.Column,
+		}
+		if shadowed.Filename != position.Filename {
+			shadow.ShadowedFile = shadowed.Filename
+		}
+		shadows = append(shadows, shadow)
+		return true
+	})
+	return shadows
+}
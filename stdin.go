@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/kobi2187/go2json/astjson"
+)
+
+var stdinFilename = flag.String("filename", "stdin.go", "file name of the source read from stdin when the path is -, used in positions, metadata and CODEOWNERS lookups")
+
+// memorySource holds a source that is not read from a local file, such as the
+// source read from stdin when the path is -. It stands in for the file named
+// memoryFilename, which need not exist.
+var (
+	memorySource   []byte
+	memoryFilename string
+)
+
+// readSource returns the content of a source file, or the source held in
+// memory for the file named memoryFilename.
+func readSource(sourceFilePath string) ([]byte, error) {
+	if memorySource != nil && sourceFilePath == memoryFilename {
+		return memorySource, nil
+	}
+	if err := checkUntrustedFileSize(sourceFilePath); err != nil {
+		return nil, err
+	}
+	src, err := os.ReadFile(sourceFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading Go source file %s: %w", sourceFilePath, err)
+	}
+	return src, nil
+}
+
+// processStdin converts the source read from stdin. Its document goes to the
+// -out targets if any are given, and to stdout otherwise.
+func processStdin() error {
+	var stdin io.Reader = os.Stdin
+	if *untrustedMode {
+		stdin = io.LimitReader(stdin, int64(untrustedMaxSize)+1)
+	}
+	src, err := io.ReadAll(stdin)
+	if err != nil {
+		return fmt.Errorf("error reading Go source from stdin: %w", err)
+	}
+	if err := checkUntrustedSize(*stdinFilename, int64(len(src))); err != nil {
+		return err
+	}
+	astNode, err := convertMemorySource(*stdinFilename, src)
+	if err != nil {
+		return err
+	}
+	return writeMemoryDocument(*stdinFilename, astNode)
+}
+
+// convertMemorySource converts a source held in memory as the file name.
+func convertMemorySource(name string, src []byte) (*astjson.ASTNode, error) {
+	memoryFilename, memorySource = name, append([]byte{}, src...)
+	return convertFile(name)
+}
+
+// writeMemoryDocument writes the document of a source held in memory to the
+// -out targets if any are given, and to stdout otherwise.
+func writeMemoryDocument(name string, astNode *astjson.ASTNode) error {
+	if len(outTargets) > 0 {
+		return outTargets.write(filepath.Dir(name), name, astNode)
+	}
+	return encodeAST(os.Stdout, astNode, conversionOptions().Indent)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/kobi2187/go2json/astjson"
+)
+
+// streamOutput is set with -stream to write documents as they are converted.
+var streamOutput bool
+
+func init() {
+	flag.BoolVar(&streamOutput, "stream", false, "write each document next to its source file as the nodes are converted instead of building the tree first, so memory grows with the depth of the tree rather than its size; streamed documents are compact and list the children of each node last (not with -out, -o, -packages, -string-table or -chunk-above)")
+}
+
+// processStreamedFile converts a source file straight into its document next
+// to the source file. The tree of the file is never held in memory as a whole.
+func processStreamedFile(sourceFilePath string) error {
+	c, err := prepareFile(sourceFilePath)
+	if err != nil {
+		return err
+	}
+	// Annotations are merged into each node as it is created, since the
+	// tree is not there to merge them into afterwards.
+	if sidecarAnnotations != nil {
+		annotate := c.m.Annotate
+		c.m.Annotate = func(node ast.Node, astNode *astjson.ASTNode) {
+			annotate(node, astNode)
+			sidecarAnnotations.merge(astNode)
+		}
+	}
+
+	newBaseName, err := outputName(sourceFilePath)
+	if err != nil {
+		return err
+	}
+	newFilePath := filepath.Join(filepath.Dir(sourceFilePath), newBaseName)
+	outputFile, err := os.Create(newFilePath)
+	if err != nil {
+		return fmt.Errorf("error creating output file %s: %w", newFilePath, err)
+	}
+	defer outputFile.Close()
+	if err := c.m.MarshalStream(outputFile, c.file, c.head); err != nil {
+		return fmt.Errorf("error serializing AST to JSON for file %s: %w", sourceFilePath, err)
+	}
+
+	slog.Info("AST generated", "file", sourceFilePath, "output", newFilePath)
+	return nil
+}
@@ -0,0 +1,36 @@
+package main
+
+import "github.com/kobi2187/go2json/astjson"
+
+// internKinds replaces the kind name of every node of a document by a reference
+// into a table of kind names stored once on the root node, which considerably
+// shrinks large documents.
+func internKinds(root *astjson.ASTNode) {
+	index := make(map[string]int)
+	var collect func(astNode *astjson.ASTNode)
+	collect = func(astNode *astjson.ASTNode) {
+		if _, ok := index[astNode.Type]; !ok {
+			index[astNode.Type] = len(root.KindTable)
+			root.KindTable = append(root.KindTable, astNode.Type)
+		}
+		for _, child := range astNode.Children {
+			collect(child)
+		}
+	}
+	collect(root)
+
+	// Nodes of the same kind share their reference.
+	refs := make([]int, len(root.KindTable))
+	for i := range refs {
+		refs[i] = i
+	}
+	var replace func(astNode *astjson.ASTNode)
+	replace = func(astNode *astjson.ASTNode) {
+		astNode.Kind = &refs[index[astNode.Type]]
+		astNode.Type = ""
+		for _, child := range astNode.Children {
+			replace(child)
+		}
+	}
+	replace(root)
+}
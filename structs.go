@@ -0,0 +1,147 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"runtime"
+	"strconv"
+
+	"github.com/kobi2187/go2json/astjson"
+)
+
+// hostSizes computes type sizes for the gc compiler on the host architecture.
+var hostSizes = types.SizesFor("gc", runtime.GOARCH)
+
+// structLayoutReport lists the struct type declarations of file, including local
+// ones, in source order. With type information, non-generic structs are
+// annotated with their memory layout.
+func structLayoutReport(fset *token.FileSet, info *types.Info, file *ast.File) []*astjson.StructLayout {
+	layouts := []*astjson.StructLayout{}
+	ast.Inspect(file, func(node ast.Node) bool {
+		spec, ok := node.(*ast.TypeSpec)
+		if !ok {
+			return true
+		}
+		structType, ok := spec.Type.(*ast.StructType)
+		if !ok {
+			return true
+		}
+		layout := &astjson.StructLayout{
+			Name:   spec.Name.Name,
+			Line:   sourcePosition(fset, spec.Pos()).Line,
+			Fields: structFields(structType),
+		}
+		layout.Start, layout.End = sourceRange(fset, spec)
+		if info != nil && spec.TypeParams == nil {
+			if obj := info.Defs[spec.Name]; obj != nil {
+				if underlying, ok := obj.Type().Underlying().(*types.Struct); ok && underlying.NumFields() == len(layout.Fields) {
+					addStructSizes(layout, underlying)
+				}
+			}
+		}
+		layouts = append(layouts, layout)
+		return true
+	})
+	return layouts
+}
+
+// structFields lists the fields of a struct type expression.
+func structFields(structType *ast.StructType) []*astjson.StructField {
+	fields := []*astjson.StructField{}
+	for _, field := range structType.Fields.List {
+		typeString := types.ExprString(field.Type)
+		tag := ""
+		if field.Tag != nil {
+			tag, _ = strconv.Unquote(field.Tag.Value)
+		}
+		if len(field.Names) == 0 {
+			name := embeddedFieldName(field.Type)
+			fields = append(fields, &astjson.StructField{
+				Name:     name,
+				Type:     typeString,
+				Tag:      tag,
+				Embedded: true,
+				Exported: ast.IsExported(name),
+			})
+			continue
+		}
+		for _, ident := range field.Names {
+			fields = append(fields, &astjson.StructField{
+				Name:     ident.Name,
+				Type:     typeString,
+				Tag:      tag,
+				Exported: ast.IsExported(ident.Name),
+			})
+		}
+	}
+	return fields
+}
+
+// embeddedFieldName returns the name of an embedded field: the name of its type
+// without pointer, package qualifier or type arguments.
+func embeddedFieldName(expr ast.Expr) string {
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.SelectorExpr:
+			return e.Sel.Name
+		case *ast.Ident:
+			return e.Name
+		default:
+			return types.ExprString(expr)
+		}
+	}
+}
+
+// addStructSizes annotates a layout with the sizes, alignments and field offsets
+// of its type. Types whose size cannot be computed, such as those with invalid
+// field types, are left without sizes.
+func addStructSizes(layout *astjson.StructLayout, structType *types.Struct) {
+	for i := 0; i < structType.NumFields(); i++ {
+		if !validType(structType.Field(i).Type()) {
+			return
+		}
+	}
+	vars := make([]*types.Var, structType.NumFields())
+	for i := range vars {
+		vars[i] = structType.Field(i)
+	}
+	offsets := hostSizes.Offsetsof(vars)
+	size, align := hostSizes.Sizeof(structType), hostSizes.Alignof(structType)
+	layout.Size, layout.Align = &size, &align
+	for i, field := range layout.Fields {
+		fieldSize, fieldAlign := hostSizes.Sizeof(vars[i].Type()), hostSizes.Alignof(vars[i].Type())
+		field.Offset, field.Size, field.Align = &offsets[i], &fieldSize, &fieldAlign
+	}
+}
+
+// validType reports whether t and the types it is composed of are free of
+// invalid types left by type errors, which have no size.
+func validType(t types.Type) bool {
+	switch t := t.(type) {
+	case *types.Basic:
+		return t.Kind() != types.Invalid
+	case *types.Array:
+		return validType(t.Elem())
+	case *types.Struct:
+		for i := 0; i < t.NumFields(); i++ {
+			if !validType(t.Field(i).Type()) {
+				return false
+			}
+		}
+		return true
+	case *types.Named:
+		return validType(t.Underlying())
+	case *types.TypeParam:
+		return false
+	}
+	return true
+}
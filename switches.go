@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"go/types"
+	"sort"
+
+	"github.com/kobi2187/go2json/astjson"
+)
+
+// switchReport lists the switch, type switch and select statements of the
+// functions in file with the cases they handle. With type information, a switch
+// over a named type lists the constants declared with that type as its possible
+// values, and a type switch over a named interface the types of the interface's
+// package implementing it; Missing holds those no case handles.
+func switchReport(fset *token.FileSet, info *types.Info, file *ast.File) []*astjson.Switch {
+	switches := []*astjson.Switch{}
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+		name := fn.Name.Name
+		if recv := receiverTypeName(fn.Recv); recv != "" {
+			name = recv + "." + name
+		}
+		var qualifier types.Qualifier
+		if info != nil {
+			if obj := info.Defs[fn.Name]; obj != nil {
+				qualifier = types.RelativeTo(obj.Pkg())
+			}
+		}
+
+		ast.Inspect(fn.Body, func(node ast.Node) bool {
+			var sw *astjson.Switch
+			switch stmt := node.(type) {
+			case *ast.SwitchStmt:
+				sw = &astjson.Switch{Kind: "switch", Cases: []string{}}
+				if stmt.Tag != nil {
+					sw.Tag = types.ExprString(stmt.Tag)
+				}
+				var handled []ast.Expr
+				for _, clause := range stmt.Body.List {
+					cc := clause.(*ast.CaseClause)
+					if cc.List == nil {
+						sw.Default = true
+					}
+					for _, expr := range cc.List {
+						sw.Cases = append(sw.Cases, types.ExprString(expr))
+						handled = append(handled, expr)
+					}
+				}
+				if info != nil && stmt.Tag != nil {
+					constantCases(sw, info, qualifier, stmt.Tag, handled)
+				}
+			case *ast.TypeSwitchStmt:
+				sw = &astjson.Switch{Kind: "type_switch", Cases: []string{}}
+				x := typeSwitchOperand(stmt)
+				sw.Tag = types.ExprString(x)
+				var handled []ast.Expr
+				for _, clause := range stmt.Body.List {
+					cc := clause.(*ast.CaseClause)
+					if cc.List == nil {
+						sw.Default = true
+					}
+					for _, expr := range cc.List {
+						sw.Cases = append(sw.Cases, types.ExprString(expr))
+						handled = append(handled, expr)
+					}
+				}
+				if info != nil {
+					implementationCases(sw, info, qualifier, x, handled)
+				}
+			case *ast.SelectStmt:
+				sw = &astjson.Switch{Kind: "select", Cases: []string{}}
+				for _, clause := range stmt.Body.List {
+					cc := clause.(*ast.CommClause)
+					if cc.Comm == nil {
+						sw.Default = true
+						continue
+					}
+					sw.Cases = append(sw.Cases, stmtString(fset, cc.Comm))
+				}
+			default:
+				return true
+			}
+			sw.Func, sw.Line = name, sourcePosition(fset, node.Pos()).Line
+			sw.Start, sw.End = sourceRange(fset, node)
+			switches = append(switches, sw)
+			return true
+		})
+	}
+	return switches
+}
+
+// typeSwitchOperand returns the expression x of a type switch on x.(type).
+func typeSwitchOperand(stmt *ast.TypeSwitchStmt) ast.Expr {
+	var assert ast.Expr
+	switch assign := stmt.Assign.(type) {
+	case *ast.AssignStmt:
+		assert = assign.Rhs[0]
+	case *ast.ExprStmt:
+		assert = assign.X
+	}
+	return assert.(*ast.TypeAssertExpr).X
+}
+
+// stmtString returns the source of a select case, a send or a receive that is
+// possibly assigned, as gofmt formats it.
+func stmtString(fset *token.FileSet, stmt ast.Stmt) string {
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, stmt); err != nil {
+		return ""
+	}
+	return buf.String()
+}
+
+// constantCases fills in the type, possible values and missing values of a
+// switch whose tag has a named type: the constants of that type declared in
+// its package.
+func constantCases(sw *astjson.Switch, info *types.Info, qualifier types.Qualifier, tag ast.Expr, handled []ast.Expr) {
+	tv, ok := info.Types[tag]
+	if !ok || tv.Type == nil {
+		return
+	}
+	sw.Type = types.TypeString(tv.Type, qualifier)
+	named, ok := tv.Type.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return
+	}
+
+	covered := make(map[types.Object]bool)
+	for _, expr := range handled {
+		switch e := ast.Unparen(expr).(type) {
+		case *ast.Ident:
+			covered[info.Uses[e]] = true
+		case *ast.SelectorExpr:
+			covered[info.Uses[e.Sel]] = true
+		}
+	}
+	scope := named.Obj().Pkg().Scope()
+	var consts []*types.Const
+	for _, name := range scope.Names() {
+		if c, ok := scope.Lookup(name).(*types.Const); ok && name != "_" && types.Identical(c.Type(), named) {
+			consts = append(consts, c)
+		}
+	}
+	// List constants in declaration order, as enumerations are read.
+	sort.Slice(consts, func(i, j int) bool { return consts[i].Pos() < consts[j].Pos() })
+	for _, c := range consts {
+		possible := qualifiedName(c, qualifier)
+		sw.Possible = append(sw.Possible, possible)
+		if !covered[c] {
+			sw.Missing = append(sw.Missing, possible)
+		}
+	}
+}
+
+// implementationCases fills in the type, possible types and missing types of a
+// type switch over a named interface: the named types of the interface's
+// package that implement it, directly or through a pointer.
+func implementationCases(sw *astjson.Switch, info *types.Info, qualifier types.Qualifier, x ast.Expr, handled []ast.Expr) {
+	tv, ok := info.Types[x]
+	if !ok || tv.Type == nil {
+		return
+	}
+	sw.Type = types.TypeString(tv.Type, qualifier)
+	named, ok := tv.Type.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return
+	}
+	iface, ok := named.Underlying().(*types.Interface)
+	if !ok {
+		return
+	}
+
+	var covered []types.Type
+	for _, expr := range handled {
+		if tv, ok := info.Types[expr]; ok && tv.Type != nil {
+			covered = append(covered, tv.Type)
+		}
+	}
+	isCovered := func(t types.Type) bool {
+		for _, c := range covered {
+			if types.Identical(c, t) {
+				return true
+			}
+		}
+		return false
+	}
+	scope := named.Obj().Pkg().Scope()
+	for _, name := range scope.Names() {
+		typeName, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok || typeName.IsAlias() || types.IsInterface(typeName.Type()) {
+			continue
+		}
+		if t, ok := typeName.Type().(*types.Named); ok && t.TypeParams().Len() > 0 {
+			continue
+		}
+		var impl types.Type
+		switch t := typeName.Type(); {
+		case types.Implements(t, iface):
+			impl = t
+		case types.Implements(types.NewPointer(t), iface):
+			impl = types.NewPointer(t)
+		default:
+			continue
+		}
+		possible := types.TypeString(impl, qualifier)
+		sw.Possible = append(sw.Possible, possible)
+		if !isCovered(impl) {
+			sw.Missing = append(sw.Missing, possible)
+		}
+	}
+}
+
+// qualifiedName returns the name of a package member as code in the package
+// described by qualifier refers to it.
+func qualifiedName(obj types.Object, qualifier types.Qualifier) string {
+	if qualifier != nil {
+		if prefix := qualifier(obj.Pkg()); prefix != "" {
+			return prefix + "." + obj.Name()
+		}
+		return obj.Name()
+	}
+	return obj.Pkg().Path() + "." + obj.Name()
+}
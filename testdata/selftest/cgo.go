@@ -0,0 +1,21 @@
+package cgostub
+
+/*
+#include <stdlib.h>
+#include <string.h>
+
+static int add(int a, int b) { return a + b; }
+*/
+import "C"
+
+import "unsafe"
+
+func Add(a, b int) int {
+	return int(C.add(C.int(a), C.int(b)))
+}
+
+func Length(s string) int {
+	cs := C.CString(s)
+	defer C.free(unsafe.Pointer(cs))
+	return int(C.strlen(cs))
+}
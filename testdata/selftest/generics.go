@@ -0,0 +1,59 @@
+package generics
+
+import "fmt"
+
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 | ~float32 | ~float64
+}
+
+type Pair[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+type List[T any] struct {
+	head *node[T]
+	size int
+}
+
+type IntList = List[int]
+
+type node[T any] struct {
+	value T
+	next  *node[T]
+}
+
+func (l *List[T]) Push(v T) {
+	l.head = &node[T]{value: v, next: l.head}
+	l.size++
+}
+
+func (l *List[_]) Len() int { return l.size }
+
+func Map[S ~[]E, E, R any](s S, f func(E) R) []R {
+	out := make([]R, 0, len(s))
+	for _, v := range s {
+		out = append(out, f(v))
+	}
+	return out
+}
+
+func Sum[T Number](values ...T) (total T) {
+	for _, v := range values {
+		total += v
+	}
+	return
+}
+
+type Tree[T interface{ Less(T) bool }] struct {
+	Left, Right *Tree[T]
+	Value       T
+}
+
+func use() {
+	var l List[Pair[string, []int]]
+	l.Push(Pair[string, []int]{"a", []int{1}})
+	fmt.Println(Sum(1, 2, 3), Sum[float64](1.5), Map([]int{1, 2}, func(i int) string { return fmt.Sprint(i) }))
+	f := Map[[]string, string, int]
+	_ = f
+}
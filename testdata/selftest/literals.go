@@ -0,0 +1,41 @@
+package literals
+
+const (
+	Hex      = 0xDead_Beef
+	Octal    = 0o755
+	OldOctal = 0755
+	Binary   = 0b1010_0101
+	Million  = 1_000_000
+	HexFloat = 0x1.8p-2
+	Exp      = 6.022e+23
+	Dot      = .5
+	Trailing = 5.
+	Imag     = 1i
+	HexImag  = 0x1p4i
+	Rune     = 'é'
+	Escape   = '\x7f'
+	Unicode  = '\U0001F600'
+	Quote    = '\''
+	Newline  = "line\n\ttab \"quoted\" é \xff"
+	Raw      = `raw \n string with "quotes"
+spanning lines`
+	Empty = ""
+)
+
+const (
+	A = iota * 10
+	B
+	_
+	D
+)
+
+var (
+	Elided   = [][]int{{1, 2}, {3}, {}}
+	Array    = [...]string{2: "c", 0: "a"}
+	Nested   = map[string][]struct{ X, Y int }{"p": {{1, 2}, {X: 3}}}
+	Pointers = []*struct{ N int }{{1}, {N: 2}}
+	Keys     = map[[2]int]string{{1, 2}: "a"}
+	Func     = func(x int) int { return x }(1)
+	Conv     = []byte("bytes")
+	Nil      = (*int)(nil)
+)
@@ -0,0 +1,82 @@
+package statements
+
+import (
+	"errors"
+	"sync"
+)
+
+type Shape interface {
+	Area() float64
+}
+
+type Square struct{ side float64 }
+
+func (s Square) Area() float64 { return s.side * s.side }
+
+type embedded struct {
+	sync.Mutex
+	*Square
+	Shape
+	tags string `json:"tags,omitempty"`
+}
+
+func Control(ch chan int, done <-chan struct{}, out chan<- error, shapes []Shape) (n int, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.New("recovered")
+		}
+	}()
+
+outer:
+	for i := 0; i < 10; i++ {
+		switch {
+		case i%2 == 0:
+			continue
+		case i > 7:
+			break outer
+		default:
+			fallthrough
+		case i == 3:
+			n++
+		}
+	}
+
+	for range 3 {
+		n--
+	}
+
+	for _, s := range shapes {
+		switch v := s.(type) {
+		case Square, *Square:
+			_ = v
+		case nil:
+			goto end
+		}
+	}
+
+	go func() { ch <- 1 }()
+	select {
+	case v, ok := <-ch:
+		if !ok {
+			return
+		}
+		n += v
+	case <-done:
+	case out <- nil:
+	default:
+	}
+
+	x := [3]int{1, 2, 3}
+	s := x[1:2:3]
+	p := &x
+	*p = [3]int{}
+	n, _ = len(s), cap(s)
+	n <<= 1
+	n &^= 2
+	{
+		n := -n
+		_ = ^n
+	}
+end:
+	return n, nil
+}
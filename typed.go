@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// typedPackage is a type-checked package together with the syntax trees of its files.
+type typedPackage struct {
+	fset  *token.FileSet
+	files map[string]*ast.File
+	pkg   *types.Package
+	info  *types.Info
+}
+
+// file returns the syntax tree of the given source file.
+func (p *typedPackage) file(sourceFilePath string) *ast.File {
+	return p.files[filepath.Clean(sourceFilePath)]
+}
+
+// typesInfo returns the type information of the package, or nil if p is nil
+// because typed mode is disabled.
+func (p *typedPackage) typesInfo() *types.Info {
+	if p == nil {
+		return nil
+	}
+	return p.info
+}
+
+// typedPackageCache holds the packages loaded for the folders most recently
+// visited, by folder, then by package name and test variant. Folders are
+// walked one at a time, but concurrent conversions reach the next folders
+// while the files of the previous ones are still converted, so the cache keeps
+// as many folders as there are conversion jobs and drops earlier ones to keep
+// memory bounded. mu guards the cache and is held while a package is checked,
+// so the files of a package converted concurrently check it only once.
+type typedPackageCache struct {
+	mu       sync.Mutex
+	dirs     []string // most recently visited last
+	packages map[string]map[string]*typedPackage
+}
+
+var typedCache = &typedPackageCache{packages: make(map[string]map[string]*typedPackage)}
+
+// folder returns the cached packages of dir, marking it the most recently
+// visited folder. c.mu must be held.
+func (c *typedPackageCache) folder(dir string) map[string]*typedPackage {
+	if i := slices.Index(c.dirs, dir); i >= 0 {
+		c.dirs = append(slices.Delete(c.dirs, i, i+1), dir)
+	} else {
+		c.dirs = append(c.dirs, dir)
+		c.packages[dir] = make(map[string]*typedPackage)
+	}
+	for len(c.dirs) > conversionJobs() {
+		delete(c.packages, c.dirs[0])
+		c.dirs = c.dirs[1:]
+	}
+	return c.packages[dir]
+}
+
+// loadTypedPackage type-checks the package the given source file belongs to.
+// The package consists of the files in the same folder with the same package
+// name that match the current build context; test files are only included when
+// the source file is a test file itself. Type errors are logged and otherwise
+// ignored, so partially typed packages still produce output. Type checking
+// that has not finished by deadline, unless it is zero, fails with an
+// untrustedLimitError.
+func loadTypedPackage(sourceFilePath string, deadline time.Time) (*typedPackage, error) {
+	sourceFilePath = filepath.Clean(sourceFilePath)
+	dir := filepath.Dir(sourceFilePath)
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, sourceFilePath, nil, parser.PackageClauseOnly)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing Go source file %s: %w", sourceFilePath, err)
+	}
+	isTest := strings.HasSuffix(sourceFilePath, "_test.go")
+	key := fmt.Sprintf("%s/%t", file.Name.Name, isTest)
+
+	typedCache.mu.Lock()
+	defer typedCache.mu.Unlock()
+	cached := typedCache.folder(dir)
+	if pkg, ok := cached[key]; ok && pkg.file(sourceFilePath) != nil {
+		return pkg, nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading folder %s: %w", dir, err)
+	}
+
+	pkg := &typedPackage{fset: token.NewFileSet(), files: make(map[string]*ast.File)}
+	var files []*ast.File
+	for _, entry := range entries {
+		name := entry.Name()
+		path := filepath.Join(dir, name)
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") {
+			continue
+		}
+		if !isTest && strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		if path != sourceFilePath {
+			if match, err := build.Default.MatchFile(dir, name); err != nil || !match {
+				continue
+			}
+		}
+		f, err := parser.ParseFile(pkg.fset, path, nil, conversionOptions().ParserMode())
+		if err != nil {
+			if path == sourceFilePath {
+				return nil, fmt.Errorf("error parsing Go source file %s: %w", path, err)
+			}
+			slog.Debug("skipping unparsable package file", "file", path, "error", err)
+			continue
+		}
+		if f.Name.Name != file.Name.Name {
+			continue
+		}
+		pkg.files[path] = f
+		files = append(files, f)
+	}
+
+	pkg.info = &types.Info{
+		Types:      make(map[ast.Expr]types.TypeAndValue),
+		Instances:  make(map[*ast.Ident]types.Instance),
+		Defs:       make(map[*ast.Ident]types.Object),
+		Uses:       make(map[*ast.Ident]types.Object),
+		Implicits:  make(map[ast.Node]types.Object),
+		Selections: make(map[*ast.SelectorExpr]*types.Selection),
+		Scopes:     make(map[ast.Node]*types.Scope),
+	}
+	conf := types.Config{
+		GoVersion: *langVersion,
+		Importer:  importer.Default(),
+		Error: func(err error) {
+			slog.Debug("type error", "package", dir, "error", err)
+		},
+	}
+	// The package is only cached once it is checked, so a check given up
+	// on by runUntil changes no shared state.
+	pkg.pkg, err = runUntil(sourceFilePath, deadline, func() (*types.Package, error) {
+		checked, _ := conf.Check(file.Name.Name, pkg.fset, files, pkg.info)
+		return checked, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cached[key] = pkg
+	return pkg, nil
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"os"
+	"time"
+)
+
+// -untrusted limits what converting a single input file can cost, for inputs
+// from untrusted sources. It does not limit how often conversions are
+// requested: go2json has no server mode, and a service running it on requests
+// has to rate limit them itself.
+var untrustedMode = flag.Bool("untrusted", false, "enforce hard limits on every input file, set with the -untrusted-max flags")
+
+// Limits enforced per input file under -untrusted.
+var (
+	untrustedMaxSize  = byteSize(1 << 20)
+	untrustedMaxNodes = flag.Int("untrusted-max-nodes", 200_000, "maximum number of nodes of a file under -untrusted")
+	untrustedMaxDepth = flag.Int("untrusted-max-depth", 500, "maximum nesting depth of the nodes of a file under -untrusted")
+	untrustedMaxTime  = flag.Duration("untrusted-max-time", 5*time.Second, "time limit for parsing, type checking and converting a file under -untrusted")
+)
+
+func init() {
+	flag.Var(&untrustedMaxSize, "untrusted-max-size", "maximum size of the source of a file under -untrusted, such as 512KiB or 2MiB")
+}
+
+// untrustedLimitError reports an input exceeding a limit of -untrusted. It is
+// raised as a panic where the conversion cannot return an error, and turned
+// back into an error by isolateFile.
+type untrustedLimitError struct {
+	path  string
+	limit string
+}
+
+func (e *untrustedLimitError) Error() string {
+	return fmt.Sprintf("%s exceeds the -untrusted limit of %s", e.path, e.limit)
+}
+
+// checkUntrustedSize rejects a source of size bytes under -untrusted if it is
+// too large.
+func checkUntrustedSize(path string, size int64) error {
+	if *untrustedMode && size > int64(untrustedMaxSize) {
+		return &untrustedLimitError{path: path, limit: fmt.Sprintf("%d bytes", untrustedMaxSize)}
+	}
+	return nil
+}
+
+// checkUntrustedFileSize is like checkUntrustedSize for a file on disk, which
+// it checks before the file is read.
+func checkUntrustedFileSize(path string) error {
+	if !*untrustedMode {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("error reading Go source file %s: %w", path, err)
+	}
+	return checkUntrustedSize(path, info.Size())
+}
+
+// checkUntrustedTree rejects a syntax tree under -untrusted if it has too many
+// nodes or nests them too deeply.
+func checkUntrustedTree(path string, file *ast.File) error {
+	if !*untrustedMode {
+		return nil
+	}
+	nodes, depth := 0, 0
+	var err error
+	ast.Inspect(file, func(n ast.Node) bool {
+		if n == nil {
+			depth--
+			return false
+		}
+		nodes++
+		depth++
+		switch {
+		case nodes > *untrustedMaxNodes:
+			err = &untrustedLimitError{path: path, limit: fmt.Sprintf("%d nodes", *untrustedMaxNodes)}
+		case depth > *untrustedMaxDepth:
+			err = &untrustedLimitError{path: path, limit: fmt.Sprintf("%d levels of nesting", *untrustedMaxDepth)}
+		}
+		return err == nil
+	})
+	return err
+}
+
+// untrustedDeadline returns the time by which the conversion of a file started
+// now has to finish under -untrusted, or the zero time if there is no limit.
+func untrustedDeadline() time.Time {
+	if !*untrustedMode {
+		return time.Time{}
+	}
+	return time.Now().Add(*untrustedMaxTime)
+}
+
+// untrustedTimeError reports that the conversion of path ran out of time.
+func untrustedTimeError(path string) error {
+	return &untrustedLimitError{path: path, limit: untrustedMaxTime.String() + " of conversion"}
+}
+
+// runUntil runs fn for the file path and returns its results, or an
+// untrustedLimitError if fn has not returned by deadline, a zero deadline
+// meaning no limit. go/parser and go/types cannot be interrupted, so fn keeps
+// running after a timeout, but its results are dropped: it must not change
+// state shared with later conversions.
+func runUntil[T any](path string, deadline time.Time, fn func() (T, error)) (T, error) {
+	if deadline.IsZero() {
+		return fn()
+	}
+	type result struct {
+		value T
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		value, err := fn()
+		done <- result{value, err}
+	}()
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	select {
+	case r := <-done:
+		return r.value, r.err
+	case <-timer.C:
+		var zero T
+		return zero, untrustedTimeError(path)
+	}
+}
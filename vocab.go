@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+var identVocabulary = &vocabularyCollector{packages: make(map[vocabularyKey]map[string]int)}
+
+// vocabularyKey identifies a package: its directory and name, since external test
+// packages share a directory with the package they test.
+type vocabularyKey struct {
+	dir  string
+	name string
+}
+
+// vocabularyCollector counts identifier tokens per package during a run.
+type vocabularyCollector struct {
+	packages map[vocabularyKey]map[string]int
+}
+
+// VocabularyReport is the result of -vocab.
+type VocabularyReport struct {
+	Split    bool                 `json:"split"`
+	Tokens   []*TokenCount        `json:"tokens"`
+	Packages []*PackageVocabulary `json:"packages"`
+}
+
+// PackageVocabulary holds the token frequencies of one package.
+type PackageVocabulary struct {
+	Dir    string        `json:"dir"`
+	Name   string        `json:"name"`
+	Tokens []*TokenCount `json:"tokens"`
+}
+
+// TokenCount is the number of occurrences of an identifier or subtoken.
+type TokenCount struct {
+	Token string `json:"token"`
+	Count int    `json:"count"`
+}
+
+// record counts the identifiers of file, split into subtokens if -vocab-split is set.
+// The blank identifier is not counted.
+func (c *vocabularyCollector) record(sourceFilePath string, file *ast.File) {
+	key := vocabularyKey{dir: filepath.Dir(sourceFilePath), name: file.Name.Name}
+	counts := c.packages[key]
+	if counts == nil {
+		counts = make(map[string]int)
+		c.packages[key] = counts
+	}
+	ast.Inspect(file, func(node ast.Node) bool {
+		ident, ok := node.(*ast.Ident)
+		if !ok || ident.Name == "_" {
+			return true
+		}
+		if !*vocabSplit {
+			counts[ident.Name]++
+			return true
+		}
+		for _, token := range splitIdentifier(ident.Name) {
+			counts[token]++
+		}
+		return true
+	})
+}
+
+// report sums the package counts into repository-wide totals.
+func (c *vocabularyCollector) report() *VocabularyReport {
+	report := &VocabularyReport{Split: *vocabSplit, Packages: []*PackageVocabulary{}}
+	total := make(map[string]int)
+	for key, counts := range c.packages {
+		for token, count := range counts {
+			total[token] += count
+		}
+		report.Packages = append(report.Packages, &PackageVocabulary{
+			Dir:    key.dir,
+			Name:   key.name,
+			Tokens: tokenCounts(counts),
+		})
+	}
+	report.Tokens = tokenCounts(total)
+	sort.Slice(report.Packages, func(i, j int) bool {
+		a, b := report.Packages[i], report.Packages[j]
+		if a.Dir != b.Dir {
+			return a.Dir < b.Dir
+		}
+		return a.Name < b.Name
+	})
+	return report
+}
+
+// write saves the report to path.
+func (c *vocabularyCollector) write(path string) error {
+	outputFile, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("error creating vocabulary report %s: %w", path, err)
+	}
+	defer outputFile.Close()
+	return encodeAST(outputFile, c.report(), "  ")
+}
+
+// tokenCounts orders counts by decreasing frequency, then by token.
+func tokenCounts(counts map[string]int) []*TokenCount {
+	tokens := make([]*TokenCount, 0, len(counts))
+	for token, count := range counts {
+		tokens = append(tokens, &TokenCount{Token: token, Count: count})
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		if tokens[i].Count != tokens[j].Count {
+			return tokens[i].Count > tokens[j].Count
+		}
+		return tokens[i].Token < tokens[j].Token
+	})
+	return tokens
+}
+
+// splitIdentifier splits name into lower-case subtokens at underscores and
+// camelCase boundaries, keeping acronyms together: "parseHTTPRequest_v2" yields
+// parse, http, request and v2.
+func splitIdentifier(name string) []string {
+	var tokens []string
+	for _, part := range strings.Split(name, "_") {
+		runes := []rune(part)
+		start := 0
+		for i := 1; i < len(runes); i++ {
+			prev, cur := runes[i-1], runes[i]
+			lowerToUpper := !unicode.IsUpper(prev) && unicode.IsUpper(cur)
+			acronymEnd := unicode.IsUpper(prev) && unicode.IsUpper(cur) &&
+				i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if lowerToUpper || acronymEnd {
+				tokens = append(tokens, strings.ToLower(string(runes[start:i])))
+				start = i
+			}
+		}
+		if start < len(runes) {
+			tokens = append(tokens, strings.ToLower(string(runes[start:])))
+		}
+	}
+	return tokens
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/kobi2187/go2json/astjson"
+)
+
+// workspaceManifest describes the modules of a go.work workspace converted in
+// one run. Module folders are relative to the folder holding go.work.
+type workspaceManifest struct {
+	Go      string             `json:"go,omitempty"`
+	Modules []*workspaceModule `json:"modules"`
+}
+
+// workspaceModule is a module used by a workspace. Path is empty if the folder
+// has no go.mod declaring a module path.
+type workspaceModule struct {
+	Path   string `json:"path,omitempty"`
+	Dir    string `json:"dir"`
+	Files  int    `json:"files"`
+	Failed int    `json:"failed,omitempty"`
+}
+
+// workspaceFile returns the go.work file governing a folder given on the
+// command line, or "" if there is none or workspaces are disabled with
+// GOWORK=off. Only the folder itself is checked, so converting a single module
+// of a workspace is unaffected.
+func workspaceFile(dir string) string {
+	if os.Getenv("GOWORK") == "off" {
+		return ""
+	}
+	path := filepath.Join(dir, "go.work")
+	if info, err := os.Stat(path); err != nil || info.IsDir() {
+		return ""
+	}
+	return path
+}
+
+// parseWorkFile returns the go version and the module folders listed by the
+// use directives of a go.work file, in order of appearance.
+func parseWorkFile(gowork []byte) (goVersion string, uses []string) {
+	scanner := bufio.NewScanner(bytes.NewReader(gowork))
+	inUse := false
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		if inUse {
+			if line == ")" {
+				inUse = false
+			} else if line != "" {
+				uses = append(uses, unquoteWorkPath(line))
+			}
+			continue
+		}
+		verb, rest, _ := strings.Cut(line, " ")
+		rest = strings.TrimSpace(rest)
+		switch verb {
+		case "go":
+			goVersion = rest
+		case "use":
+			if rest == "(" {
+				inUse = true
+			} else if rest != "" {
+				uses = append(uses, unquoteWorkPath(rest))
+			}
+		}
+	}
+	return goVersion, uses
+}
+
+// unquoteWorkPath returns a go.work path, which may be quoted.
+func unquoteWorkPath(path string) string {
+	if unquoted, err := strconv.Unquote(path); err == nil {
+		return unquoted
+	}
+	return path
+}
+
+// processWorkspace converts the modules used by the go.work file in dir, with
+// output paths relative to dir, and writes a manifest of the modules to
+// manifestPath, or to go.work.json next to go.work if it is empty. Folders of
+// a module that belong to another module of the workspace are left to that
+// module. Like in folder mode, failures are reported once every module has
+// been visited.
+func processWorkspace(dir, manifestPath string) error {
+	goworkPath := filepath.Join(dir, "go.work")
+	gowork, err := os.ReadFile(goworkPath)
+	if err != nil {
+		return fmt.Errorf("error reading workspace file %s: %w", goworkPath, err)
+	}
+	goVersion, uses := parseWorkFile(gowork)
+	if len(uses) == 0 {
+		return fmt.Errorf("workspace file %s uses no modules", goworkPath)
+	}
+
+	moduleDirs := make(map[string]bool)
+	for _, use := range uses {
+		moduleDirs[filepath.Join(dir, filepath.FromSlash(use))] = true
+	}
+
+	manifest := &workspaceManifest{Go: goVersion, Modules: []*workspaceModule{}}
+	var failures []fileFailure
+	total := 0
+	for _, use := range uses {
+		moduleDir := filepath.Join(dir, filepath.FromSlash(use))
+		module := &workspaceModule{Dir: filepath.ToSlash(filepath.Clean(use))}
+		if abs, err := filepath.Abs(moduleDir); err == nil {
+			if found := astjson.FindModule(abs); found != nil && found.Dir == abs {
+				module.Path = found.Path
+			}
+		}
+		if module.Path == "" {
+			slog.Warn("workspace folder has no go.mod", "dir", moduleDir)
+		}
+		manifest.Modules = append(manifest.Modules, module)
+
+		err := filepath.Walk(moduleDir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				if path != moduleDir && moduleDirs[path] {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !strings.HasSuffix(info.Name(), ".go") || !matchesTestsMode(info.Name()) {
+				return nil
+			}
+			module.Files++
+			if err := isolateFile(path, func(path string) error { return processFile(dir, path) }); err != nil {
+				slog.Error("error processing file", "file", path, "error", err)
+				failures = append(failures, fileFailure{path: path, err: err})
+				module.Failed++
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("error processing workspace module %s: %w", moduleDir, err)
+		}
+		total += module.Files
+	}
+
+	if manifestPath == "" {
+		manifestPath = goworkPath + ".json"
+	}
+	manifestFile, err := os.Create(manifestPath)
+	if err != nil {
+		return fmt.Errorf("error creating workspace manifest %s: %w", manifestPath, err)
+	}
+	defer manifestFile.Close()
+	if err := encodeAST(manifestFile, manifest, "  "); err != nil {
+		return fmt.Errorf("error writing workspace manifest %s: %w", manifestPath, err)
+	}
+	slog.Info("workspace manifest generated", "workspace", goworkPath, "output", manifestPath)
+
+	if len(failures) > 0 {
+		for _, failure := range failures {
+			slog.Error("failed file", "file", failure.path, "error", failure.err)
+		}
+		slog.Error("conversion finished with errors", "failed", len(failures), "total", total)
+		return fmt.Errorf("%d of %d files in workspace %s failed to convert", len(failures), total, dir)
+	}
+	return nil
+}